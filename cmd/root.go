@@ -9,6 +9,7 @@ import (
 
 	"github.com/ccdcoe/go-peek/pkg/ingest"
 	"github.com/ccdcoe/go-peek/pkg/ingest/logfile"
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
 	"github.com/ccdcoe/go-peek/pkg/models/events"
 	"github.com/ccdcoe/go-peek/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -121,6 +122,17 @@ func initStreamConfig() {
 		)
 
 	}
+	rootCmd.PersistentFlags().String("stream-zeek-log", "",
+		fmt.Sprintf(`Zeek log type for records without _path field, e.g. conn or dns. If empty, log type is guessed from source file or kafka topic name. Supported logs are %s.`, strings.Join(atomic.ZeekPaths, ", ")))
+	viper.BindPFlag("stream.zeek.log", rootCmd.PersistentFlags().Lookup("stream-zeek-log"))
+
+	rootCmd.PersistentFlags().Int("stream-auditd-pending", 10000,
+		`Maximum number of audit events waiting for their end of event record. Oldest are processed incomplete when exceeded.`)
+	viper.BindPFlag("stream.auditd.pending", rootCmd.PersistentFlags().Lookup("stream-auditd-pending"))
+
+	rootCmd.PersistentFlags().Duration("stream-auditd-timeout", 5*time.Second,
+		`How long audit event waits for its end of event record. Measured in event time.`)
+	viper.BindPFlag("stream.auditd.timeout", rootCmd.PersistentFlags().Lookup("stream-auditd-timeout"))
 }
 
 func initInputConfig() {
@@ -173,9 +185,17 @@ func initProcessorConfig() {
 	viper.BindPFlag("processor.compat.logstash", rootCmd.PersistentFlags().Lookup("processor-compat-logstash"))
 
 	rootCmd.PersistentFlags().Bool("processor-anonymize", false,
-		`Anonymize messages. Simple method by replacing host names with aliases`)
+		`Anonymize messages. Host names, addresses, user names and domains are replaced with asset aliases or keyed pseudonyms.`)
 	viper.BindPFlag("processor.anonymize", rootCmd.PersistentFlags().Lookup("processor-anonymize"))
 
+	rootCmd.PersistentFlags().String("processor-anonymization-key", "",
+		`Secret for keyed pseudonyms. Takes precedence over key file. Pseudonyms stay the same only as long as the key does.`)
+	viper.BindPFlag("processor.anonymization.key", rootCmd.PersistentFlags().Lookup("processor-anonymization-key"))
+
+	rootCmd.PersistentFlags().String("processor-anonymization-keyfile", "anonymize.key",
+		`File with hex encoded pseudonym secret. Generated if missing. Relative path is resolved against --work-dir.`)
+	viper.BindPFlag("processor.anonymization.keyfile", rootCmd.PersistentFlags().Lookup("processor-anonymization-keyfile"))
+
 	rootCmd.PersistentFlags().Bool("processor-inputs-wise-enabled", false,
 		`Enable or disable WISE asset lookups.`)
 	viper.BindPFlag("processor.inputs.wise.enabled", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-enabled"))
@@ -184,6 +204,164 @@ func initProcessorConfig() {
 		`Remote Moloch WISE host that holds asset and IOC information.`)
 	viper.BindPFlag("processor.inputs.wise.host", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-host"))
 
+	rootCmd.PersistentFlags().StringSlice("processor-inputs-inventory-path", []string{},
+		`Local asset inventory files. Loaded into asset cache on startup. `+
+			`Supported formats are CSV with header, YAML, JSON lines (including asset dump) and SaltStack grains JSON. `+
+			`Format is decided by file extension.`)
+	viper.BindPFlag("processor.inputs.inventory.path", rootCmd.PersistentFlags().Lookup("processor-inputs-inventory-path"))
+
+	rootCmd.PersistentFlags().Bool("processor-inputs-inventory-watch", false,
+		`Periodically check inventory files for modifications and reload if changed.`)
+	viper.BindPFlag("processor.inputs.inventory.watch", rootCmd.PersistentFlags().Lookup("processor-inputs-inventory-watch"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-inventory-ttl", 1*time.Minute,
+		`How long assets resolved from inventory files are cached. Reloaded files take effect after this period.`)
+	viper.BindPFlag("processor.inputs.inventory.ttl", rootCmd.PersistentFlags().Lookup("processor-inputs-inventory-ttl"))
+
+	rootCmd.PersistentFlags().String("processor-inputs-networks-path", "",
+		`Exercise network table for segment enrichment. `+
+			`Pandas to_json() export or CSV with Name, VLAN/Portgroup, IPv4, IPv6 and Team columns.`)
+	viper.BindPFlag("processor.inputs.networks.path", rootCmd.PersistentFlags().Lookup("processor-inputs-networks-path"))
+
+	rootCmd.PersistentFlags().Duration("processor-assets-negative-ttl", 2*time.Minute,
+		`How long a failed asset lookup is remembered before asking providers again.`)
+	viper.BindPFlag("processor.assets.negative.ttl", rootCmd.PersistentFlags().Lookup("processor-assets-negative-ttl"))
+
+	rootCmd.PersistentFlags().String("processor-persist-json-assets", "",
+		`File for periodically dumping known assets. Dump is loaded on startup for warm start. `+
+			`Relative path is resolved against --work-dir. Empty value disables persistence.`)
+	viper.BindPFlag("processor.persist.json.assets", rootCmd.PersistentFlags().Lookup("processor-persist-json-assets"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-wise-ttl", 10*time.Minute,
+		`How long assets resolved from WISE are cached.`)
+	viper.BindPFlag("processor.inputs.wise.ttl", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-ttl"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-wise-timeout", 500*time.Millisecond,
+		`Timeout for a single WISE query. Queries are done in background and never block event processing.`)
+	viper.BindPFlag("processor.inputs.wise.timeout", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-timeout"))
+
+	rootCmd.PersistentFlags().Int("processor-inputs-wise-breaker-threshold", 5,
+		`Consecutive failed WISE queries before lookups are suspended.`)
+	viper.BindPFlag("processor.inputs.wise.breaker.threshold", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-breaker-threshold"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-wise-breaker-cooldown", 30*time.Second,
+		`How long WISE lookups are suspended before probing again.`)
+	viper.BindPFlag("processor.inputs.wise.breaker.cooldown", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-breaker-cooldown"))
+
+	rootCmd.PersistentFlags().Bool("processor-inputs-wise-intel-enabled", false,
+		`Look up domains, URLs and file hashes from Suricata and Sysmon events in WISE. `+
+			`Observables are resolved in background, so only repeated occurrences are enriched.`)
+	viper.BindPFlag("processor.inputs.wise.intel.enabled", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-intel-enabled"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-wise-intel-ttl", 10*time.Minute,
+		`How long WISE threat intel responses are cached.`)
+	viper.BindPFlag("processor.inputs.wise.intel.ttl", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-intel-ttl"))
+
+	rootCmd.PersistentFlags().String("processor-inputs-wise-prefix", "peek",
+		`WISE field prefix. Threat intel matches are stored in GameMeta under this key, with prefix stripped from field names.`)
+	viper.BindPFlag("processor.inputs.wise.prefix", rootCmd.PersistentFlags().Lookup("processor-inputs-wise-prefix"))
+
+	rootCmd.PersistentFlags().StringSlice("processor-inputs-ioc-path", []string{},
+		`Indicator lists for IOC matching. Plain text with one indicator per line, CSV with value and optional type column, `+
+			`MISP JSON export or STIX 2 bundle. Matched events are sent to emit outputs.`)
+	viper.BindPFlag("processor.inputs.ioc.path", rootCmd.PersistentFlags().Lookup("processor-inputs-ioc-path"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-ioc-interval", 30*time.Second,
+		`Interval for checking indicator lists for modifications. Zero disables reloading.`)
+	viper.BindPFlag("processor.inputs.ioc.interval", rootCmd.PersistentFlags().Lookup("processor-inputs-ioc-interval"))
+
+	rootCmd.PersistentFlags().String("processor-inputs-geoip-city", "",
+		`GeoLite2 or GeoIP2 City mmdb file for enriching external addresses with location.`)
+	viper.BindPFlag("processor.inputs.geoip.city", rootCmd.PersistentFlags().Lookup("processor-inputs-geoip-city"))
+
+	rootCmd.PersistentFlags().String("processor-inputs-geoip-asn", "",
+		`GeoLite2 ASN mmdb file for enriching external addresses with autonomous system number and organization.`)
+	viper.BindPFlag("processor.inputs.geoip.asn", rootCmd.PersistentFlags().Lookup("processor-inputs-geoip-asn"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-geoip-interval", 1*time.Minute,
+		`Interval for checking mmdb files for updates. Zero disables reloading.`)
+	viper.BindPFlag("processor.inputs.geoip.interval", rootCmd.PersistentFlags().Lookup("processor-inputs-geoip-interval"))
+
+	rootCmd.PersistentFlags().Bool("processor-pdns-enabled", false,
+		`Build passive DNS table from Suricata DNS answers and attach resolved names to event source and destination.`)
+	viper.BindPFlag("processor.pdns.enabled", rootCmd.PersistentFlags().Lookup("processor-pdns-enabled"))
+
+	rootCmd.PersistentFlags().Int("processor-pdns-size", 100000,
+		`Maximum number of addresses in passive DNS table. Least recently resolved are evicted first.`)
+	viper.BindPFlag("processor.pdns.size", rootCmd.PersistentFlags().Lookup("processor-pdns-size"))
+
+	rootCmd.PersistentFlags().Duration("processor-pdns-ttl", 24*time.Hour,
+		`How long passive DNS binding is kept after it was last seen. Measured in event time.`)
+	viper.BindPFlag("processor.pdns.ttl", rootCmd.PersistentFlags().Lookup("processor-pdns-ttl"))
+
+	rootCmd.PersistentFlags().String("processor-persist-json-pdns", "pdns.json",
+		`Passive DNS dump file. Relative path is resolved against --work-dir. Empty value disables persistence.`)
+	viper.BindPFlag("processor.persist.json.pdns", rootCmd.PersistentFlags().Lookup("processor-persist-json-pdns"))
+
+	rootCmd.PersistentFlags().Bool("processor-proctree-enabled", false,
+		`Build per-host process table from Sysmon process events and snoopy commands and attach process ancestors to events.`)
+	viper.BindPFlag("processor.proctree.enabled", rootCmd.PersistentFlags().Lookup("processor-proctree-enabled"))
+
+	rootCmd.PersistentFlags().Int("processor-proctree-size", 100000,
+		`Maximum number of processes in process table. Least recently seen are evicted first.`)
+	viper.BindPFlag("processor.proctree.size", rootCmd.PersistentFlags().Lookup("processor-proctree-size"))
+
+	rootCmd.PersistentFlags().Int("processor-proctree-depth", 16,
+		`Maximum number of ancestors attached to event.`)
+	viper.BindPFlag("processor.proctree.depth", rootCmd.PersistentFlags().Lookup("processor-proctree-depth"))
+
+	rootCmd.PersistentFlags().Duration("processor-proctree-retention", 1*time.Hour,
+		`How long terminated processes and idle snoopy sessions are kept. Measured in event time.`)
+	viper.BindPFlag("processor.proctree.retention", rootCmd.PersistentFlags().Lookup("processor-proctree-retention"))
+
+	rootCmd.PersistentFlags().String("processor-persist-json-proctree", "proctree.json",
+		`Process table dump file. Relative path is resolved against --work-dir. Empty value disables persistence.`)
+	viper.BindPFlag("processor.persist.json.proctree", rootCmd.PersistentFlags().Lookup("processor-persist-json-proctree"))
+
+	rootCmd.PersistentFlags().Bool("processor-dhcp-enabled", false,
+		`Learn address bindings from Suricata dhcp events and resolve event addresses to host that held the lease at event time.`)
+	viper.BindPFlag("processor.dhcp.enabled", rootCmd.PersistentFlags().Lookup("processor-dhcp-enabled"))
+
+	rootCmd.PersistentFlags().Bool("processor-dhcp-syslog", false,
+		`Also learn leases from ISC dhcpd syslog messages. Lease time is not logged, so processor-dhcp-lease is assumed.`)
+	viper.BindPFlag("processor.dhcp.syslog", rootCmd.PersistentFlags().Lookup("processor-dhcp-syslog"))
+
+	rootCmd.PersistentFlags().Duration("processor-dhcp-lease", 24*time.Hour,
+		`Lease duration when log does not contain one.`)
+	viper.BindPFlag("processor.dhcp.lease", rootCmd.PersistentFlags().Lookup("processor-dhcp-lease"))
+
+	rootCmd.PersistentFlags().Duration("processor-dhcp-retention", 7*24*time.Hour,
+		`How long expired leases are kept for resolving delayed events. Measured in event time.`)
+	viper.BindPFlag("processor.dhcp.retention", rootCmd.PersistentFlags().Lookup("processor-dhcp-retention"))
+
+	rootCmd.PersistentFlags().String("processor-persist-json-dhcp", "dhcp.json",
+		`DHCP lease dump file. Relative path is resolved against --work-dir. Empty value disables persistence.`)
+	viper.BindPFlag("processor.persist.json.dhcp", rootCmd.PersistentFlags().Lookup("processor-persist-json-dhcp"))
+
+	rootCmd.PersistentFlags().String("processor-ecs-original", "original",
+		`Key for keeping original event in ECS view. Empty value drops original.`)
+	viper.BindPFlag("processor.ecs.original", rootCmd.PersistentFlags().Lookup("processor-ecs-original"))
+
+	rootCmd.PersistentFlags().Bool("processor-inputs-redis-assets-enabled", false,
+		`Enable asset lookups from redis hashes. Hash fields are host, alias, os and vm.`)
+	viper.BindPFlag("processor.inputs.redis.assets.enabled", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-assets-enabled"))
+
+	rootCmd.PersistentFlags().String("processor-inputs-redis-assets-prefix", "asset:",
+		`Key prefix for redis asset hashes. Address or host name is appended.`)
+	viper.BindPFlag("processor.inputs.redis.assets.prefix", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-assets-prefix"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-redis-assets-ttl", 5*time.Minute,
+		`How long assets resolved from redis are cached.`)
+	viper.BindPFlag("processor.inputs.redis.assets.ttl", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-assets-ttl"))
+
+	rootCmd.PersistentFlags().Int("processor-inputs-redis-assets-breaker-threshold", 5,
+		`Consecutive failed redis asset queries before lookups are suspended.`)
+	viper.BindPFlag("processor.inputs.redis.assets.breaker.threshold", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-assets-breaker-threshold"))
+
+	rootCmd.PersistentFlags().Duration("processor-inputs-redis-assets-breaker-cooldown", 30*time.Second,
+		`How long redis asset lookups are suspended before probing again.`)
+	viper.BindPFlag("processor.inputs.redis.assets.breaker.cooldown", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-assets-breaker-cooldown"))
+
 	rootCmd.PersistentFlags().String("processor-inputs-redis-host", "localhost",
 		`Redis host for collecting asset and threat intel.`)
 	viper.BindPFlag("processor.inputs.redis.host", rootCmd.PersistentFlags().Lookup("processor-inputs-redis-host"))
@@ -211,8 +389,31 @@ func initProcessorConfig() {
 	viper.BindPFlag("processor.sigma.quickmatch", rootCmd.PersistentFlags().Lookup("processor-sigma-quickmatch"))
 
 	rootCmd.PersistentFlags().String("processor-mitre-technique-json", "",
-		`JSON file containing MITRE att&ck ID to technique and phase mapping.`)
+		`JSON file containing MITRE att&ck ID to technique and phase mapping. `+
+			`Either enterprise-attack.json STIX 2 bundle from mitre/cti repository or pre-digested ID to technique map.`)
 	viper.BindPFlag("processor.mitre.technique.json", rootCmd.PersistentFlags().Lookup("processor-mitre-technique-json"))
+
+	rootCmd.PersistentFlags().Bool("emit-alerts-enabled", false,
+		`Send compact alert documents to emit outputs, one per matched rule, instead of whole events.`)
+	viper.BindPFlag("emit.alerts.enabled", rootCmd.PersistentFlags().Lookup("emit-alerts-enabled"))
+
+	rootCmd.PersistentFlags().Duration("emit-alerts-suppress-window", 5*time.Minute,
+		`Repeated hits of a rule are suppressed for this long after first alert and reported as a single summary when window closes. `+
+			`Measured in event time. Zero disables suppression.`)
+	viper.BindPFlag("emit.alerts.suppress.window", rootCmd.PersistentFlags().Lookup("emit-alerts-suppress-window"))
+
+	rootCmd.PersistentFlags().String("emit-alerts-suppress-key", "host",
+		`What makes hits of a rule repeated. Either host, or pair for source and destination. `+
+			`Events without both endpoints fall back to host.`)
+	viper.BindPFlag("emit.alerts.suppress.key", rootCmd.PersistentFlags().Lookup("emit-alerts-suppress-key"))
+
+	rootCmd.PersistentFlags().Int("emit-alerts-suppress-size", 100000,
+		`Maximum number of open suppression windows. Oldest are closed first.`)
+	viper.BindPFlag("emit.alerts.suppress.size", rootCmd.PersistentFlags().Lookup("emit-alerts-suppress-size"))
+
+	rootCmd.PersistentFlags().Duration("emit-alerts-suppress-interval", 10*time.Second,
+		`How often closed suppression windows are checked for summaries when no new alerts arrive.`)
+	viper.BindPFlag("emit.alerts.suppress.interval", rootCmd.PersistentFlags().Lookup("emit-alerts-suppress-interval"))
 }
 
 func initOutputConfig(prefix string) {
@@ -267,6 +468,18 @@ func initOutputConfig(prefix string) {
 		`Named pipe, or FIFO, for outputting event messages. Multiple outputs can be specified.`)
 	viper.BindPFlag(prefix+".fifo.path", rootCmd.PersistentFlags().Lookup(prefix+"-fifo-path"))
 
+	// views
+	rootCmd.PersistentFlags().String(prefix+"-view", "full",
+		`Default view for outputs. Builtin views are full, anonymized, meta, ecs and anonymized-ecs. `+
+			`Custom views with anonymized, ecs, meta, include and exclude keys can be defined in config file under `+prefix+`.views.<name>.`)
+	viper.BindPFlag(prefix+".view", rootCmd.PersistentFlags().Lookup(prefix+"-view"))
+
+	for _, output := range []string{"elastic", "kafka", "file", "fifo"} {
+		rootCmd.PersistentFlags().String(prefix+"-"+output+"-view", "",
+			`View for `+output+` output. Falls back to --`+prefix+`-view when empty.`)
+		viper.BindPFlag(prefix+"."+output+".view", rootCmd.PersistentFlags().Lookup(prefix+"-"+output+"-view"))
+	}
+
 	// stdout
 	rootCmd.PersistentFlags().Bool(prefix+"-stdout", false,
 		`Print output messages to stdout. Good for simple cli piping and debug.`)
@@ -22,6 +22,7 @@ require (
 	github.com/olivere/elastic/v7 v7.0.10
 	github.com/onsi/ginkgo v1.11.0 // indirect
 	github.com/onsi/gomega v1.8.1 // indirect
+	github.com/oschwald/geoip2-golang v1.4.0
 	github.com/pebbe/zmq4 v1.0.0 // indirect
 	github.com/pierrec/lz4 v2.4.0+incompatible // indirect
 	github.com/pkg/errors v0.9.1 // indirect
@@ -33,4 +34,5 @@ require (
 	golang.org/x/net v0.0.0-20200114155413-6afb5195e5aa // indirect
 	golang.org/x/tools v0.0.0-20200107050322-53017a39ae36 // indirect
 	gopkg.in/jcmturner/gokrb5.v7 v7.4.0 // indirect
+	gopkg.in/yaml.v2 v2.2.7
 )
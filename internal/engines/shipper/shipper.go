@@ -22,6 +22,15 @@ func (e ErrNoOutputs) Error() string {
 	return fmt.Sprintf("No outputs for %s module. See --help.", e.Name)
 }
 
+// HasOutputs reports if any output is enabled for module, Send would return ErrNoOutputs otherwise
+func HasOutputs(module string) bool {
+	return viper.GetBool(module+".stdout") ||
+		(viper.GetBool(module+".fifo.enabled") && len(viper.GetStringSlice(module+".fifo.path")) > 0) ||
+		viper.GetBool(module+".elastic.enabled") ||
+		viper.GetBool(module+".kafka.enabled") ||
+		viper.GetBool(module+".file.enabled")
+}
+
 func Send(
 	msgs <-chan *consumer.Message,
 	module string,
@@ -44,10 +53,25 @@ func Send(
 		fileEnabled  = viper.GetBool(module + ".file.enabled")
 	)
 
-	if !stdout && !fifoEnabled && !elaEnabled && !kafkaEnabled && !fileEnabled {
+	if !HasOutputs(module) {
 		return ErrNoOutputs{Name: module}
 	}
 
+	outputView := func(output string) *View {
+		v, err := OutputView(module, output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return v
+	}
+	var (
+		stdoutView = outputView("")
+		fifoView   = outputView("fifo")
+		elaView    = outputView("elastic")
+		kafkaView  = outputView("kafka")
+		fileView   = outputView("file")
+	)
+
 	bufsize := 0
 	stdoutCh := make(chan consumer.Message, bufsize)
 	fifoCh := func() []chan consumer.Message {
@@ -228,22 +252,47 @@ func Send(
 	}
 
 	for m := range msgs {
+		// outputs sharing a view get the same rendering
+		rendered := make(map[string][]byte)
+		render := func(v *View) (consumer.Message, bool) {
+			data, ok := rendered[v.Name]
+			if !ok {
+				var err error
+				if data, err = v.Render(*m); err != nil {
+					log.WithField("view", v.Name).Error(err)
+				}
+				rendered[v.Name] = data
+			}
+			out := *m
+			out.Data, out.Views = data, nil
+			return out, data != nil
+		}
 		if stdout {
-			stdoutCh <- *m
+			if out, ok := render(stdoutView); ok {
+				stdoutCh <- out
+			}
 		}
 		if fifoEnabled {
-			for _, tx := range fifoCh {
-				tx <- *m
+			if out, ok := render(fifoView); ok {
+				for _, tx := range fifoCh {
+					tx <- out
+				}
 			}
 		}
 		if elaEnabled {
-			elaCh <- *m
+			if out, ok := render(elaView); ok {
+				elaCh <- out
+			}
 		}
 		if kafkaEnabled {
-			kafkaCh <- *m
+			if out, ok := render(kafkaView); ok {
+				kafkaCh <- out
+			}
 		}
 		if fileEnabled {
-			fileCh <- *m
+			if out, ok := render(fileView); ok {
+				fileCh <- out
+			}
 		}
 	}
 	cancel()
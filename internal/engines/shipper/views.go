@@ -0,0 +1,203 @@
+package shipper
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/consumer"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ViewFull is the enriched event as produced by processor
+	ViewFull = "full"
+	// ViewAnonymized is the event with host names, addresses and users replaced with pseudonyms
+	ViewAnonymized = "anonymized"
+	// ViewMeta keeps only timestamp and GameMeta of event
+	ViewMeta = "meta"
+	// ViewECS is the event normalized into Elastic Common Schema
+	ViewECS = "ecs"
+	// ViewAnonymizedECS is ECS rendering of anonymized event
+	ViewAnonymizedECS = ViewAnonymized + "-" + ViewECS
+)
+
+// outputs that may define their own view, stdout uses module default
+var viewOutputs = []string{"elastic", "kafka", "file", "fifo"}
+
+// ErrNotRendered is returned when message lacks rendering that view is based on
+// message is dropped rather than sent in full, so misconfiguration can not leak original data
+type ErrNotRendered struct {
+	View, Base string
+}
+
+func (e ErrNotRendered) Error() string {
+	return fmt.Sprintf("view %s needs %s rendering of message, but processor did not provide one", e.View, e.Base)
+}
+
+// View decides how message is rendered for a single output
+// named views are defined under <module>.views.<name> with anonymized, ecs, meta, include and exclude keys
+type View struct {
+	Name       string
+	Anonymized bool
+	ECS        bool
+	MetaOnly   bool
+	// Include is an allow-list of dotted field paths, applied before Exclude
+	Include []string
+	// Exclude is a deny-list of dotted field paths
+	Exclude []string
+}
+
+func NewView(module, name string) (*View, error) {
+	switch name {
+	case "", ViewFull:
+		return &View{Name: ViewFull}, nil
+	case ViewAnonymized:
+		return &View{Name: name, Anonymized: true}, nil
+	case ViewMeta:
+		return &View{Name: name, MetaOnly: true}, nil
+	case ViewECS:
+		return &View{Name: name, ECS: true}, nil
+	case ViewAnonymizedECS:
+		return &View{Name: name, Anonymized: true, ECS: true}, nil
+	}
+	key := module + ".views." + name
+	if !viper.IsSet(key) {
+		return nil, fmt.Errorf("view %s is not defined in %s", name, module+".views")
+	}
+	return &View{
+		Name:       name,
+		Anonymized: viper.GetBool(key + ".anonymized"),
+		ECS:        viper.GetBool(key + ".ecs"),
+		MetaOnly:   viper.GetBool(key + ".meta"),
+		Include:    viper.GetStringSlice(key + ".include"),
+		Exclude:    viper.GetStringSlice(key + ".exclude"),
+	}, nil
+}
+
+// OutputView returns view configured for output of module, falling back to module default
+func OutputView(module, output string) (*View, error) {
+	name := viper.GetString(module + "." + output + ".view")
+	if name == "" {
+		name = viper.GetString(module + ".view")
+	}
+	return NewView(module, name)
+}
+
+// Renderings returns processor renderings that outputs of module are based on
+// processor only produces those that are needed, as anonymization and ECS mapping walk the whole event
+func Renderings(module string) map[string]bool {
+	out := make(map[string]bool)
+	for _, output := range append(viewOutputs, "") {
+		if v, err := OutputView(module, output); err == nil && v.Rendering() != "" {
+			out[v.Rendering()] = true
+		}
+	}
+	return out
+}
+
+// Rendering returns key of processor rendering that view is based on, empty string means full event
+func (v View) Rendering() string {
+	switch {
+	case v.Anonymized && v.ECS:
+		return ViewAnonymizedECS
+	case v.Anonymized:
+		return ViewAnonymized
+	case v.ECS:
+		return ViewECS
+	}
+	return ""
+}
+
+func (v View) projected() bool {
+	return v.MetaOnly || len(v.Include) > 0 || len(v.Exclude) > 0
+}
+
+// Render returns message data as seen through view
+// data is passed through without decoding when view does not project fields
+// messages that have no ECS mapping, such as alert documents, are sent in their own format to ECS views
+func (v View) Render(msg consumer.Message) ([]byte, error) {
+	data := msg.Data
+	if base := v.Rendering(); base != "" {
+		rendered, ok := msg.Views[base]
+		if !ok && v.ECS {
+			// anonymization is still required, so only the ECS part is dropped
+			if base = (View{Anonymized: v.Anonymized}).Rendering(); base != "" {
+				rendered, ok = msg.Views[base]
+			} else {
+				rendered, ok = msg.Data, true
+			}
+		}
+		if !ok {
+			return nil, ErrNotRendered{View: v.Name, Base: base}
+		}
+		data = rendered
+	}
+	if !v.projected() {
+		return data, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	if v.MetaOnly {
+		meta := map[string]interface{}{"GameMeta": obj["GameMeta"]}
+		if !msg.Time.IsZero() {
+			meta["@timestamp"] = msg.Time
+		}
+		obj = meta
+	}
+	if len(v.Include) > 0 {
+		out := make(map[string]interface{})
+		for _, path := range v.Include {
+			copyPath(obj, out, path)
+		}
+		obj = out
+	}
+	for _, path := range v.Exclude {
+		deletePath(obj, path)
+	}
+	return json.Marshal(obj)
+}
+
+// copyPath copies value at dotted path from src to dst, keeping nesting of src
+// keys that themselves contain dots, such as zeek id.orig_h, are matched before nested objects
+func copyPath(src, dst map[string]interface{}, path string) {
+	if val, ok := src[path]; ok {
+		dst[path] = val
+		return
+	}
+	for i := strings.IndexByte(path, '.'); i != -1; i = nextDot(path, i) {
+		if next, ok := src[path[:i]].(map[string]interface{}); ok {
+			sub, ok := dst[path[:i]].(map[string]interface{})
+			if !ok {
+				sub = make(map[string]interface{})
+			}
+			copyPath(next, sub, path[i+1:])
+			if len(sub) > 0 {
+				dst[path[:i]] = sub
+			}
+			return
+		}
+	}
+}
+
+func nextDot(path string, i int) int {
+	if j := strings.IndexByte(path[i+1:], '.'); j != -1 {
+		return i + 1 + j
+	}
+	return -1
+}
+
+func deletePath(obj map[string]interface{}, path string) {
+	if _, ok := obj[path]; ok {
+		delete(obj, path)
+		return
+	}
+	for i := strings.IndexByte(path, '.'); i != -1; i = nextDot(path, i) {
+		if next, ok := obj[path[:i]].(map[string]interface{}); ok {
+			deletePath(next, path[i+1:])
+			return
+		}
+	}
+}
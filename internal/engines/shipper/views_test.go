@@ -0,0 +1,48 @@
+package shipper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/consumer"
+)
+
+func TestViewRender(t *testing.T) {
+	msg := consumer.Message{
+		Data:  []byte(`{"host":"ws01","id.orig_h":"10.0.0.5","alert":{"signature":"x","category":"y"},"GameMeta":{"Host":"ws01"}}`),
+		Time:  time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC),
+		Views: map[string][]byte{ViewAnonymized: []byte(`{"host":"alias01","GameMeta":{"Host":"alias01"}}`)},
+	}
+	for _, tc := range []struct {
+		view View
+		want string
+	}{
+		{View{Name: "full"}, string(msg.Data)},
+		{View{Name: "anonymized", Anonymized: true}, `{"host":"alias01","GameMeta":{"Host":"alias01"}}`},
+		{View{Name: "ecs", ECS: true}, string(msg.Data)},
+		{View{Name: "anonymized-ecs", Anonymized: true, ECS: true}, `{"host":"alias01","GameMeta":{"Host":"alias01"}}`},
+		{View{Name: "meta", MetaOnly: true}, `{"@timestamp":"2020-04-14T10:00:00Z","GameMeta":{"Host":"ws01"}}`},
+		{View{Name: "allow", Include: []string{"alert.signature", "id.orig_h"}}, `{"alert":{"signature":"x"},"id.orig_h":"10.0.0.5"}`},
+		{View{Name: "deny", Exclude: []string{"alert.category", "GameMeta", "host"}}, `{"alert":{"signature":"x"},"id.orig_h":"10.0.0.5"}`},
+	} {
+		got, err := tc.view.Render(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a, b interface{}
+		json.Unmarshal(got, &a)
+		json.Unmarshal([]byte(tc.want), &b)
+		if !reflect.DeepEqual(a, b) {
+			t.Fatalf("view %s\ngot  %s\nwant %s", tc.view.Name, got, tc.want)
+		}
+	}
+	msg.Views = nil
+	if _, err := (View{Name: "players", Anonymized: true}).Render(msg); err == nil {
+		t.Fatal("anonymized view without rendering should fail")
+	}
+	if _, err := (View{Name: "players-ecs", Anonymized: true, ECS: true}).Render(msg); err == nil {
+		t.Fatal("anonymized ECS view without anonymized rendering should fail")
+	}
+}
@@ -0,0 +1,73 @@
+package run
+
+import (
+	"encoding/json"
+
+	"github.com/ccdcoe/go-peek/internal/engines/shipper"
+	"github.com/ccdcoe/go-peek/pkg/alerts"
+	"github.com/ccdcoe/go-peek/pkg/models/consumer"
+	"github.com/ccdcoe/go-peek/pkg/models/events"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	"github.com/spf13/viper"
+)
+
+// newAlertSuppressor sets up alert documents for emit outputs, nil is returned if whole events are emitted instead
+func newAlertSuppressor() (*alerts.Suppressor, error) {
+	if !viper.GetBool("emit.alerts.enabled") {
+		return nil, nil
+	}
+	return alerts.NewSuppressor(&alerts.Config{
+		Window:   viper.GetDuration("emit.alerts.suppress.window"),
+		Key:      viper.GetString("emit.alerts.suppress.key"),
+		Size:     viper.GetInt("emit.alerts.suppress.size"),
+		Interval: viper.GetDuration("emit.alerts.suppress.interval"),
+	})
+}
+
+// alertMessage wraps alert document for emit outputs
+// alert is its own format without ECS mapping, so ECS views fall back to plain or anonymized alert
+func alertMessage(a alerts.Alert, anonymizeAll bool) (*consumer.Message, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	msg := &consumer.Message{
+		Data:      data,
+		Offset:    a.Event.Offset,
+		Partition: a.Event.Partition,
+		Source:    a.Event.Source,
+		Key:       "alert",
+		Time:      a.Timestamp,
+	}
+	for _, e := range events.Atomics {
+		if e.String() == a.Event.Type {
+			msg.Event = e
+			break
+		}
+	}
+	if a.Anonymized == nil {
+		return msg, nil
+	}
+	anonymized, err := json.Marshal(a.Anonymized)
+	if err != nil {
+		return nil, err
+	}
+	msg.Views = map[string][]byte{shipper.ViewAnonymized: anonymized}
+	if anonymizeAll {
+		msg.Data = anonymized
+	}
+	return msg, nil
+}
+
+// sendAlert passes alert document to emit outputs, nothing is sent if emitter is disabled
+func sendAlert(ch chan<- *consumer.Message, a alerts.Alert, anonymizeAll bool, errs *utils.ErrChan) {
+	if ch == nil {
+		return
+	}
+	msg, err := alertMessage(a, anonymizeAll)
+	if err != nil {
+		errs.Send(err)
+		return
+	}
+	ch <- msg
+}
@@ -0,0 +1,179 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/internal/engines/shipper"
+	"github.com/ccdcoe/go-peek/pkg/anonymize"
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/events"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/spf13/viper"
+)
+
+// newPseudonymizer sets up keyed pseudonyms, nil is returned if neither processor nor any output view needs them
+// generated key is kept in work dir, so pseudonyms survive restarts
+func newPseudonymizer(spooldir string) (*anonymize.Pseudonymizer, error) {
+	if !viper.GetBool("processor.anonymize") && !needsAnonymized() {
+		return nil, nil
+	}
+	keyfile := viper.GetString("processor.anonymization.keyfile")
+	if keyfile != "" && !filepath.IsAbs(keyfile) && !strings.HasPrefix(keyfile, "~") {
+		keyfile = filepath.Join(spooldir, keyfile)
+	}
+	if keyfile != "" {
+		if err := os.MkdirAll(filepath.Dir(keyfile), 0750); err != nil {
+			return nil, err
+		}
+	}
+	return anonymize.NewPseudonymizer(&anonymize.Config{
+		Key:     viper.GetString("processor.anonymization.key"),
+		KeyFile: keyfile,
+	})
+}
+
+// renderings returns processor renderings that any output view is based on
+func renderings() map[string]bool {
+	out := shipper.Renderings("output")
+	for key := range shipper.Renderings("emit") {
+		out[key] = true
+	}
+	return out
+}
+
+func needsAnonymized() bool {
+	r := renderings()
+	return r[shipper.ViewAnonymized] || r[shipper.ViewAnonymizedECS]
+}
+
+// wellKnownUsers are built in accounts that do not identify anyone
+var wellKnownUsers = map[string]bool{
+	"root":            true,
+	"nobody":          true,
+	"system":          true,
+	"local service":   true,
+	"network service": true,
+	"anonymous logon": true,
+}
+
+// pseudonyms collects identifying values of event and maps them to replacements
+// known assets keep their alias, everything else gets a keyed pseudonym
+func pseudonyms(p *anonymize.Pseudonymizer, ev interface{}, m *meta.GameAsset, raw []byte) map[string]string {
+	out := make(map[string]string)
+	// first mapping wins, so asset alias is not overridden by a pseudonym of same name seen elsewhere in event
+	set := func(from, to string) {
+		from = strings.ToLower(from)
+		if _, ok := out[from]; !ok && len(from) > 1 {
+			out[from] = to
+		}
+	}
+	domain := func(name string) {
+		if name = strings.TrimSuffix(name, "."); name != "" {
+			set(name, p.Domain(name))
+		}
+	}
+	host := func(name, alias string) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return
+		}
+		if alias == "" {
+			alias = p.Host(name)
+		}
+		set(name, alias)
+		// FQDN also appears as bare host name, and its domain elsewhere
+		if bits := strings.SplitN(name, ".", 2); len(bits) == 2 {
+			set(bits[0], alias)
+			domain(bits[1])
+		}
+	}
+	for _, a := range []*meta.Asset{&m.Asset, m.Source, m.Destination} {
+		if a == nil {
+			continue
+		}
+		var alias string
+		if a.IsAsset {
+			alias = a.Alias
+		}
+		host(a.Host, alias)
+		host(a.VM, "")
+		if a.IP != nil {
+			set(a.IP.String(), p.IP(a.IP).String())
+		}
+		if a.MAC != "" {
+			set(a.MAC, p.MAC(a.MAC))
+		}
+		for _, name := range a.DNSNames {
+			domain(name)
+		}
+	}
+	if obj, ok := ev.(atomic.Event); ok {
+		var alias string
+		if m.Asset.IsAsset {
+			alias = m.Asset.Alias
+		}
+		host(obj.Sender(), alias)
+	}
+	for literal, ip := range anonymize.AddressLiterals(raw) {
+		set(literal, p.IP(ip).String())
+	}
+	if obj, ok := ev.(events.ObservableGetter); ok {
+		for _, o := range obj.Observables() {
+			switch o.Kind {
+			case meta.ObservableDomain:
+				domain(o.Value)
+			case meta.ObservableEmail:
+				if bits := strings.SplitN(o.Value, "@", 2); len(bits) == 2 {
+					set(bits[0], p.User(bits[0]))
+					domain(bits[1])
+				}
+			}
+		}
+	}
+	var users []string
+	if obj, ok := ev.(events.UserGetter); ok {
+		users = append(users, obj.Users()...)
+	}
+	// ancestor processes often run as someone else, e.g. commands under sudo
+	for _, proc := range m.Ancestors {
+		if proc.User != "" {
+			users = append(users, proc.User)
+		}
+	}
+	for _, name := range users {
+		if bits := strings.SplitN(name, `\`, 2); len(bits) == 2 {
+			domain(bits[0])
+			name = bits[1]
+		} else if bits := strings.SplitN(name, "@", 2); len(bits) == 2 {
+			domain(bits[1])
+			name = bits[0]
+		}
+		if strings.HasSuffix(name, "$") {
+			// machine account is named after host
+			host(strings.TrimSuffix(name, "$"), "")
+			continue
+		}
+		if !wellKnownUsers[strings.ToLower(name)] {
+			set(name, p.User(name))
+		}
+	}
+	return out
+}
+
+// renderAnonymized pseudonymises event in place and returns its new rendering with replacements that were used
+// identifying values are harvested from enriched event, so data is its previous rendering
+func renderAnonymized(p *anonymize.Pseudonymizer, e events.GameEvent, m *meta.GameAsset, data []byte) ([]byte, map[string]string, error) {
+	obj, ok := e.(events.Anonymizer)
+	if !ok {
+		return nil, nil, fmt.Errorf("event type %T does not support anonymization", e)
+	}
+	replacements := pseudonyms(p, e, m, data)
+	if err := obj.Anonymize(replacements); err != nil {
+		return nil, nil, err
+	}
+	out, err := e.JSONFormat()
+	return out, replacements, err
+}
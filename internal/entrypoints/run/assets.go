@@ -0,0 +1,86 @@
+package run
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/intel/assetcache"
+	"github.com/ccdcoe/go-peek/pkg/intel/inventory"
+	"github.com/ccdcoe/go-peek/pkg/intel/wise"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// newAssetCacheConfig builds global asset cache config and provider lookup chain from viper
+// chain order is inventory files, WISE and redis hashes, WISE is skipped if handle is nil
+// remote providers are async, so workers never wait for them
+func newAssetCacheConfig(spooldir string, wh *wise.Handle) (*assetcache.Config, error) {
+	var (
+		providers = make([]assetcache.ProviderConfig, 0)
+		negative  = viper.GetDuration("processor.assets.negative.ttl")
+	)
+	if paths := viper.GetStringSlice("processor.inputs.inventory.path"); len(paths) > 0 {
+		p, err := assetcache.NewFileProvider(&inventory.Config{
+			Paths: paths,
+			Watch: viper.GetBool("processor.inputs.inventory.watch"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, assetcache.ProviderConfig{
+			Provider:    p,
+			TTL:         viper.GetDuration("processor.inputs.inventory.ttl"),
+			NegativeTTL: negative,
+		})
+	}
+	if wh != nil {
+		providers = append(providers, assetcache.ProviderConfig{
+			Provider:    assetcache.NewWiseProvider(wh),
+			TTL:         viper.GetDuration("processor.inputs.wise.ttl"),
+			NegativeTTL: negative,
+			Async:       true,
+		})
+	}
+	if viper.GetBool("processor.inputs.redis.assets.enabled") {
+		p, err := assetcache.NewRedisProvider(&assetcache.RedisConfig{
+			Host:      viper.GetString("processor.inputs.redis.host"),
+			Port:      viper.GetInt("processor.inputs.redis.port"),
+			DB:        viper.GetInt("processor.inputs.redis.db"),
+			Prefix:    viper.GetString("processor.inputs.redis.assets.prefix"),
+			Threshold: viper.GetInt("processor.inputs.redis.assets.breaker.threshold"),
+			Cooldown:  viper.GetDuration("processor.inputs.redis.assets.breaker.cooldown"),
+		})
+		if p == nil {
+			return nil, err
+		} else if err != nil {
+			// lookups are async and circuit breaker keeps probing, so redis may come up later
+			log.Warnf("redis asset provider ping failed, will retry in background: %s", err)
+		}
+		providers = append(providers, assetcache.ProviderConfig{
+			Provider:    p,
+			TTL:         viper.GetDuration("processor.inputs.redis.assets.ttl"),
+			NegativeTTL: negative,
+			Async:       true,
+		})
+	}
+	return &assetcache.Config{
+		Providers: providers,
+		DumpJSONAssets: func() string {
+			if pth := viper.GetString("processor.persist.json.assets"); pth != "" {
+				if !filepath.IsAbs(pth) && !strings.HasPrefix(pth, "~") {
+					return filepath.Join(spooldir, pth)
+				}
+				pth, _ = utils.ExpandHome(pth)
+				return pth
+			}
+			return ""
+		}(),
+		LoadNetworks: func() string {
+			pth, _ := utils.ExpandHome(viper.GetString("processor.inputs.networks.path"))
+			return pth
+		}(),
+		Prune: true,
+	}, nil
+}
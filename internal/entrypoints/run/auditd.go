@@ -0,0 +1,86 @@
+package run
+
+import (
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/consumer"
+	"github.com/ccdcoe/go-peek/pkg/models/events"
+	"github.com/ccdcoe/go-peek/pkg/parsers"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	"github.com/spf13/viper"
+)
+
+// newAuditdAssembler sets up grouping of audit records into events, shared by all inputs
+func newAuditdAssembler() *atomic.AuditdAssembler {
+	return atomic.NewAuditdAssembler(
+		viper.GetInt("stream.auditd.pending"),
+		viper.GetDuration("stream.auditd.timeout"),
+	)
+}
+
+// job is message for workers, ev is set if event was already assembled from several messages
+type job struct {
+	msg *consumer.Message
+	ev  interface{}
+}
+
+// assembleAuditd groups auditd records into events before messages are spread over workers, other messages are passed through
+// incomplete events are released when they go stale, even if no more records arrive, and all of them when input is closed
+// nil assembler passes every message through as is
+func assembleAuditd(
+	rx <-chan *consumer.Message,
+	mapping consumer.ParseMap,
+	a *atomic.AuditdAssembler,
+	errs *utils.ErrChan,
+) <-chan job {
+	tx := make(chan job)
+	go func() {
+		defer close(tx)
+		if a == nil {
+			for msg := range rx {
+				tx <- job{msg: msg}
+			}
+			return
+		}
+		tick := time.NewTicker(a.Timeout())
+		defer tick.Stop()
+		// events released by ticker carry input info of newest record
+		var last consumer.Message
+		send := func(evs []*events.Auditd) {
+			for _, ev := range evs {
+				data, err := ev.JSONFormat()
+				if err != nil {
+					errs.Send(err)
+					continue
+				}
+				msg := last
+				msg.Data = data
+				tx <- job{msg: &msg, ev: ev}
+			}
+		}
+		for {
+			select {
+			case msg, ok := <-rx:
+				if !ok {
+					send(parsers.AuditdEvents(a.Flush()))
+					return
+				}
+				if info, ok := mapping[msg.Source]; !ok || info.Atomic != events.AuditdE {
+					tx <- job{msg: msg}
+					continue
+				}
+				last = *msg
+				evs, err := parsers.ParseAuditd(msg.Data, mapping[msg.Source].Parser, a)
+				if err != nil {
+					errs.Send(err)
+					continue
+				}
+				send(evs)
+			case <-tick.C:
+				send(parsers.AuditdEvents(a.Expire()))
+			}
+		}
+	}()
+	return tx
+}
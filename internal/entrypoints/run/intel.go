@@ -0,0 +1,248 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/intel/assetcache"
+	"github.com/ccdcoe/go-peek/pkg/intel/dhcp"
+	"github.com/ccdcoe/go-peek/pkg/intel/geoip"
+	"github.com/ccdcoe/go-peek/pkg/intel/ioc"
+	"github.com/ccdcoe/go-peek/pkg/intel/pdns"
+	"github.com/ccdcoe/go-peek/pkg/intel/proctree"
+	"github.com/ccdcoe/go-peek/pkg/intel/wise"
+	"github.com/ccdcoe/go-peek/pkg/models/events"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// newWiseHandle connects to WISE, nil is returned if it is disabled
+// asset lookups and threat intel share the handle, so both are paused by the same circuit breaker
+func newWiseHandle() (*wise.Handle, error) {
+	if !viper.GetBool("processor.inputs.wise.enabled") {
+		return nil, nil
+	}
+	if prefix := viper.GetString("processor.inputs.wise.prefix"); prefix != "" {
+		wise.FieldPrefix = prefix
+	}
+	wh := viper.GetString("processor.inputs.wise.host")
+	log.Debugf("wise enabled, configuring for host %s", wh)
+	h, err := wise.NewHandle(&wise.Config{
+		Host:      wh,
+		Timeout:   viper.GetDuration("processor.inputs.wise.timeout"),
+		Threshold: viper.GetInt("processor.inputs.wise.breaker.threshold"),
+		Cooldown:  viper.GetDuration("processor.inputs.wise.breaker.cooldown"),
+	})
+	if h == nil {
+		return nil, err
+	} else if err != nil {
+		// lookups are async and circuit breaker keeps probing, so WISE may come up later
+		log.Warnf("wise health check failed, will retry in background: %s", err)
+	}
+	return h, nil
+}
+
+// newWiseEnricher sets up background threat intel lookups, nil is returned if feature is disabled
+func newWiseEnricher(h *wise.Handle) (*wise.Enricher, error) {
+	if h == nil || !viper.GetBool("processor.inputs.wise.intel.enabled") {
+		return nil, nil
+	}
+	return wise.NewEnricher(h, &wise.EnricherConfig{
+		TTL:         viper.GetDuration("processor.inputs.wise.intel.ttl"),
+		NegativeTTL: viper.GetDuration("processor.assets.negative.ttl"),
+	})
+}
+
+// enrichIntel attaches cached WISE responses for event observables
+func enrichIntel(e *wise.Enricher, ev interface{}, m *meta.GameAsset) {
+	obj, ok := ev.(events.ObservableGetter)
+	if !ok {
+		return
+	}
+	for _, o := range obj.Observables() {
+		if o.Kind == meta.ObservableIP {
+			// addresses are already resolved from WISE by asset cache
+			continue
+		}
+		if resp, ok := e.Get(wise.Kind(o.Kind), o.Value); ok && len(resp) > 0 {
+			if m.Intel == nil {
+				m.Intel = &meta.Intel{Key: wise.FieldPrefix}
+			}
+			m.Intel.Add(meta.IntelMatch{Observable: o, Fields: resp.Fields()})
+		}
+	}
+}
+
+// newIOCMatcher loads local indicator lists, nil is returned if none are configured
+func newIOCMatcher() (*ioc.Matcher, error) {
+	paths := viper.GetStringSlice("processor.inputs.ioc.path")
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	return ioc.NewMatcher(&ioc.Config{
+		Paths:    paths,
+		Interval: viper.GetDuration("processor.inputs.ioc.interval"),
+	})
+}
+
+// matchIOC records indicator list matches for event observables
+func matchIOC(matcher *ioc.Matcher, ev interface{}, m *meta.GameAsset) {
+	if obj, ok := ev.(events.ObservableGetter); ok {
+		m.IOC = matcher.Match(obj.Observables())
+	}
+}
+
+// newGeoIP opens local MaxMind databases, nil is returned if none are configured
+func newGeoIP() (*geoip.Handle, error) {
+	city, asn := viper.GetString("processor.inputs.geoip.city"), viper.GetString("processor.inputs.geoip.asn")
+	if city == "" && asn == "" {
+		return nil, nil
+	}
+	return geoip.NewHandle(&geoip.Config{
+		City:     city,
+		ASN:      asn,
+		Interval: viper.GetDuration("processor.inputs.geoip.interval"),
+	})
+}
+
+// enrichGeo attaches location to external endpoints, known assets are skipped as exercise ranges are not in public databases
+func enrichGeo(h *geoip.Handle, m *meta.GameAsset) {
+	for _, a := range []*meta.Asset{m.Source, m.Destination} {
+		if a == nil || a.IP == nil || a.IsAsset {
+			continue
+		}
+		if g, ok := h.Lookup(a.IP); ok {
+			a.Geo = g
+		}
+	}
+}
+
+// persistPath resolves dump file of a table relative to work dir and creates its directory
+// empty path means persistence is disabled
+func persistPath(spooldir, key string) (string, error) {
+	persist := viper.GetString(key)
+	if persist == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(persist) {
+		persist = filepath.Join(spooldir, persist)
+	}
+	return persist, os.MkdirAll(filepath.Dir(persist), 0750)
+}
+
+// newPassiveDNS sets up passive DNS table persisted in work dir, nil is returned if feature is disabled
+func newPassiveDNS(spooldir string) (*pdns.Table, error) {
+	if !viper.GetBool("processor.pdns.enabled") {
+		return nil, nil
+	}
+	persist, err := persistPath(spooldir, "processor.persist.json.pdns")
+	if err != nil {
+		return nil, err
+	}
+	return pdns.NewTable(&pdns.Config{
+		Size:    viper.GetInt("processor.pdns.size"),
+		TTL:     viper.GetDuration("processor.pdns.ttl"),
+		Persist: persist,
+	})
+}
+
+// passiveDNS learns bindings from DNS answers and names addresses of events from any stream
+func passiveDNS(t *pdns.Table, ev interface{}, ts time.Time, m *meta.GameAsset) {
+	if obj, ok := ev.(*events.Suricata); ok {
+		for _, a := range obj.DNSAnswers() {
+			t.Learn(a.Name, a.IP, ts)
+		}
+	}
+	for _, a := range []*meta.Asset{m.Source, m.Destination} {
+		if a == nil || a.IP == nil || a.Host != "" {
+			continue
+		}
+		a.DNSNames = t.Lookup(a.IP)
+	}
+}
+
+// newProcessTree sets up process table persisted in work dir, nil is returned if feature is disabled
+func newProcessTree(spooldir string) (*proctree.Table, error) {
+	if !viper.GetBool("processor.proctree.enabled") {
+		return nil, nil
+	}
+	persist, err := persistPath(spooldir, "processor.persist.json.proctree")
+	if err != nil {
+		return nil, err
+	}
+	return proctree.NewTable(&proctree.Config{
+		Size:      viper.GetInt("processor.proctree.size"),
+		Depth:     viper.GetInt("processor.proctree.depth"),
+		Retention: viper.GetDuration("processor.proctree.retention"),
+		Persist:   persist,
+	})
+}
+
+// processTree learns processes from sysmon and snoopy events and attaches ancestors of process that generated event
+func processTree(t *proctree.Table, ev interface{}, ts time.Time, m *meta.GameAsset) {
+	switch obj := ev.(type) {
+	case *events.DynamicWinlogbeat:
+		if obj.Sysmon != nil {
+			m.Ancestors = t.ObserveSysmon(obj.Sender(), obj.Sysmon, ts)
+		}
+	case *events.Snoopy:
+		m.Ancestors = t.ObserveSnoopy(obj.Syslog.Host, &obj.Snoopy, ts)
+	}
+}
+
+// newDHCP sets up lease table persisted in work dir, nil is returned if feature is disabled
+func newDHCP(spooldir string) (*dhcp.Table, error) {
+	if !viper.GetBool("processor.dhcp.enabled") {
+		return nil, nil
+	}
+	persist, err := persistPath(spooldir, "processor.persist.json.dhcp")
+	if err != nil {
+		return nil, err
+	}
+	return dhcp.NewTable(&dhcp.Config{
+		DefaultLease: viper.GetDuration("processor.dhcp.lease"),
+		Retention:    viper.GetDuration("processor.dhcp.retention"),
+		Persist:      persist,
+	})
+}
+
+// learnDHCP records leases from Suricata dhcp events and, if enabled, from dhcpd syslog messages
+func learnDHCP(t *dhcp.Table, ev interface{}, ts time.Time, syslog bool) {
+	switch obj := ev.(type) {
+	case *events.Suricata:
+		if obj.DHCP != nil {
+			t.ObserveSuricata(obj.DHCP, ts)
+		}
+	case *events.Syslog:
+		if syslog && obj.Syslog.Program == "dhcpd" {
+			t.ObserveDhcpd(obj.Syslog.Message, ts)
+		}
+	}
+}
+
+// resolveDHCP replaces addresses with host that held the lease at event time
+// lease is more accurate than static inventory for dynamic ranges, so it takes precedence
+func resolveDHCP(t *dhcp.Table, cache *assetcache.LocalCache, ts time.Time, assets ...*meta.Asset) {
+	for _, a := range assets {
+		if a == nil || a.IP == nil {
+			continue
+		}
+		lease, ok := t.Resolve(a.IP, ts)
+		if !ok {
+			continue
+		}
+		if lease.Host != "" && lease.Host != a.Host {
+			ip := a.IP
+			if val, ok := cache.GetString(lease.Host); ok && val.IsAsset && val.Data != nil {
+				*a = *val.Data
+			} else {
+				*a = meta.Asset{Host: lease.Host}
+			}
+			a.IP = ip
+		}
+		a.MAC = lease.MAC
+	}
+}
@@ -0,0 +1,44 @@
+package run
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/events"
+	"github.com/markuskont/go-sigma-rule-engine/pkg/sigma"
+)
+
+// checkRecords matches sigma rules against each record of event, results are deduplicated by rule
+func checkRecords(rules sigma.RuleMap, ev events.SigmaRecordGetter, product string, firstmatch bool) (sigma.Results, bool) {
+	var out sigma.Results
+	seen := make(map[string]bool)
+	for _, r := range ev.SigmaRecords() {
+		res, match := rules.Check(r, product, firstmatch)
+		if !match {
+			continue
+		}
+		for _, item := range res {
+			if !seen[item.ID] {
+				seen[item.ID] = true
+				out = append(out, item)
+			}
+		}
+		if firstmatch {
+			break
+		}
+	}
+	return out, len(out) > 0
+}
+
+// ruleLevels maps sigma rule IDs to their level, as level is not part of match results
+func ruleLevels(rules sigma.RuleMap) map[string]string {
+	out := make(map[string]string)
+	for _, group := range rules {
+		for _, rule := range group {
+			if rule.Level != nil {
+				out[rule.ID] = strings.ToLower(fmt.Sprint(rule.Level))
+			}
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package run
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -8,9 +9,9 @@ import (
 	"time"
 
 	"github.com/ccdcoe/go-peek/internal/engines/shipper"
+	"github.com/ccdcoe/go-peek/pkg/alerts"
 	"github.com/ccdcoe/go-peek/pkg/intel/assetcache"
 	"github.com/ccdcoe/go-peek/pkg/intel/mitremeerkat"
-	"github.com/ccdcoe/go-peek/pkg/intel/wise"
 	"github.com/ccdcoe/go-peek/pkg/models/consumer"
 	"github.com/ccdcoe/go-peek/pkg/models/events"
 	"github.com/ccdcoe/go-peek/pkg/models/meta"
@@ -46,20 +47,17 @@ func spawnWorkers(
 		return false
 	}()
 	var (
-		every = time.NewTicker(3 * time.Second)
-		count uint64
+		every            = time.NewTicker(3 * time.Second)
+		count            uint64
+		globalAssetCache *assetcache.GlobalCache
 	)
-	globalAssetCache, err := assetcache.NewGlobalCache(&assetcache.Config{
-		Wise: func() *wise.Config {
-			if viper.GetBool("processor.inputs.wise.enabled") {
-				wh := viper.GetString("processor.inputs.wise.host")
-				log.Debugf("wise enabled, configuring for host %s", wh)
-				return &wise.Config{Host: wh}
-			}
-			return nil
-		}(),
-		Prune: true,
-	})
+	wiseHandle, err := newWiseHandle()
+	if err == nil {
+		var assetConfig *assetcache.Config
+		if assetConfig, err = newAssetCacheConfig(spooldir, wiseHandle); err == nil {
+			globalAssetCache, err = assetcache.NewGlobalCache(assetConfig)
+		}
+	}
 	logContext := log.WithFields(log.Fields{
 		"action": "init global cache",
 		"thread": "main spawn",
@@ -69,29 +67,146 @@ func spawnWorkers(
 	} else if err != nil && noparse {
 		logContext.Warn(err)
 	}
-	go func() {
-		for {
-			select {
-			case <-every.C:
-				log.Infof("Collected %d events", atomic.LoadUint64(&count))
+	if globalAssetCache == nil {
+		// lookup chain failed but processing is disabled anyway, keep an empty cache for workers
+		globalAssetCache, _ = assetcache.NewGlobalCache(&assetcache.Config{})
+	}
+	mitreTechniqueMapper := func() meta.Techniques {
+		if path := viper.GetString("processor.mitre.technique.json"); path != "" {
+			out, err := meta.NewTechniquesFromFile(path)
+			if err != nil {
+				log.Fatal(err)
 			}
+			log.Infof("loaded %d mitre att&ck technique mappings from %s", len(out), path)
+			return out
 		}
+		return nil
 	}()
+	intel, err := newWiseEnricher(wiseHandle)
+	if err != nil {
+		log.Fatal(err)
+	}
+	matcher, err := newIOCMatcher()
+	if err != nil {
+		log.Fatal(err)
+	}
+	geo, err := newGeoIP()
+	if err != nil {
+		log.Fatal(err)
+	}
+	passive, err := newPassiveDNS(spooldir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	procs, err := newProcessTree(spooldir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	leases, err := newDHCP(spooldir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	leasesFromSyslog := viper.GetBool("processor.dhcp.syslog")
+	anonymizeAll := viper.GetBool("processor.anonymize")
+	render := renderings()
+	ecsOriginal := viper.GetString("processor.ecs.original")
+	zeekLog := viper.GetString("stream.zeek.log")
+	auditd := newAuditdAssembler()
+	if noparse || logstashCompat {
+		auditd = nil
+	}
+	jobs := assembleAuditd(rx, mapping, auditd, errs)
+	suppress, err := newAlertSuppressor()
+	if err != nil {
+		log.Fatal(err)
+	}
+	pseudonymizer, err := newPseudonymizer(spooldir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// emitter state is decided before any goroutine starts, as workers and suppressor read it concurrently
+	var emitCh chan *consumer.Message
+	emit := shipper.HasOutputs("emit")
+	if emit {
+		emitCh = make(chan *consumer.Message, 100)
+		go func(ch chan *consumer.Message) {
+			if err := shipper.Send(ch, "emit"); err != nil {
+				log.Fatal(err)
+			}
+		}(emitCh)
+	} else {
+		log.Warn("Emitter has no outputs. Will not be enabled.")
+	}
 
-	emitCh := make(chan *consumer.Message, 100)
-	emit := true
+	intelCtx, intelStop := context.WithCancel(context.Background())
+	intelDone := make(chan struct{})
 	go func() {
-		defer close(emitCh)
-		if err := shipper.Send(emitCh, "emit"); err != nil {
-			switch err.(type) {
-			case shipper.ErrNoOutputs, *shipper.ErrNoOutputs:
-				emit = false
-				log.Warn("Emitter has no outputs. Will not be enabled.")
-			default:
-				log.Fatal(err)
+		defer close(intelDone)
+		var wg sync.WaitGroup
+		if intel != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				intel.Run(intelCtx, errs)
+			}()
+		}
+		if matcher != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				matcher.Run(intelCtx, errs)
+			}()
+		}
+		if geo != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				geo.Run(intelCtx, errs)
+			}()
+		}
+		if passive != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				passive.Run(intelCtx, errs)
+			}()
+		}
+		if procs != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				procs.Run(intelCtx, errs)
+			}()
+		}
+		if leases != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				leases.Run(intelCtx, errs)
+			}()
+		}
+		if suppress != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// summaries are sent when suppression window closes
+				suppress.Run(intelCtx, func(a alerts.Alert) {
+					sendAlert(emitCh, a, anonymizeAll, errs)
+				})
+			}()
+		}
+		wg.Wait()
+		if geo != nil {
+			geo.Close()
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case <-every.C:
+				log.Infof("Collected %d events", atomic.LoadUint64(&count))
 			}
 		}
-		emitCh = nil
 	}()
 
 	go func(emit bool) {
@@ -104,6 +219,11 @@ func spawnWorkers(
 			return consumer.ParseMapping{}
 		}
 		defer globalAssetCache.Close()
+		defer func() {
+			// lookups in flight report to errs, so they must finish before it is closed
+			intelStop()
+			<-intelDone
+		}()
 		for i := 0; i < workers; i++ {
 			wg.Add(1)
 			go func(id int, emit bool) {
@@ -123,20 +243,12 @@ func spawnWorkers(
 					DB:   viper.GetInt("processor.inputs.redis.db"),
 				})
 				if err != nil {
-					logContext.Fatal(err)
+					// rule metadata still provides ATT&CK context for most alerts, so redis is not mandatory
+					logContext.Warnf("mitre meerkat sid mapper unavailable, using only rule metadata: %s", err)
+					mitreSignatureConverter = nil
 				}
-				mitreTechniqueMapper := func() meta.Techniques {
-					if path := viper.GetString("processor.mitre.technique.json"); path != "" {
-						out, err := meta.NewTechniquesFromJSONfile(path)
-						if err != nil {
-							log.Fatal(err)
-						}
-						log.Infof("worker %d loaded %d mappings from %s", id, len(out), path)
-						return out
-					}
-					return nil
-				}()
 
+				var levels map[string]string
 				ruleset, checkRules, quickmatch := func() (*sigma.Ruleset, bool, bool) {
 					if !viper.GetBool("processor.sigma.enabled") {
 						return nil, false, false
@@ -159,10 +271,12 @@ func spawnWorkers(
 						len(ruleset.Unsupported),
 						len(ruleset.Broken),
 					)
+					levels = ruleLevels(ruleset.Rules)
 					return ruleset, true, viper.GetBool("processor.sigma.quickmatch")
 				}()
 			loop:
-				for msg := range rx {
+				for j := range jobs {
+					msg := j.msg
 					atomic.AddUint64(&count, 1)
 
 					evInfo := sourceToEvent(msg.Source)
@@ -190,7 +304,16 @@ func spawnWorkers(
 						continue loop
 					}
 
-					ev, err := parsers.Parse(msg.Data, evType, evParse)
+					ev := j.ev
+					switch {
+					case ev != nil:
+						// auditd event, assembled before it was handed to worker
+					case evType == events.ZeekE && (evParse == consumer.RawJSON || evParse == consumer.RFC5424):
+						// zeek json writer omits log path unless it is configured to add it
+						ev, err = parsers.ParseZeekMessage(msg.Data, evParse, zeekLog, msg.Source)
+					default:
+						ev, err = parsers.Parse(msg.Data, evType, evParse)
+					}
 					if err != nil {
 						errs.Send(err)
 						continue loop
@@ -202,6 +325,10 @@ func spawnWorkers(
 					}
 					msg.Time = e.Time()
 					msg.Key = evType.String()
+					if obj, ok := ev.(*events.Osquery); ok && obj.Name != "" {
+						// prefixed, so query names do not collide with topics or indices of other streams
+						msg.Key = msg.Key + "_" + obj.Key()
+					}
 
 					m := e.GetAsset()
 					if m == nil {
@@ -214,7 +341,7 @@ func spawnWorkers(
 
 					// Asset checking stuff
 					if ip := m.Asset.IP; ip != nil {
-						if val, ok := localAssetCache.GetIP(ip.String()); ok && val.IsAsset {
+						if val, ok := localAssetCache.GetIP(ip); ok && val.IsAsset {
 							m.Asset = *val.Data
 						}
 					} else if host := m.Asset.Host; host != "" {
@@ -224,24 +351,74 @@ func spawnWorkers(
 					}
 					if m.Source != nil {
 						if ip := m.Source.IP; ip != nil {
-							if val, ok := localAssetCache.GetIP(ip.String()); ok && val.IsAsset && val.Data != nil {
-								m.Source = val.Data
+							if val, ok := localAssetCache.GetIP(ip); ok && val.IsAsset && val.Data != nil {
+								// copy, as cached item is shared between events and workers
+								src := *val.Data
+								m.Source = &src
 								m.Source.IP = ip
 							}
+						} else if host := m.Source.Host; host != "" {
+							// endpoint that is only known by name, e.g. host that logged a DNS query
+							if val, ok := localAssetCache.GetString(host); ok && val.IsAsset && val.Data != nil {
+								src := *val.Data
+								m.Source = &src
+							}
 						}
 					}
 					if m.Destination != nil {
 						if ip := m.Destination.IP; ip != nil {
-							if val, ok := localAssetCache.GetIP(ip.String()); ok && val.IsAsset && val.Data != nil {
-								m.Destination = val.Data
+							if val, ok := localAssetCache.GetIP(ip); ok && val.IsAsset && val.Data != nil {
+								dest := *val.Data
+								m.Destination = &dest
 								m.Destination.IP = ip
 							}
+						} else if host := m.Destination.Host; host != "" {
+							if val, ok := localAssetCache.GetString(host); ok && val.IsAsset && val.Data != nil {
+								dest := *val.Data
+								m.Destination = &dest
+							}
 						}
 					}
+					if leases != nil {
+						learnDHCP(leases, ev, msg.Time, leasesFromSyslog)
+						resolveDHCP(leases, localAssetCache, msg.Time, &m.Asset, m.Source, m.Destination)
+					}
+					// segment is attached to every address, not only known assets
+					for _, a := range []*meta.Asset{&m.Asset, m.Source, m.Destination} {
+						if a != nil && a.IP != nil {
+							if seg, ok := localAssetCache.GetNetwork(a.IP); ok {
+								a.Network = seg
+							}
+						}
+					}
+
+					if id, ok := events.CommunityID(ev); ok {
+						m.CommunityID = id
+					}
+					if procs != nil {
+						processTree(procs, ev, msg.Time, m)
+					}
+					if passive != nil {
+						passiveDNS(passive, ev, msg.Time, m)
+					}
+					if geo != nil {
+						enrichGeo(geo, m)
+					}
+					if intel != nil {
+						enrichIntel(intel, ev, m)
+					}
+					if matcher != nil {
+						matchIOC(matcher, ev, m)
+					}
 
 					if checkRules {
-						if obj, ok := ev.(sigma.EventChecker); ok {
-							if res, match := ruleset.Rules.Check(obj, evType.String(), quickmatch); match {
+						switch obj := ev.(type) {
+						case events.SigmaRecordGetter:
+							if res, match := checkRecords(ruleset.Rules, obj, evType.SigmaProduct(), quickmatch); match {
+								m.SigmaResults = res
+							}
+						case sigma.EventChecker:
+							if res, match := ruleset.Rules.Check(obj, evType.SigmaProduct(), quickmatch); match {
 								m.SigmaResults = res
 							}
 						}
@@ -252,14 +429,19 @@ func spawnWorkers(
 
 					switch obj := ev.(type) {
 					case *events.Suricata:
-						if obj.Alert != nil && obj.Alert.SignatureID > 0 {
-							if mapping, ok := mitreSignatureConverter.GetSid(obj.Alert.SignatureID); ok {
-								m.MitreAttack.Techniques = append(m.MitreAttack.Techniques, meta.Technique{
-									ID:   mapping.ID,
-									Name: mapping.Name,
-								})
-								m.MitreAttack.Set(mitreTechniqueMapper)
+						if obj.Alert != nil {
+							if res := obj.MitreAttack(); res != nil {
+								m.MitreAttack.Add(res.Techniques...)
+							}
+							if mitreSignatureConverter != nil && obj.Alert.SignatureID > 0 {
+								if mapping, ok := mitreSignatureConverter.GetSid(obj.Alert.SignatureID); ok {
+									m.MitreAttack.Add(meta.Technique{
+										ID:   mapping.ID,
+										Name: mapping.Name,
+									})
+								}
 							}
+							m.MitreAttack.Set(mitreTechniqueMapper)
 						}
 					case *events.DynamicWinlogbeat:
 						if res := obj.MitreAttack(); res != nil {
@@ -271,20 +453,78 @@ func spawnWorkers(
 					if len(m.MitreAttack.Techniques) == 0 {
 						m.MitreAttack = nil
 					}
-					if emitCh != nil && (m.MitreAttack != nil || m.SigmaResults != nil) {
+					emitEvent := m.MitreAttack != nil || m.SigmaResults != nil || len(m.IOC) > 0
+					if emitCh != nil && emitEvent {
 						m.EventData = e.DumpEventData()
 					}
 					m.EventType = evType.String()
 					e.SetAsset(*m.SetDirection())
-
-					modified, err := e.JSONFormat()
-					if err != nil {
-						errs.Send(err)
-						continue loop
+					var alertDocs []alerts.Alert
+					if emitCh != nil && emitEvent && suppress != nil {
+						alertDocs = alerts.New(m, msg.Time, alerts.Reference{
+							Source:    msg.Source,
+							Key:       msg.Key,
+							Offset:    msg.Offset,
+							Partition: msg.Partition,
+						}, levels)
 					}
-					msg.Data = modified
-					if emitCh != nil && (m.MitreAttack != nil || m.SigmaResults != nil) {
-						emitCh <- msg
+					// full rendering is skipped when every output gets anonymized data anyway
+					if !anonymizeAll {
+						modified, err := e.JSONFormat()
+						if err != nil {
+							errs.Send(err)
+							continue loop
+						}
+						msg.Data = modified
+					}
+					views := make(map[string][]byte)
+					if render[shipper.ViewECS] && !anonymizeAll {
+						if views[shipper.ViewECS], err = events.FormatECS(e, ecsOriginal, msg.Data); err != nil {
+							errs.Send(err)
+							continue loop
+						}
+					}
+					if pseudonymizer != nil {
+						anonymized, replacements, err := renderAnonymized(pseudonymizer, e, m, msg.Data)
+						if err != nil {
+							errs.Send(err)
+							continue loop
+						}
+						for i := range alertDocs {
+							alertDocs[i].Anonymize(replacements)
+						}
+						if anonymizeAll {
+							msg.Data = anonymized
+						}
+						views[shipper.ViewAnonymized] = anonymized
+						if render[shipper.ViewAnonymizedECS] || (anonymizeAll && render[shipper.ViewECS]) {
+							ecs, err := events.FormatECS(e, ecsOriginal, anonymized)
+							if err != nil {
+								errs.Send(err)
+								continue loop
+							}
+							views[shipper.ViewAnonymizedECS] = ecs
+							if anonymizeAll {
+								views[shipper.ViewECS] = ecs
+							}
+						}
+					}
+					if len(views) > 0 {
+						msg.Views = views
+					}
+					if emitCh != nil && emitEvent {
+						if suppress == nil {
+							emitCh <- msg
+						}
+						for _, a := range alertDocs {
+							pass, closed := suppress.Add(a)
+							for _, summary := range closed {
+								sendAlert(emitCh, summary, anonymizeAll, errs)
+							}
+							if pass {
+								sendAlert(emitCh, a, anonymizeAll, errs)
+							}
+						}
 					}
 					tx <- msg
 				}
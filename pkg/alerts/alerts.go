@@ -0,0 +1,157 @@
+package alerts
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/anonymize"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// Rule describes detection that produced alert
+type Rule struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title,omitempty"`
+	Level string   `json:"level,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Engine is sigma for rule matches, otherwise name of event type or ioc that carried detection
+	Engine string `json:"engine"`
+}
+
+// Reference points to matched event in its input, full event is found from regular outputs
+type Reference struct {
+	Type        string `json:"type"`
+	Source      string `json:"source,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Offset      int64  `json:"offset"`
+	Partition   int64  `json:"partition"`
+	CommunityID string `json:"community_id,omitempty"`
+}
+
+// Endpoint is compact view of meta.Asset
+type Endpoint struct {
+	Host  string `json:"host,omitempty"`
+	Alias string `json:"alias,omitempty"`
+	IP    net.IP `json:"ip,omitempty"`
+	Asset bool   `json:"asset"`
+}
+
+func newEndpoint(a *meta.Asset) *Endpoint {
+	if a == nil || (a.Host == "" && a.IP == nil) {
+		return nil
+	}
+	return &Endpoint{Host: a.Host, Alias: a.Alias, IP: a.IP, Asset: a.IsAsset}
+}
+
+// name is host name of endpoint, or textual address if name is not known
+func (e *Endpoint) name() string {
+	switch {
+	case e == nil:
+		return ""
+	case e.Host != "":
+		return e.Host
+	case e.IP != nil:
+		return e.IP.String()
+	}
+	return ""
+}
+
+// Alert is compact document for emit outputs, one per matched rule
+type Alert struct {
+	Timestamp   time.Time        `json:"@timestamp"`
+	Rule        Rule             `json:"rule"`
+	Event       Reference        `json:"event"`
+	Asset       *Endpoint        `json:"asset,omitempty"`
+	Source      *Endpoint        `json:"source,omitempty"`
+	Destination *Endpoint        `json:"destination,omitempty"`
+	Direction   string           `json:"direction,omitempty"`
+	Techniques  []meta.Technique `json:"techniques,omitempty"`
+	// Count is number of matches that alert stands for, more than one only for summaries
+	Count     int        `json:"count"`
+	Summary   bool       `json:"summary,omitempty"`
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+	LastSeen  *time.Time `json:"last_seen,omitempty"`
+
+	// Anonymized is pseudonymized copy of alert, set if any emit output needs it
+	Anonymized *Alert `json:"-"`
+}
+
+// New builds alert documents from enriched event meta
+// each sigma result becomes separate alert, other detections, such as suricata alerts with ATT&CK metadata or IOC matches, produce one
+// levels maps sigma rule ID to rule level, as level is not part of match results
+func New(m *meta.GameAsset, ts time.Time, ref Reference, levels map[string]string) []Alert {
+	if ref.Type == "" {
+		ref.Type = m.EventType
+	}
+	if ref.CommunityID == "" {
+		ref.CommunityID = m.CommunityID
+	}
+	base := Alert{
+		Timestamp:   ts,
+		Event:       ref,
+		Asset:       newEndpoint(&m.Asset),
+		Source:      newEndpoint(m.Source),
+		Destination: newEndpoint(m.Destination),
+		Direction:   m.DirectionString,
+		Count:       1,
+	}
+	if m.MitreAttack != nil {
+		base.Techniques = m.MitreAttack.Techniques
+	}
+	out := make([]Alert, 0, len(m.SigmaResults))
+	for _, res := range m.SigmaResults {
+		a := base
+		a.Rule = Rule{
+			ID:     res.ID,
+			Title:  res.Title,
+			Level:  levels[res.ID],
+			Tags:   res.Tags,
+			Engine: "sigma",
+		}
+		out = append(out, a)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	a := base
+	switch {
+	case m.EventData != nil && (m.EventData.ID != 0 || len(m.EventData.Fields) > 0):
+		a.Rule = Rule{ID: m.EventData.Key, Engine: ref.Type}
+		if m.EventData.ID != 0 {
+			a.Rule.ID = strconv.Itoa(m.EventData.ID)
+		}
+		for _, f := range m.EventData.Fields {
+			if f != "" {
+				a.Rule.Title = f
+				break
+			}
+		}
+	case len(m.IOC) > 0:
+		a.Rule = Rule{ID: m.IOC[0].Indicator, Title: m.IOC[0].Description, Engine: "ioc"}
+		if a.Rule.Title == "" {
+			a.Rule.Title = m.IOC[0].Source
+		}
+	default:
+		a.Rule = Rule{ID: ref.Type, Engine: ref.Type}
+	}
+	return append(out, a)
+}
+
+// Anonymize attaches pseudonymized copy of alert, original is not modified
+func (a *Alert) Anonymize(replacements map[string]string) {
+	c := *a
+	c.Anonymized = nil
+	for _, e := range []**Endpoint{&c.Asset, &c.Source, &c.Destination} {
+		if *e != nil {
+			copied := **e
+			*e = &copied
+		}
+	}
+	c.Rule.Tags = append([]string(nil), a.Rule.Tags...)
+	// techniques are shared with event meta and do not identify anyone
+	c.Techniques = nil
+	anonymize.NewReplacer(replacements).Walk(&c)
+	c.Techniques = a.Techniques
+	a.Anonymized = &c
+}
@@ -0,0 +1,99 @@
+package alerts
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/markuskont/go-sigma-rule-engine/pkg/sigma"
+)
+
+func TestSuppressor(t *testing.T) {
+	ts := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC)
+	m := &meta.GameAsset{
+		Asset:           meta.Asset{Host: "ws01", IP: net.ParseIP("10.0.0.5"), Indicators: meta.Indicators{IsAsset: true}},
+		Source:          &meta.Asset{Host: "ws01", IP: net.ParseIP("10.0.0.5"), Indicators: meta.Indicators{IsAsset: true}},
+		Destination:     &meta.Asset{IP: net.ParseIP("192.0.2.10")},
+		DirectionString: "Outbound",
+		EventType:       "sysmon",
+		SigmaResults:    sigma.Results{{ID: "r1", Title: "Suspicious", Tags: sigma.Tags{"attack.t1059"}}},
+		MitreAttack:     &meta.MitreAttack{Techniques: []meta.Technique{{ID: "T1059"}}},
+	}
+	docs := New(m, ts, Reference{Source: "sysmon", Offset: 42}, map[string]string{"r1": "high"})
+	if len(docs) != 1 || docs[0].Rule.Level != "high" || docs[0].Rule.Engine != "sigma" || docs[0].Event.Type != "sysmon" || len(docs[0].Techniques) != 1 {
+		t.Fatalf("bad alert %+v", docs)
+	}
+
+	docs[0].Anonymize(map[string]string{"ws01": "host-a", "10.0.0.5": "10.255.0.1"})
+	if anon := docs[0].Anonymized; anon == nil || anon.Asset.Host != "host-a" || anon.Source.IP.String() != "10.255.0.1" {
+		t.Fatalf("bad anonymized alert %+v", docs[0].Anonymized)
+	}
+	if docs[0].Asset.Host != "ws01" || docs[0].Source.IP.String() != "10.0.0.5" {
+		t.Fatal("original alert should not be modified by anonymization")
+	}
+
+	s, err := NewSuppressor(&Config{Window: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(a Alert, offset time.Duration) Alert {
+		a.Timestamp = ts.Add(offset)
+		return a
+	}
+	a := docs[0]
+	if pass, closed := s.Add(at(a, 0)); !pass || len(closed) != 0 {
+		t.Fatal("first hit should pass")
+	}
+	for _, offset := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		if pass, _ := s.Add(at(a, offset)); pass {
+			t.Fatal("repeated hit should be suppressed")
+		}
+	}
+	other := a
+	other.Asset = &Endpoint{Host: "ws02"}
+	if pass, _ := s.Add(at(other, 30*time.Second)); !pass {
+		t.Fatal("hit on another host should pass")
+	}
+	pass, closed := s.Add(at(a, 2*time.Minute))
+	if !pass || len(closed) != 1 {
+		t.Fatalf("window should be closed by newer hit, got %v %+v", pass, closed)
+	}
+	sum := closed[0]
+	if !sum.Summary || sum.Count != 3 || !sum.FirstSeen.Equal(ts) || !sum.LastSeen.Equal(ts.Add(20*time.Second)) {
+		t.Fatalf("bad summary %+v", sum)
+	}
+	if sum.Anonymized == nil || sum.Anonymized.Count != 3 {
+		t.Fatal("anonymized summary should carry count")
+	}
+	if rest := s.Flush(); len(rest) != 0 {
+		t.Fatalf("windows without repeated hits should not be summarized, got %+v", rest)
+	}
+
+	// late arrival opens window that ends before windows opened earlier
+	late, _ := NewSuppressor(&Config{Window: time.Minute})
+	late.Add(at(a, 0))
+	late.Add(at(other, -50*time.Second))
+	late.Add(at(other, -40*time.Second))
+	if pass, closed := late.Add(at(a, 30*time.Second)); pass || len(closed) != 1 || closed[0].Asset.Host != "ws02" {
+		t.Fatalf("out of order window should be closed, got %v %+v", pass, closed)
+	}
+
+	capped, _ := NewSuppressor(&Config{Window: time.Minute, Size: 1})
+	capped.Add(at(a, 0))
+	capped.Add(at(a, time.Second))
+	if pass, closed := capped.Add(at(other, 5*time.Second)); !pass || len(closed) != 1 || closed[0].Count != 2 {
+		t.Fatalf("window ending first should be closed over size limit, got %v %+v", pass, closed)
+	}
+
+	pairs, _ := NewSuppressor(&Config{Window: time.Minute, Key: ByPair})
+	pairs.Add(at(a, 0))
+	moved := a
+	moved.Destination = &Endpoint{IP: net.ParseIP("192.0.2.11")}
+	if pass, _ := pairs.Add(at(moved, time.Second)); !pass {
+		t.Fatal("hit between another pair should pass")
+	}
+	if _, err := NewSuppressor(&Config{Key: "rule"}); err == nil {
+		t.Fatal("unknown key should be rejected")
+	}
+}
@@ -0,0 +1,248 @@
+package alerts
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Suppression keys
+const (
+	// ByHost suppresses repeated hits of a rule on same host
+	ByHost = "host"
+	// ByPair suppresses repeated hits of a rule between same source and destination
+	// events without both endpoints fall back to host
+	ByPair = "pair"
+)
+
+type Config struct {
+	// Window is how long repeated hits are suppressed after first alert, zero disables suppression
+	// measured in event time, so replayed logs behave like live ones
+	Window time.Duration
+	// Key is ByHost or ByPair
+	Key string
+	// Size is maximum number of open windows, those ending first are closed first
+	Size int
+	// Interval between checks for closed windows
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	switch c.Key {
+	case "":
+		c.Key = ByHost
+	case ByHost, ByPair:
+	default:
+		return fmt.Errorf("invalid alert suppression key %s, use %s or %s", c.Key, ByHost, ByPair)
+	}
+	if c.Window < 0 {
+		c.Window = 0
+	}
+	if c.Size < 1 {
+		c.Size = 100000
+	}
+	if c.Interval <= 0 {
+		c.Interval = 10 * time.Second
+	}
+	return nil
+}
+
+type window struct {
+	key   string
+	first Alert
+	last  time.Time
+	count int
+	// index is position in queue, maintained by heap
+	index int
+}
+
+func (w window) end(size time.Duration) time.Time { return w.first.Timestamp.Add(size) }
+
+// windows is a min-heap of open windows by start time, as all windows have same size it is also end time order
+type windows []*window
+
+func (q windows) Len() int           { return len(q) }
+func (q windows) Less(i, j int) bool { return q[i].first.Timestamp.Before(q[j].first.Timestamp) }
+func (q windows) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *windows) Push(x interface{}) {
+	w := x.(*window)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *windows) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
+
+// summary is first alert of window with total number of hits, nil if nothing was suppressed
+func (w window) summary() *Alert {
+	if w.count < 2 {
+		return nil
+	}
+	set := func(a *Alert) {
+		first, last := a.Timestamp, w.last
+		a.Timestamp = last
+		a.Count = w.count
+		a.Summary = true
+		a.FirstSeen, a.LastSeen = &first, &last
+	}
+	a := w.first
+	set(&a)
+	if a.Anonymized != nil {
+		anon := *a.Anonymized
+		set(&anon)
+		a.Anonymized = &anon
+	}
+	return &a
+}
+
+// Suppressor passes first alert of rule and key through, and counts repeated hits until window closes
+type Suppressor struct {
+	mu    *sync.Mutex
+	data  map[string]*window
+	queue *windows
+	// clock is newest observed alert timestamp, wall is when it was observed
+	clock, wall time.Time
+	Config
+}
+
+func NewSuppressor(c *Config) (*Suppressor, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &Suppressor{
+		mu:     &sync.Mutex{},
+		data:   make(map[string]*window),
+		queue:  &windows{},
+		Config: *c,
+	}, nil
+}
+
+// keyOf returns suppression key of alert
+func (s Suppressor) keyOf(a Alert) string {
+	bits := []string{a.Rule.Engine, a.Rule.ID}
+	src, dest := a.Source.name(), a.Destination.name()
+	if s.Key == ByPair && src != "" && dest != "" {
+		bits = append(bits, src, dest)
+	} else {
+		bits = append(bits, a.Asset.name())
+	}
+	return strings.ToLower(strings.Join(bits, "|"))
+}
+
+// now is event clock advanced by wall time since newest alert, so windows close on idle streams as well
+// caller must hold lock
+func (s *Suppressor) now() time.Time {
+	if s.clock.IsZero() {
+		return time.Now()
+	}
+	return s.clock.Add(time.Since(s.wall))
+}
+
+// Add reports whether alert should be emitted
+// summaries of windows that closed by the time of alert are returned as well
+func (s *Suppressor) Add(a Alert) (bool, []Alert) {
+	if s.Window == 0 {
+		return true, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a.Timestamp.IsZero() {
+		a.Timestamp = s.now()
+	}
+	if a.Timestamp.After(s.clock) {
+		s.clock, s.wall = a.Timestamp, time.Now()
+	}
+	closed := s.expire(s.now())
+
+	key := s.keyOf(a)
+	if w, ok := s.data[key]; ok && a.Timestamp.Before(w.end(s.Window)) {
+		w.count++
+		if a.Timestamp.After(w.last) {
+			w.last = a.Timestamp
+		}
+		return false, closed
+	} else if ok {
+		closed = s.close(w, closed)
+	}
+	w := &window{key: key, first: a, last: a.Timestamp, count: 1}
+	heap.Push(s.queue, w)
+	s.data[key] = w
+	for s.queue.Len() > s.Size {
+		closed = s.close((*s.queue)[0], closed)
+	}
+	return true, closed
+}
+
+// expire closes windows that ended before now, caller must hold lock
+// queue is ordered by end time, so only expired windows are visited even if alerts arrive out of order
+func (s *Suppressor) expire(now time.Time) []Alert {
+	var closed []Alert
+	for s.queue.Len() > 0 && !(*s.queue)[0].end(s.Window).After(now) {
+		closed = s.close((*s.queue)[0], closed)
+	}
+	return closed
+}
+
+func (s *Suppressor) close(w *window, closed []Alert) []Alert {
+	heap.Remove(s.queue, w.index)
+	delete(s.data, w.key)
+	if a := w.summary(); a != nil {
+		closed = append(closed, *a)
+	}
+	return closed
+}
+
+// Expire closes windows that have ended and returns their summaries
+func (s *Suppressor) Expire() []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expire(s.now())
+}
+
+// Flush closes all open windows and returns their summaries
+func (s *Suppressor) Flush() []Alert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var closed []Alert
+	for s.queue.Len() > 0 {
+		closed = s.close((*s.queue)[0], closed)
+	}
+	return closed
+}
+
+// Run periodically closes windows and passes summaries to fn, remaining windows are flushed when context is done
+func (s *Suppressor) Run(ctx context.Context, fn func(Alert)) {
+	if s.Window == 0 {
+		return
+	}
+	tick := time.NewTicker(s.Interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			for _, a := range s.Flush() {
+				fn(a)
+			}
+			return
+		case <-tick.C:
+			for _, a := range s.Expire() {
+				fn(a)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+type Config struct {
+	// Key is HMAC secret, takes precedence over KeyFile
+	Key string
+	// KeyFile holds hex encoded secret, a random one is generated if file does not exist
+	// pseudonyms stay the same across restarts only as long as the key does
+	KeyFile string
+}
+
+func (c *Config) Validate() error {
+	if c == nil || (c.Key == "" && c.KeyFile == "") {
+		return fmt.Errorf("neither anonymization key nor key file configured")
+	}
+	var err error
+	if c.KeyFile, err = utils.ExpandHome(c.KeyFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Pseudonymizer derives stable replacement values with keyed HMAC
+// same input always gives same pseudonym, while original cannot be recovered without the key
+type Pseudonymizer struct {
+	key []byte
+}
+
+func NewPseudonymizer(c *Config) (*Pseudonymizer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.Key != "" {
+		return &Pseudonymizer{key: []byte(c.Key)}, nil
+	}
+	key, err := loadKey(c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Pseudonymizer{key: key}, nil
+}
+
+func loadKey(path string) ([]byte, error) {
+	if !utils.FileNotExists(path) {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := hex.DecodeString(strings.TrimSpace(string(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("anonymization key file %s: %s", path, err)
+		}
+		if len(key) == 0 {
+			return nil, fmt.Errorf("anonymization key file %s is empty", path)
+		}
+		return key, nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (p Pseudonymizer) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(value)))
+	return mac.Sum(nil)
+}
+
+func (p Pseudonymizer) token(kind, value string) string {
+	return hex.EncodeToString(p.sum(kind, value)[:5])
+}
+
+// Host returns pseudonym for host name, case is ignored as windows and linux logs disagree on it
+func (p Pseudonymizer) Host(name string) string { return "host-" + p.token("host", name) }
+
+// User returns pseudonym for account name
+func (p Pseudonymizer) User(name string) string { return "user-" + p.token("user", name) }
+
+// Domain returns pseudonym for DNS or NetBIOS domain
+// reserved .invalid TLD keeps value syntactically valid while making it obvious it is not real
+func (p Pseudonymizer) Domain(name string) string {
+	return "dom-" + p.token("domain", strings.TrimSuffix(name, ".")) + ".invalid"
+}
+
+// IP returns pseudonym address that is still valid for address typed fields
+// IPv4 is mapped into reserved 240.0.0.0/4 and IPv6 into unique local fd00::/8
+func (p Pseudonymizer) IP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		sum := p.sum("ip", v4.String())
+		return net.IPv4(0xf0|(sum[0]&0x0f), sum[1], sum[2], sum[3]).To4()
+	}
+	sum := p.sum("ip", ip.String())
+	out := make(net.IP, net.IPv6len)
+	out[0] = 0xfd
+	copy(out[1:], sum[:15])
+	return out
+}
+
+// MAC returns locally administered unicast hardware address
+func (p Pseudonymizer) MAC(mac string) string {
+	sum := p.sum("mac", mac)
+	sum[0] = (sum[0] | 0x02) & 0xfe
+	out := make([]string, 6)
+	for i := range out {
+		out[i] = hex.EncodeToString(sum[i : i+1])
+	}
+	return strings.Join(out, ":")
+}
+
+var (
+	ipv4Literal = regexp.MustCompile(`(?:[0-9]{1,3}\.){3}[0-9]{1,3}`)
+	ipv6Literal = regexp.MustCompile(`[0-9a-fA-F]*:[0-9a-fA-F:]*:[0-9a-fA-F.]*`)
+)
+
+// Addresses finds address literals in raw message, e.g. in free text fields that are not parsed
+// loopback, unspecified and multicast addresses do not identify anything and are skipped
+// matching is loose, as Replacer only substitutes whole tokens anyway
+func Addresses(raw []byte) []net.IP {
+	out := make([]net.IP, 0)
+	seen := make(map[string]bool)
+	eachAddress(raw, func(_ string, ip net.IP) {
+		if key := ip.String(); !seen[key] {
+			seen[key] = true
+			out = append(out, ip)
+		}
+	})
+	return out
+}
+
+// AddressLiterals is like Addresses, but keyed by literal as it was written in raw message
+// same address may appear under several spellings, e.g. full form IPv6 or IPv4 mapped IPv6 address
+// and each of them needs its own replacement
+func AddressLiterals(raw []byte) map[string]net.IP {
+	out := make(map[string]net.IP)
+	eachAddress(raw, func(literal string, ip net.IP) { out[literal] = ip })
+	return out
+}
+
+func eachAddress(raw []byte, fn func(string, net.IP)) {
+	for _, re := range []*regexp.Regexp{ipv4Literal, ipv6Literal} {
+		for _, m := range re.FindAll(raw, -1) {
+			literal := strings.TrimRight(string(m), ".:")
+			ip := fields.ParseIP(literal)
+			if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+				continue
+			}
+			fn(literal, ip)
+		}
+	}
+}
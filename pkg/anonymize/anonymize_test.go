@@ -0,0 +1,89 @@
+package anonymize
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+func TestPseudonyms(t *testing.T) {
+	p, err := NewPseudonymizer(&Config{Key: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Host("WS01") != p.Host("ws01") {
+		t.Fatal("host pseudonym should ignore case")
+	}
+	other, _ := NewPseudonymizer(&Config{Key: "other"})
+	if p.User("alice") == other.User("alice") {
+		t.Fatal("pseudonym should depend on key")
+	}
+	if ip := p.IP(net.ParseIP("10.0.0.5")); ip.To4() == nil || ip[0]&0xf0 != 0xf0 {
+		t.Fatalf("IPv4 pseudonym %s not in reserved range", ip)
+	}
+
+	ip := net.ParseIP("10.0.0.5").To4()
+	r := NewReplacer(map[string]string{
+		"ws01":          "alias01",
+		"10.0.0.5":      p.IP(ip).String(),
+		"alice":         p.User("alice"),
+		"corp.example":  p.Domain("corp.example"),
+		"ws01.corp.lan": "alias01",
+	})
+	for in, want := range map[string]string{
+		"Accepted publickey for alice from 10.0.0.5 port 22": "Accepted publickey for " + p.User("alice") + " from " + p.IP(ip).String() + " port 22",
+		"10.0.0.50 and 110.0.0.5 are other hosts":            "10.0.0.50 and 110.0.0.5 are other hosts",
+		`\\WS01\c$ on ws01-backup`:                           `\\alias01\c$ on ws01-backup`,
+		"WS01.corp.lan resolved by dc.corp.example":          "alias01 resolved by dc." + p.Domain("corp.example"),
+	} {
+		if got := r.Text(in); got != want {
+			t.Fatalf("replacing %q\ngot  %q\nwant %q", in, got, want)
+		}
+	}
+
+	ev := struct {
+		Message string
+		Src     *fields.StringIP
+		Extra   map[string]interface{}
+		Meta    meta.GameAsset
+	}{
+		Message: "login by alice",
+		Src:     &fields.StringIP{IP: ip},
+		Extra:   map[string]interface{}{"hosts": []interface{}{"ws01", "other"}},
+		Meta:    meta.GameAsset{Asset: meta.Asset{Host: "ws01", IP: ip}},
+	}
+	shared := ev.Meta
+	r.Walk(&ev)
+	r.GameAsset(&ev.Meta)
+	if ev.Message != "login by "+p.User("alice") || !ev.Src.IP.Equal(p.IP(ip)) {
+		t.Fatalf("struct fields not replaced: %+v", ev)
+	}
+	if hosts := ev.Extra["hosts"].([]interface{}); hosts[0] != "alias01" || hosts[1] != "other" {
+		t.Fatalf("nested values not replaced: %+v", hosts)
+	}
+	if ev.Meta.Host != "alias01" || shared.Host != "ws01" {
+		t.Fatalf("meta not replaced or shared copy modified: %+v %+v", ev.Meta.Asset, shared.Asset)
+	}
+
+	found := Addresses([]byte(`{"msg":"from 192.168.1.1,10.0.0.5:443 and fe80::1. at 12:30:45 127.0.0.1"}`))
+	if len(found) != 3 {
+		t.Fatalf("expected 3 addresses, got %v", found)
+	}
+
+	raw := `{"msg":"from 2001:0db8:0000:0000:0000:0000:0000:0001 and ::ffff:10.0.0.5"}`
+	literals := AddressLiterals([]byte(raw))
+	pairs := make(map[string]string)
+	for literal, ip := range literals {
+		pairs[literal] = p.IP(ip).String()
+	}
+	full := net.ParseIP("2001:db8::1")
+	if !literals["2001:0db8:0000:0000:0000:0000:0000:0001"].Equal(full) {
+		t.Fatalf("full form IPv6 literal not found: %v", literals)
+	}
+	want := `{"msg":"from ` + p.IP(full).String() + ` and ` + p.IP(net.ParseIP("10.0.0.5")).String() + `"}`
+	if got := NewReplacer(pairs).Text(raw); got != want {
+		t.Fatalf("non-canonical literals not replaced: %s", got)
+	}
+}
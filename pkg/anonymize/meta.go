@@ -0,0 +1,95 @@
+package anonymize
+
+import (
+	"net"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// GameAsset rewrites identifying values in event meta
+// assets, intel and indicator matches are copied first, as they may be shared with caches and other events
+func (r Replacer) GameAsset(m *meta.GameAsset) {
+	if m == nil || len(r.from) == 0 {
+		return
+	}
+	m.Asset = r.asset(m.Asset)
+	if m.Source != nil {
+		src := r.asset(*m.Source)
+		m.Source = &src
+	}
+	if m.Destination != nil {
+		dest := r.asset(*m.Destination)
+		m.Destination = &dest
+	}
+	if m.EventData != nil {
+		data := *m.EventData
+		data.Key = r.Text(data.Key)
+		data.Fields = r.strings(data.Fields)
+		m.EventData = &data
+	}
+	if len(m.Ancestors) > 0 {
+		procs := make([]meta.Process, len(m.Ancestors))
+		for i, proc := range m.Ancestors {
+			proc.Image = r.Text(proc.Image)
+			proc.CommandLine = r.Text(proc.CommandLine)
+			proc.User = r.Text(proc.User)
+			procs[i] = proc
+		}
+		m.Ancestors = procs
+	}
+	if len(m.IOC) > 0 {
+		matches := make([]meta.IOCMatch, len(m.IOC))
+		for i, match := range m.IOC {
+			match.Value = r.Text(match.Value)
+			match.Indicator = r.Text(match.Indicator)
+			matches[i] = match
+		}
+		m.IOC = matches
+	}
+	if m.Intel != nil {
+		intel := &meta.Intel{Key: m.Intel.Key, Matches: make([]meta.IntelMatch, len(m.Intel.Matches))}
+		for i, match := range m.Intel.Matches {
+			fields := make(map[string]string, len(match.Fields))
+			for k, v := range match.Fields {
+				fields[k] = r.Text(v)
+			}
+			match.Value = r.Text(match.Value)
+			match.Fields = fields
+			intel.Matches[i] = match
+		}
+		m.Intel = intel
+	}
+}
+
+func (r Replacer) asset(a meta.Asset) meta.Asset {
+	a.Host = r.Text(a.Host)
+	a.VM = r.Text(a.VM)
+	a.MAC = r.Text(a.MAC)
+	a.IP = r.ip(a.IP)
+	a.DNSNames = r.strings(a.DNSNames)
+	return a
+}
+
+func (r Replacer) ip(ip net.IP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	if out := net.ParseIP(r.Text(ip.String())); out != nil {
+		if v4 := out.To4(); v4 != nil {
+			return v4
+		}
+		return out
+	}
+	return ip
+}
+
+func (r Replacer) strings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	for i, s := range in {
+		out[i] = r.Text(s)
+	}
+	return out
+}
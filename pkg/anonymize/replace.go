@@ -0,0 +1,191 @@
+package anonymize
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Replacer substitutes whole tokens, case insensitively, in strings and nested values
+// token must not be surrounded by letters, digits, underscore or dash, so 10.0.0.1 does not match inside 10.0.0.10
+// and host name is replaced in FQDN and UNC path but not in a longer host name
+type Replacer struct {
+	from []string
+	to   []string
+}
+
+func NewReplacer(pairs map[string]string) *Replacer {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	// longest first, so FQDN wins over its host label
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	r := &Replacer{from: make([]string, len(keys)), to: make([]string, len(keys))}
+	for i, k := range keys {
+		r.from[i], r.to[i] = lower(k), pairs[k]
+	}
+	return r
+}
+
+// Len returns number of replaced values
+func (r Replacer) Len() int { return len(r.from) }
+
+// lower is ASCII only, so byte offsets stay the same as in original
+func lower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 32
+		}
+	}
+	return string(b)
+}
+
+func isWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// Text replaces all known values in s
+func (r Replacer) Text(s string) string {
+	if len(r.from) == 0 || s == "" {
+		return s
+	}
+	ls := lower(s)
+	var b strings.Builder
+	var last int
+	for i := 0; i < len(ls); {
+		if i > 0 && isWord(ls[i-1]) {
+			i++
+			continue
+		}
+		matched := false
+		for j, from := range r.from {
+			end := i + len(from)
+			if strings.HasPrefix(ls[i:], from) && (end == len(ls) || !isWord(ls[end])) {
+				b.WriteString(s[last:i])
+				b.WriteString(r.to[j])
+				i, last, matched = end, end, true
+				break
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Value rewrites strings in decoded JSON, maps and slices are modified in place
+func (r Replacer) Value(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return r.Text(val)
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = r.Value(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.Value(item)
+		}
+	case []string:
+		for i, item := range val {
+			val[i] = r.Text(item)
+		}
+	case map[string]string:
+		for k, item := range val {
+			val[k] = r.Text(item)
+		}
+	}
+	return v
+}
+
+var ipType = reflect.TypeOf(net.IP{})
+
+// Walk rewrites exported string and address fields of structure that v points to
+// keys of maps are left as they are, unexported fields can not be set and are skipped
+// pointers are followed and modified in place, so v must not share data with other events
+func (r Replacer) Walk(v interface{}) {
+	if len(r.from) == 0 {
+		return
+	}
+	r.walk(reflect.ValueOf(v))
+}
+
+func (r Replacer) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			// values stored in interface are not addressable, replace them as a whole
+			if v.CanSet() {
+				v.Set(reflect.ValueOf(r.Value(v.Interface())))
+			}
+			return
+		}
+		r.walk(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				r.walk(f)
+			}
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(r.Text(v.String()))
+		}
+	case reflect.Slice:
+		if v.Type() == ipType {
+			if v.Len() > 0 && v.CanSet() {
+				v.Set(reflect.ValueOf(r.ip(v.Interface().(net.IP))))
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			r.walk(v.Index(i))
+		}
+	case reflect.Array:
+		if v.CanSet() {
+			for i := 0; i < v.Len(); i++ {
+				r.walk(v.Index(i))
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			item := iter.Value()
+			switch item.Kind() {
+			case reflect.String:
+				v.SetMapIndex(iter.Key(), reflect.ValueOf(r.Text(item.String())).Convert(item.Type()))
+			case reflect.Interface:
+				if !item.IsNil() {
+					v.SetMapIndex(iter.Key(), reflect.ValueOf(r.Value(item.Interface())))
+				}
+			default:
+				// map values are not addressable, copy, rewrite and store back
+				cp := reflect.New(item.Type()).Elem()
+				cp.Set(item)
+				r.walk(cp)
+				v.SetMapIndex(iter.Key(), cp)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package communityid implements Community ID v1 flow hashing
+// https://github.com/corelight/community-id-spec
+package communityid
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// IANA protocol numbers that hash includes ports for
+const (
+	ProtoICMP   uint8 = 1
+	ProtoTCP    uint8 = 6
+	ProtoUDP    uint8 = 17
+	ProtoICMPv6 uint8 = 58
+	ProtoSCTP   uint8 = 132
+)
+
+// icmpEquivalents map ICMP request types to reply types and vice versa, so both directions hash the same
+var (
+	icmpEquivalents = map[uint16]uint16{
+		8: 0, 0: 8, 13: 14, 14: 13, 15: 16, 16: 15, 10: 9, 9: 10, 17: 18, 18: 17,
+	}
+	icmpv6Equivalents = map[uint16]uint16{
+		128: 129, 129: 128, 133: 134, 134: 133, 135: 136, 136: 135, 130: 131, 131: 130, 144: 145, 145: 144,
+	}
+)
+
+// Protocol converts textual protocol name or number into IANA protocol number
+func Protocol(name string) (uint8, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "tcp":
+		return ProtoTCP, true
+	case "udp":
+		return ProtoUDP, true
+	case "icmp":
+		return ProtoICMP, true
+	case "icmpv6", "ipv6-icmp", "icmp6":
+		return ProtoICMPv6, true
+	case "sctp":
+		return ProtoSCTP, true
+	}
+	if num, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return uint8(num), true
+	}
+	return 0, false
+}
+
+// Hash computes Community ID of flow with seed
+// for ICMP source port is message type and destination port is message code
+func Hash(seed uint16, src, dst net.IP, sport, dport uint16, proto uint8) (string, bool) {
+	if src == nil || dst == nil {
+		return "", false
+	}
+	if v4, v4dst := src.To4(), dst.To4(); v4 != nil && v4dst != nil {
+		src, dst = v4, v4dst
+	} else if v4 != nil || v4dst != nil {
+		// mixed address families can not be a single flow
+		return "", false
+	} else {
+		src, dst = src.To16(), dst.To16()
+	}
+
+	oneWay := false
+	switch proto {
+	case ProtoICMP:
+		sport, dport, oneWay = icmpPorts(icmpEquivalents, sport, dport)
+	case ProtoICMPv6:
+		sport, dport, oneWay = icmpPorts(icmpv6Equivalents, sport, dport)
+	}
+	if !oneWay {
+		if cmp := bytes.Compare(src, dst); cmp > 0 || (cmp == 0 && sport > dport) {
+			src, dst = dst, src
+			sport, dport = dport, sport
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, seed)
+	buf.Write(src)
+	buf.Write(dst)
+	buf.WriteByte(proto)
+	buf.WriteByte(0)
+	switch proto {
+	case ProtoICMP, ProtoICMPv6, ProtoTCP, ProtoUDP, ProtoSCTP:
+		binary.Write(buf, binary.BigEndian, sport)
+		binary.Write(buf, binary.BigEndian, dport)
+	}
+	sum := sha1.Sum(buf.Bytes())
+	return "1:" + base64.StdEncoding.EncodeToString(sum[:]), true
+}
+
+func icmpPorts(equivalents map[uint16]uint16, msgType, code uint16) (uint16, uint16, bool) {
+	if reply, ok := equivalents[msgType]; ok {
+		return msgType, reply, false
+	}
+	return msgType, code, true
+}
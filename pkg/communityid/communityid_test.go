@@ -0,0 +1,26 @@
+package communityid
+
+import (
+	"net"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	for _, tc := range []struct {
+		src, dst     string
+		sport, dport uint16
+		proto        uint8
+		want         string
+	}{
+		{"128.232.110.120", "66.35.250.204", 34855, 80, ProtoTCP, "1:LQU9qZlK+B5F3KDmev6m5PMibrg="},
+		{"66.35.250.204", "128.232.110.120", 80, 34855, ProtoTCP, "1:LQU9qZlK+B5F3KDmev6m5PMibrg="},
+		{"192.168.1.52", "8.8.8.8", 54585, 53, ProtoUDP, "1:d/FP5EW3wiY1vCndhwleRRKHowQ="},
+		{"192.168.0.89", "192.168.0.1", 8, 0, ProtoICMP, "1:X0snYXpgwiv9TZtqg64sgzUn6Dk="},
+		{"192.168.0.1", "192.168.0.89", 0, 0, ProtoICMP, "1:X0snYXpgwiv9TZtqg64sgzUn6Dk="},
+	} {
+		got, ok := Hash(0, net.ParseIP(tc.src), net.ParseIP(tc.dst), tc.sport, tc.dport, tc.proto)
+		if !ok || got != tc.want {
+			t.Fatalf("%s:%d -> %s:%d got %s, want %s", tc.src, tc.sport, tc.dst, tc.dport, got, tc.want)
+		}
+	}
+}
@@ -89,10 +89,35 @@ func open(path string) (io.ReadCloser, error) {
 	if file, err = os.Open(path); err != nil {
 		return nil, err
 	}
-	switch m {
-	case Gzip:
-		return gzip.NewReader(file)
-	default:
-		return file, nil
+	var rc io.ReadCloser = file
+	if m == Gzip {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		rc = multiCloser{Reader: gz, closers: []io.Closer{gz, file}}
+	}
+	// zeek ASCII logs are converted to JSON lines, so every reader of log files sees same format
+	buf := bufio.NewReader(rc)
+	if head, err := buf.Peek(len(zeekHeader)); err == nil && string(head) == zeekHeader {
+		return newZeekTSV(buf, rc), nil
+	}
+	return multiCloser{Reader: buf, closers: []io.Closer{rc}}, nil
+}
+
+// multiCloser closes wrapped readers along with underlying file
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m multiCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
 	}
+	return err
 }
@@ -0,0 +1,212 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// zeekHeader is the first line of zeek ASCII logs, JSON logs never start with it
+const zeekHeader = "#separator"
+
+// zeekTSV converts zeek ASCII logs into JSON lines as written by zeek JSON writer, so file inputs can treat both alike
+// header is parsed per block, as rotated logs may be concatenated and every block carries its own
+// log path from header is added as _path field for picking the log model
+type zeekTSV struct {
+	src    *bufio.Reader
+	closer io.Closer
+	out    bytes.Buffer
+	err    error
+
+	sep, setSep  string
+	empty, unset string
+	path         string
+	fields       []string
+	types        []string
+	hasPath      bool
+}
+
+func newZeekTSV(src *bufio.Reader, closer io.Closer) *zeekTSV {
+	return &zeekTSV{
+		src:    src,
+		closer: closer,
+		sep:    "\t",
+		setSep: ",",
+		empty:  "(empty)",
+		unset:  "-",
+	}
+}
+
+func (z *zeekTSV) Read(p []byte) (int, error) {
+	for z.out.Len() == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		line, err := z.src.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			z.line(line)
+		}
+		if err != nil {
+			z.err = err
+		}
+	}
+	return z.out.Read(p)
+}
+
+func (z *zeekTSV) Close() error { return z.closer.Close() }
+
+func (z *zeekTSV) line(line string) {
+	if strings.HasPrefix(line, "#") {
+		z.directive(line)
+		return
+	}
+	if len(z.fields) == 0 {
+		// data before header, nothing to name the columns with
+		return
+	}
+	z.out.Write(z.row(strings.Split(line, z.sep)))
+	z.out.WriteByte('\n')
+}
+
+func (z *zeekTSV) directive(line string) {
+	if strings.HasPrefix(line, zeekHeader+" ") {
+		z.sep = unescapeZeek(strings.TrimPrefix(line, zeekHeader+" "))
+		return
+	}
+	bits := strings.Split(line, z.sep)
+	values := bits[1:]
+	value := strings.Join(values, z.sep)
+	switch bits[0] {
+	case "#set_separator":
+		z.setSep = unescapeZeek(value)
+	case "#empty_field":
+		z.empty = value
+	case "#unset_field":
+		z.unset = value
+	case "#path":
+		z.path = value
+	case "#fields":
+		z.fields = values
+		z.hasPath = false
+		for _, f := range values {
+			if f == "_path" {
+				z.hasPath = true
+			}
+		}
+	case "#types":
+		z.types = values
+	case "#close":
+		z.fields, z.types = nil, nil
+	}
+}
+
+// row renders columns as JSON object, keeping column order, unset values are left out like zeek JSON writer does
+func (z *zeekTSV) row(columns []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	write := func(key string, val []byte) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	if z.path != "" && !z.hasPath {
+		p, _ := json.Marshal(z.path)
+		write("_path", p)
+	}
+	for i, f := range z.fields {
+		if i >= len(columns) {
+			break
+		}
+		typ := "string"
+		if i < len(z.types) {
+			typ = z.types[i]
+		}
+		if val, ok := z.value(typ, columns[i]); ok {
+			write(f, val)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// value converts column by its zeek type, false is returned for unset values
+func (z *zeekTSV) value(typ, raw string) ([]byte, bool) {
+	if raw == z.unset {
+		return nil, false
+	}
+	if i := strings.IndexByte(typ, '['); i != -1 && strings.HasSuffix(typ, "]") {
+		inner := typ[i+1 : len(typ)-1]
+		items := make([]json.RawMessage, 0)
+		if raw != z.empty {
+			for _, item := range strings.Split(raw, z.setSep) {
+				if val, ok := z.value(inner, item); ok {
+					items = append(items, val)
+				} else {
+					items = append(items, json.RawMessage("null"))
+				}
+			}
+		}
+		out, _ := json.Marshal(items)
+		return out, true
+	}
+	switch typ {
+	case "time", "interval", "double":
+		if num, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsNaN(num) && !math.IsInf(num, 0) {
+			return []byte(strconv.FormatFloat(num, 'f', -1, 64)), true
+		}
+	case "count", "int", "port":
+		if num, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return []byte(strconv.FormatInt(num, 10)), true
+		}
+		// count is unsigned 64 bit
+		if num, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			return []byte(strconv.FormatUint(num, 10)), true
+		}
+	case "bool":
+		switch raw {
+		case "T":
+			return []byte("true"), true
+		case "F":
+			return []byte("false"), true
+		}
+	}
+	if raw == z.empty {
+		raw = ""
+	}
+	out, _ := json.Marshal(unescapeZeek(raw))
+	return out, true
+}
+
+// unescapeZeek decodes \xHH sequences that zeek uses for separators and non-printable bytes
+func unescapeZeek(raw string) string {
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+	var buf strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+3 < len(raw) && raw[i+1] == 'x' {
+			if b, err := strconv.ParseUint(raw[i+2:i+4], 16, 8); err == nil {
+				buf.WriteByte(byte(b))
+				i += 3
+				continue
+			}
+		}
+		if raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == '\\' {
+			buf.WriteByte('\\')
+			i++
+			continue
+		}
+		buf.WriteByte(raw[i])
+	}
+	return buf.String()
+}
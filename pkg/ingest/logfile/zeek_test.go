@@ -0,0 +1,69 @@
+package logfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const zeekConnTSV = "#separator \\x09\n" +
+	"#set_separator\t,\n" +
+	"#empty_field\t(empty)\n" +
+	"#unset_field\t-\n" +
+	"#path\tconn\n" +
+	"#open\t2020-04-14-10-00-00\n" +
+	"#fields\tts\tuid\tid.orig_h\tid.orig_p\tid.resp_h\tid.resp_p\tproto\tservice\tduration\tlocal_orig\ttunnel_parents\thistory\n" +
+	"#types\ttime\tstring\taddr\tport\taddr\tport\tenum\tstring\tinterval\tbool\tset[string]\tstring\n" +
+	"1586858400.123456\tCAbc1\t10.0.0.5\t51000\t192.0.2.10\t443\ttcp\t-\t0.5\tT\t(empty)\tShA\\x09d\n" +
+	"#close\t2020-04-14-11-00-00\n"
+
+func TestZeekTSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zeek")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "conn.log")
+	if err := ioutil.WriteFile(path, []byte(zeekConnTSV), 0600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	var rows []map[string]interface{}
+	for scanner.Scan() {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &obj); err != nil {
+			t.Fatalf("%s: %s", err, scanner.Text())
+		}
+		rows = append(rows, obj)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	row := rows[0]
+	for key, want := range map[string]interface{}{
+		"_path":      "conn",
+		"ts":         1586858400.123456,
+		"id.orig_p":  float64(51000),
+		"duration":   0.5,
+		"local_orig": true,
+		"history":    "ShA\td",
+	} {
+		if row[key] != want {
+			t.Fatalf("%s: got %v, want %v", key, row[key], want)
+		}
+	}
+	if _, ok := row["service"]; ok {
+		t.Fatal("unset field should be left out")
+	}
+	if parents, ok := row["tunnel_parents"].([]interface{}); !ok || len(parents) != 0 {
+		t.Fatalf("empty set should be empty list, got %v", row["tunnel_parents"])
+	}
+}
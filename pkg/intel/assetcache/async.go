@@ -0,0 +1,100 @@
+package assetcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+// pendingTTL is lifetime of placeholder entry while async lookup is in flight
+// short enough for local caches to pick up the answer soon after it arrives
+const pendingTTL = time.Second
+
+// storeFunc receives results from background lookup, data is nil for a miss
+type storeFunc func(key string, data *meta.Asset, p ProviderConfig)
+
+// filler resolves keys for a slow provider in background, so workers are never blocked by remote calls
+// concurrent requests for same key are coalesced and queued keys are sent as batches
+type filler struct {
+	ProviderConfig
+	queue   chan string
+	pending *sync.Map
+}
+
+func newFiller(p ProviderConfig) *filler {
+	if p.BatchSize < 1 {
+		p.BatchSize = 32
+	}
+	if p.BatchWait <= 0 {
+		p.BatchWait = 50 * time.Millisecond
+	}
+	return &filler{
+		ProviderConfig: p,
+		queue:          make(chan string, p.BatchSize*16),
+		pending:        &sync.Map{},
+	}
+}
+
+// enqueue schedules a textual key for lookup and never blocks
+// returns false if key could not be queued, in which case caller should treat it as a miss
+func (f *filler) enqueue(key string) bool {
+	if b, ok := f.Provider.(Breaker); ok && b.Unavailable() {
+		return false
+	}
+	if _, loaded := f.pending.LoadOrStore(key, true); loaded {
+		return true
+	}
+	select {
+	case f.queue <- key:
+		return true
+	default:
+		f.pending.Delete(key)
+		return false
+	}
+}
+
+func (f *filler) run(ctx context.Context, store storeFunc, errs *utils.ErrChan) {
+	batch := make([]string, 0, f.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		found, err := f.GetBatch(batch)
+		if err != nil {
+			if b, ok := f.Provider.(Breaker); !ok || !b.Unavailable() {
+				errs.Send(err)
+			}
+		}
+		for _, key := range batch {
+			store(key, found[key], f.ProviderConfig)
+			f.pending.Delete(key)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-f.queue:
+			batch = append(batch, key)
+			timer := time.NewTimer(f.BatchWait)
+		collect:
+			for len(batch) < f.BatchSize {
+				select {
+				case key := <-f.queue:
+					batch = append(batch, key)
+				case <-timer.C:
+					break collect
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				}
+			}
+			timer.Stop()
+			flush()
+		}
+	}
+}
@@ -3,11 +3,16 @@ package assetcache
 import (
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
-	"github.com/ccdcoe/go-peek/pkg/intel/wise"
+	"github.com/ccdcoe/go-peek/pkg/intel/inventory"
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
 	"github.com/ccdcoe/go-peek/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -21,15 +26,19 @@ type persist struct {
 // Global is a caching container that is meant to be thread safe
 // should ask from external sources if entry is missing
 type GlobalCache struct {
-	assets *sync.Map
+	assets   *sync.Map
+	networks *NetTable
 
 	persist
 
 	prune
-	wise    *wise.Handle
-	ctx     context.Context
-	stopper context.CancelFunc
-	wg      *sync.WaitGroup
+	providers []ProviderConfig
+	// fillers are background resolvers for async providers, indexed same as providers and nil for sync ones
+	fillers    []*filler
+	defaultTTL time.Duration
+	ctx        context.Context
+	stopper    context.CancelFunc
+	wg         *sync.WaitGroup
 
 	Errs *utils.ErrChan
 }
@@ -51,22 +60,75 @@ func NewGlobalCache(c *Config) (*GlobalCache, error) {
 			interval: 30 * time.Second,
 			period:   120 * time.Second,
 		},
-		ctx:     ctx,
-		stopper: cancel,
-		wg:      &sync.WaitGroup{},
-		Errs:    utils.NewErrChan(100, "Global asset cache errors"),
+		providers:  c.Providers,
+		fillers:    make([]*filler, len(c.Providers)),
+		defaultTTL: c.DefaultTTL,
+		ctx:        ctx,
+		stopper:    cancel,
+		wg:         &sync.WaitGroup{},
+		Errs:       utils.NewErrChan(100, "Global asset cache errors"),
 		persist: persist{
-			dump: 5 * time.Second,
+			dump:   5 * time.Second,
+			assets: c.DumpJSONAssets,
 		},
 	}
+	if c.LoadNetworks != "" {
+		networks, err := LoadNetworks(c.LoadNetworks)
+		if err != nil {
+			return gc, err
+		}
+		gc.networks = NewNetTable(networks)
+		log.WithField("path", c.LoadNetworks).Infof(
+			"loaded %d network ranges from %d segments", gc.networks.Len(), len(networks))
+	}
+	if gc.persist.assets != "" {
+		if err := os.MkdirAll(filepath.Dir(gc.persist.assets), 0750); err != nil {
+			return gc, err
+		}
+	}
+	// warm start from previous dump, so enrichment works before external sources respond
+	if gc.persist.assets != "" && !utils.FileNotExists(gc.persist.assets) {
+		assets, err := inventory.LoadFile(gc.persist.assets, inventory.FormatJSON)
+		if err != nil {
+			log.WithField("path", gc.persist.assets).Warn(err)
+		} else {
+			log.WithField("path", gc.persist.assets).Infof("loaded %d assets from dump", gc.Load(assets, gc.defaultTTL))
+		}
+	}
+	for i, p := range gc.providers {
+		log.WithFields(log.Fields{
+			"provider":     p.Name(),
+			"ttl":          p.TTL.String(),
+			"negative_ttl": p.NegativeTTL.String(),
+			"async":        p.Async,
+		}).Debug("asset provider configured")
+		if p.Async {
+			f := newFiller(p)
+			gc.fillers[i] = f
+			gc.wg.Add(1)
+			go func() {
+				defer gc.wg.Done()
+				f.run(gc.ctx, gc.fill, gc.Errs)
+			}()
+		}
+		if r, ok := p.Provider.(Runner); ok {
+			gc.wg.Add(1)
+			go func(r Runner) {
+				defer gc.wg.Done()
+				r.Run(gc.ctx, gc.Errs)
+			}(r)
+		}
+	}
+	gc.wg.Add(1)
 	go func() {
 		log.Tracef("spawning global asset cache housekeeper thread")
-		gc.wg.Add(1)
 		defer gc.wg.Done()
+		tick := time.NewTicker(gc.prune.interval)
+		dump := time.NewTicker(gc.persist.dump)
+		defer tick.Stop()
+		defer dump.Stop()
 	loop:
 		for {
-			tick := time.NewTicker(gc.prune.interval)
-			dump := time.NewTicker(gc.persist.dump)
 			select {
 			case <-gc.ctx.Done():
 				break loop
@@ -78,19 +140,11 @@ func NewGlobalCache(c *Config) (*GlobalCache, error) {
 				now := time.Now()
 				var count, total int
 				gc.assets.Range(func(k, v interface{}) bool {
-					switch a := v.(type) {
-					// TODO - interface
-					case Asset:
-						if now.Sub(a.updated) > gc.prune.period && !a.IsAsset {
+					if a, ok := v.(*Asset); ok {
+						if a.Expired(now) || (a.expires.IsZero() && !a.IsAsset && now.Sub(a.updated) > gc.prune.period) {
 							gc.assets.Delete(k)
 							count++
 						}
-					case *Asset:
-						if now.Sub(a.updated) > gc.prune.period && !a.IsAsset {
-							gc.assets.Delete(k)
-							count++
-						}
-					default:
 					}
 					total++
 					return true
@@ -102,53 +156,50 @@ func NewGlobalCache(c *Config) (*GlobalCache, error) {
 				if gc.persist.assets == "" {
 					continue loop
 				}
-				log.Tracef("dumping assets to %s", gc.persist.assets)
-				stuff := make([]Asset, 0)
-				gc.assets.Range(func(k, v interface{}) bool {
-					switch a := v.(type) {
-					case Asset:
-						if a.IsAsset {
-							stuff = append(stuff, a)
-						}
-					case *Asset:
-						if a.IsAsset {
-							stuff = append(stuff, *a)
-						}
-					}
-					return true
-				})
-				if len(stuff) == 0 {
-					log.Trace("No stuff to dump, continuing")
-					continue loop
-				}
-				f, err := os.Create(gc.persist.assets)
-				if err != nil {
+				if err := gc.dump(); err != nil {
 					gc.Errs.Send(err)
-					continue loop
 				}
-				for _, a := range stuff {
-					j, err := a.JSON()
-					if err != nil {
-						panic(err)
-					}
-					if err == nil {
-						fmt.Fprintf(f, "%s\n", string(j))
-					}
-				}
-				f.Close()
 			}
 		}
 		log.Tracef("global asset cache housekeeper exited correctly")
 	}()
-	if c.Wise != nil {
-		handle, err := wise.NewHandle(c.Wise)
-		gc.wise = handle
+	return gc, nil
+}
+
+func (g *GlobalCache) dump() error {
+	log.Tracef("dumping assets to %s", g.persist.assets)
+	stuff := make([]Asset, 0)
+	// same item is stored under both address and host name keys
+	seen := make(map[*meta.Asset]bool)
+	g.assets.Range(func(k, v interface{}) bool {
+		if a, ok := v.(*Asset); ok && a.IsAsset && a.Data != nil && !seen[a.Data] {
+			stuff = append(stuff, *a)
+			seen[a.Data] = true
+		}
+		return true
+	})
+	if len(stuff) == 0 {
+		log.Trace("No stuff to dump, continuing")
+		return nil
+	}
+	// write to temp file first, so crash during dump would not destroy warm start data
+	tmp := g.persist.assets + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for _, a := range stuff {
+		j, err := a.JSON()
 		if err != nil {
-			return gc, err
+			g.Errs.Send(err)
+			continue
 		}
+		fmt.Fprintf(f, "%s\n", string(j))
 	}
-	return gc, nil
+	f.Close()
+	return os.Rename(tmp, g.persist.assets)
 }
+
 func (g *GlobalCache) Close() error {
 	if g.stopper != nil {
 		g.stopper()
@@ -156,39 +207,256 @@ func (g *GlobalCache) Close() error {
 	if g.wg != nil {
 		g.wg.Wait()
 	}
+	for _, p := range g.providers {
+		if c, ok := p.Provider.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.WithField("provider", p.Name()).Error(err)
+			}
+		}
+	}
 	return nil
 }
 
-func (g GlobalCache) GetString(key string) (*Asset, bool) {
+// Load stores known assets in cache, keyed by both canonical address and host name
+// loaded items are marked as real assets, zero ttl means they are never pruned
+func (g *GlobalCache) Load(assets []meta.Asset, ttl time.Duration) int {
 	if g.assets == nil {
+		return 0
+	}
+	var count int
+	for i := range assets {
+		data := assets[i]
+		data.IsAsset = true
+		item := g.newEntry(&data, ttl)
+		item.Source = "dump"
+		if k, ok := newIPKey(data.IP); ok {
+			g.assets.Store(k, item)
+			count++
+		}
+		if data.Host != "" {
+			g.assets.Store(data.Host, item)
+		}
+	}
+	return count
+}
+
+func (g GlobalCache) newEntry(data *meta.Asset, ttl time.Duration) *Asset {
+	now := time.Now()
+	a := &Asset{
+		Data:    data,
+		updated: now,
+		IsAsset: data != nil && data.IsAsset,
+	}
+	if ttl > 0 {
+		a.expires = now.Add(ttl)
+	}
+	return a
+}
+
+// negativeTTL is shortest negative TTL in chain, so quickest refreshing provider is asked again in time
+func (g GlobalCache) negativeTTL() time.Duration {
+	var ttl time.Duration
+	for _, p := range g.providers {
+		if p.NegativeTTL > 0 && (ttl == 0 || p.NegativeTTL < ttl) {
+			ttl = p.NegativeTTL
+		}
+	}
+	if ttl == 0 {
+		ttl = g.prune.period
+	}
+	return ttl
+}
+
+// GetNetwork returns network segment of address, regardless if address belongs to known asset
+func (g GlobalCache) GetNetwork(ip net.IP) (*meta.NetSegment, bool) {
+	if g.networks == nil || ip == nil {
 		return nil, false
 	}
+	return g.networks.Lookup(ip)
+}
+
+func (g GlobalCache) cached(key interface{}) (*Asset, bool) {
 	if val, ok := g.assets.Load(key); ok {
-		switch v := val.(type) {
-		case Asset:
-			return &v, true
-		case *Asset:
-			return v, true
-		}
-	}
-
-	asset := &Asset{updated: time.Now()}
-	if g.wise == nil {
-		return asset, false
-	}
-	if a, ok, err := wise.GetAsset(
-		*g.wise,
-		key,
-		FieldPrefix+".original",
-		FieldPrefix+".pretty",
-		FieldPrefix+".os",
-		FieldPrefix+".vm",
-	); err != nil {
-		g.Errs.Send(err)
-	} else if ok {
-		asset.Data = a
-		asset.IsAsset = true
-	}
-	g.assets.Store(key, asset)
-	return asset, false
+		if a, ok := val.(*Asset); ok && !a.Expired(time.Now()) {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// GetIP returns cached entry for canonical form of address or walks the provider chain on cache miss
+// second return value reports if lookup produced an entry, misses are negatively cached and returned with IsAsset false
+// async providers are never waited for, their answer is visible in later lookups
+func (g GlobalCache) GetIP(ip net.IP) (*Asset, bool) {
+	k, ok := newIPKey(ip)
+	if !ok {
+		return nil, false
+	}
+	return g.get(k, ip.String(), func(p Provider) (*meta.Asset, bool, error) { return p.GetIP(ip) })
+}
+
+// GetString returns cached entry for host name or textual address, see GetIP
+func (g GlobalCache) GetString(key string) (*Asset, bool) {
+	if ip := net.ParseIP(key); ip != nil {
+		return g.GetIP(ip)
+	}
+	return g.get(key, key, func(p Provider) (*meta.Asset, bool, error) { return p.GetHost(key) })
+}
+
+func (g GlobalCache) get(
+	key interface{},
+	text string,
+	fn func(Provider) (*meta.Asset, bool, error),
+) (*Asset, bool) {
+	if g.assets == nil {
+		return nil, false
+	}
+	if a, ok := g.cached(key); ok {
+		return a, true
+	}
+	var (
+		async []*filler
+		hit   *Asset
+	)
+	for i, p := range g.providers {
+		if f := g.fillers[i]; f != nil {
+			async = append(async, f)
+			continue
+		}
+		data, ok, err := fn(p.Provider)
+		if err != nil {
+			g.Errs.Send(err)
+			continue
+		}
+		if ok && data != nil {
+			hit = g.newEntry(data, p.TTL)
+			hit.Source = p.Name()
+			break
+		}
+	}
+	if hit != nil {
+		g.assets.Store(key, hit)
+		g.enqueue(text, async...)
+		return hit, true
+	}
+	// keep serving expired answer while refresh is in flight, rather than flapping to non-asset
+	stale, _ := g.assets.Load(key)
+	if len(async) > 0 {
+		if a, ok := stale.(*Asset); !ok || a.Data == nil {
+			// placeholder must be in place before queueing, or it could overwrite a quick answer
+			p := g.newEntry(nil, pendingTTL)
+			p.pending = true
+			g.assets.Store(key, p)
+		}
+		if g.enqueue(text, async...) {
+			if a, ok := stale.(*Asset); ok && a.Data != nil {
+				return a, true
+			}
+			a, _ := g.assets.Load(key)
+			return a.(*Asset), true
+		}
+	}
+	a := g.newEntry(nil, g.negativeTTL())
+	g.assets.Store(key, a)
+	return a, true
+}
+
+// enqueue schedules key for background lookup and reports if any async provider accepted it
+func (g GlobalCache) enqueue(key string, fillers ...*filler) bool {
+	var queued bool
+	for _, f := range fillers {
+		if f.enqueue(key) {
+			queued = true
+		}
+	}
+	return queued
+}
+
+// fill stores result of background lookup
+// a miss never overrides a live answer from another provider
+func (g GlobalCache) fill(key string, data *meta.Asset, p ProviderConfig) {
+	k := cacheKey(key)
+	if data != nil {
+		a := g.newEntry(data, p.TTL)
+		a.Source = p.Name()
+		g.assets.Store(k, a)
+		return
+	}
+	if val, ok := g.assets.Load(k); ok {
+		if a, ok := val.(*Asset); ok && !a.pending && a.Data != nil && !a.Expired(time.Now()) {
+			return
+		}
+	}
+	ttl := p.NegativeTTL
+	if ttl == 0 {
+		ttl = g.negativeTTL()
+	}
+	g.assets.Store(k, g.newEntry(nil, ttl))
+}
+
+// GetBatch resolves multiple keys, asking each provider only for keys still unresolved
+// textual addresses are normalized before lookup, but result is keyed by original input
+func (g GlobalCache) GetBatch(keys []string) map[string]*Asset {
+	out := make(map[string]*Asset)
+	if g.assets == nil {
+		return out
+	}
+	// canonical textual form to original keys, as same address may be requested in multiple notations
+	originals := make(map[string][]string)
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if a, ok := g.cached(cacheKey(key)); ok {
+			out[key] = a
+			continue
+		}
+		norm := fields.NormalizeIP(key)
+		if _, ok := originals[norm]; !ok {
+			missing = append(missing, norm)
+		}
+		originals[norm] = append(originals[norm], key)
+	}
+	store := func(key string, a *Asset) {
+		g.assets.Store(cacheKey(key), a)
+		for _, orig := range originals[key] {
+			out[orig] = a
+		}
+	}
+	async := make([]*filler, 0)
+	for i, p := range g.providers {
+		if len(missing) == 0 {
+			break
+		}
+		if f := g.fillers[i]; f != nil {
+			async = append(async, f)
+			continue
+		}
+		found, err := p.GetBatch(missing)
+		if err != nil {
+			g.Errs.Send(err)
+		}
+		remaining := missing[:0]
+		for _, key := range missing {
+			if data, ok := found[key]; ok && data != nil {
+				a := g.newEntry(data, p.TTL)
+				a.Source = p.Name()
+				store(key, a)
+			} else {
+				remaining = append(remaining, key)
+			}
+		}
+		missing = remaining
+	}
+	ttl := g.negativeTTL()
+	for _, key := range missing {
+		if len(async) > 0 {
+			p := g.newEntry(nil, pendingTTL)
+			p.pending = true
+			store(key, p)
+			if g.enqueue(key, async...) {
+				continue
+			}
+		}
+		store(key, g.newEntry(nil, ttl))
+	}
+	return out
 }
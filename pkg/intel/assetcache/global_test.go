@@ -0,0 +1,155 @@
+package assetcache
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// memProvider serves assets from memory, bindings can be added while cache is running
+type memProvider struct {
+	*staticIndex
+}
+
+func newMemProvider() *memProvider { return &memProvider{staticIndex: newStaticIndex()} }
+
+func (m memProvider) Name() string { return "memory" }
+
+func (m *memProvider) Learn(assets ...meta.Asset) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	indexAssets(m.index, assets)
+}
+
+func TestProviderChain(t *testing.T) {
+	first, second := newMemProvider(), newMemProvider()
+	first.Learn(meta.Asset{Host: "dc", IP: net.ParseIP("10.0.0.1"), Indicators: meta.Indicators{IsAsset: true}})
+	second.Learn(
+		meta.Asset{Host: "dc-shadow", IP: net.ParseIP("10.0.0.1"), Indicators: meta.Indicators{IsAsset: true}},
+		meta.Asset{Host: "web", IP: net.ParseIP("10.0.0.2"), Indicators: meta.Indicators{IsAsset: true}},
+	)
+	gc, err := NewGlobalCache(&Config{Providers: []ProviderConfig{
+		{Provider: first, TTL: time.Minute, NegativeTTL: time.Minute},
+		{Provider: second, TTL: time.Minute, NegativeTTL: 10 * time.Millisecond},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gc.Close()
+
+	if a, ok := gc.GetString("10.0.0.1"); !ok || !a.IsAsset || a.Data.Host != "dc" {
+		t.Fatalf("first provider in chain should win, got %+v", a)
+	}
+	if a, ok := gc.GetString("web"); !ok || !a.IsAsset || a.Data.IP.String() != "10.0.0.2" {
+		t.Fatalf("host name lookup should fall through to second provider, got %+v", a)
+	}
+	if a, ok := gc.GetString("10.0.0.3"); !ok || a.IsAsset {
+		t.Fatalf("unknown address should be negatively cached, got %+v", a)
+	}
+	second.Learn(meta.Asset{Host: "new", IP: net.ParseIP("10.0.0.3"), Indicators: meta.Indicators{IsAsset: true}})
+	if a, _ := gc.GetString("10.0.0.3"); a.IsAsset {
+		t.Fatal("negative entry should be served until shortest negative ttl passes")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if a, _ := gc.GetString("10.0.0.3"); !a.IsAsset || a.Source != "memory" {
+		t.Fatalf("expired negative entry should be looked up again, got %+v", a)
+	}
+
+	batch := gc.GetBatch([]string{"10.0.0.1", "10.0.0.2", "10.0.0.9"})
+	if len(batch) != 3 || !batch["10.0.0.2"].IsAsset || batch["10.0.0.9"].IsAsset {
+		t.Fatalf("unexpected batch result %+v", batch)
+	}
+}
+
+func TestCanonicalKeys(t *testing.T) {
+	_, segment, _ := net.ParseCIDR("10.0.5.0/24")
+	_, wide, _ := net.ParseCIDR("10.0.0.0/16")
+	p := newMemProvider()
+	p.Learn(
+		meta.Asset{Host: "dc", IP: net.ParseIP("10.0.0.1").To4(), Indicators: meta.Indicators{IsAsset: true}},
+		meta.Asset{Host: "v6", IP: net.ParseIP("2001:db8::1"), Indicators: meta.Indicators{IsAsset: true}},
+		meta.Asset{Host: "workstations", Net: &fields.StringNet{IPNet: *segment}, Indicators: meta.Indicators{IsAsset: true}},
+		meta.Asset{Host: "campus", Net: &fields.StringNet{IPNet: *wide}, Indicators: meta.Indicators{IsAsset: true}},
+	)
+	gc, err := NewGlobalCache(&Config{Providers: []ProviderConfig{{Provider: p, NegativeTTL: time.Minute}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gc.Close()
+	lc := NewLocalCache(gc, 0)
+	defer lc.Close()
+
+	for _, addr := range []string{"10.0.0.1", "::ffff:10.0.0.1", "::ffff:a00:1"} {
+		if a, ok := lc.GetString(addr); !ok || !a.IsAsset || a.Data.Host != "dc" {
+			t.Fatalf("%s should resolve to dc, got %+v", addr, a)
+		}
+	}
+	if a, ok := lc.GetIP(net.ParseIP("2001:0db8:0000:0000:0000:0000:0000:0001")); !ok || a.Data.Host != "v6" {
+		t.Fatalf("full form IPv6 should resolve to v6, got %+v", a)
+	}
+	if a, ok := lc.GetIP(net.ParseIP("10.0.5.20")); !ok || a.Data.Host != "workstations" || a.Data.IP.String() != "10.0.5.20" {
+		t.Fatalf("address should fall back to longest matching network, got %+v", a)
+	}
+	if a, ok := lc.GetIP(net.ParseIP("::ffff:10.0.6.1")); !ok || a.Data.Host != "campus" {
+		t.Fatalf("mapped address should fall back to wider network, got %+v", a)
+	}
+	if a, ok := lc.GetIP(net.ParseIP("10.1.0.1")); !ok || a.IsAsset {
+		t.Fatalf("address outside known networks should not be an asset, got %+v", a)
+	}
+}
+
+// slowProvider simulates remote lookup and counts queried keys
+type slowProvider struct {
+	*memProvider
+	delay   time.Duration
+	queries *int32
+}
+
+func (s slowProvider) GetIP(ip net.IP) (*meta.Asset, bool, error) {
+	time.Sleep(s.delay)
+	atomic.AddInt32(s.queries, 1)
+	return s.memProvider.GetIP(ip)
+}
+
+func (s slowProvider) GetBatch(keys []string) (map[string]*meta.Asset, error) {
+	time.Sleep(s.delay)
+	atomic.AddInt32(s.queries, int32(len(keys)))
+	return s.memProvider.GetBatch(keys)
+}
+
+func TestAsyncProvider(t *testing.T) {
+	remote := slowProvider{memProvider: newMemProvider(), delay: 200 * time.Millisecond, queries: new(int32)}
+	remote.Learn(meta.Asset{Host: "remote", IP: net.ParseIP("10.0.0.1"), Indicators: meta.Indicators{IsAsset: true}})
+	gc, err := NewGlobalCache(&Config{Providers: []ProviderConfig{
+		{Provider: remote, Async: true, NegativeTTL: time.Minute, BatchWait: 10 * time.Millisecond},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gc.Close()
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if a, ok := gc.GetString("10.0.0.1"); !ok || a.IsAsset {
+			t.Fatalf("first lookups should return pending miss, got %+v", a)
+		}
+		gc.GetString("10.0.0.2")
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("lookups should not wait for async provider")
+	}
+	time.Sleep(pendingTTL + 300*time.Millisecond)
+	if a, ok := gc.GetString("10.0.0.1"); !ok || !a.IsAsset || a.Data.Host != "remote" {
+		t.Fatalf("background answer should be visible, got %+v", a)
+	}
+	if a, _ := gc.GetString("10.0.0.2"); a.IsAsset || a.pending {
+		t.Fatalf("background miss should be negatively cached, got %+v", a)
+	}
+	if q := atomic.LoadInt32(remote.queries); q != 2 {
+		t.Fatalf("concurrent lookups should be coalesced into 2 queries, got %d", q)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/ccdcoe/go-peek/pkg/intel/wise"
 	"github.com/ccdcoe/go-peek/pkg/models/meta"
 )
 
@@ -14,7 +13,12 @@ var FieldPrefix = "target"
 type Asset struct {
 	Data    *meta.Asset
 	updated time.Time
+	expires time.Time
 	IsAsset bool
+	// Source is the name of provider that resolved this entry
+	Source string `json:",omitempty"`
+	// pending marks a placeholder while background lookup is in flight
+	pending bool
 }
 
 func (a Asset) Update() *Asset {
@@ -22,13 +26,22 @@ func (a Asset) Update() *Asset {
 	return &a
 }
 
+// Expired checks if entry TTL has passed, entries without TTL never expire
+func (a Asset) Expired(now time.Time) bool {
+	return !a.expires.IsZero() && now.After(a.expires)
+}
+
 func (a Asset) JSON() ([]byte, error) { return json.Marshal(a) }
 
 type Config struct {
-	Wise           *wise.Config
+	// Providers is an ordered lookup chain, first positive answer wins
+	Providers []ProviderConfig
+	// DefaultTTL is applied to warm start entries loaded from asset dump
+	DefaultTTL     time.Duration
 	Prune          bool
 	DumpJSONAssets string
-	LoadJSONnets   string
+	// LoadNetworks is exercise network table, pandas JSON export or CSV
+	LoadNetworks string
 }
 
 func NewDefaultConfig() *Config {
@@ -39,6 +52,9 @@ func (c *Config) Validate() error {
 	if c == nil {
 		c = NewDefaultConfig()
 	}
+	if c.DefaultTTL == 0 {
+		c.DefaultTTL = 10 * time.Minute
+	}
 	return nil
 }
 
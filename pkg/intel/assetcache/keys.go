@@ -0,0 +1,77 @@
+package assetcache
+
+import (
+	"net"
+	"sort"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// ipKey is canonical 16-byte form of an address
+// 10.0.0.1, ::ffff:10.0.0.1 and differently written IPv6 notations all map to same key
+type ipKey [16]byte
+
+func newIPKey(ip net.IP) (ipKey, bool) {
+	var k ipKey
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return k, false
+	}
+	copy(k[:], ip16)
+	return k, true
+}
+
+func (k ipKey) IP() net.IP {
+	ip := net.IP(k[:])
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+// cacheKey converts textual key into ipKey if it is an address, host names are used as-is
+func cacheKey(key string) interface{} {
+	if ip := net.ParseIP(key); ip != nil {
+		if k, ok := newIPKey(ip); ok {
+			return k
+		}
+	}
+	return key
+}
+
+type netEntry struct {
+	net   *net.IPNet
+	ones  int
+	asset *meta.Asset
+}
+
+// netTable is a list of network ranges for CIDR fallback lookups
+// kept sorted by prefix length, so first match is longest match
+type netTable []netEntry
+
+func (t netTable) add(a *meta.Asset) netTable {
+	if a.Net == nil || a.Net.IP == nil {
+		return t
+	}
+	ones, bits := a.Net.Mask.Size()
+	if bits == 0 {
+		return t
+	}
+	// IPv4 and IPv4-mapped networks are matched against same addresses, so mask length is compared in 128-bit space
+	if bits == 32 {
+		ones += 96
+	}
+	network := a.Net.IPNet
+	t = append(t, netEntry{net: &network, ones: ones, asset: a})
+	sort.SliceStable(t, func(i, j int) bool { return t[i].ones > t[j].ones })
+	return t
+}
+
+func (t netTable) match(ip net.IP) (*meta.Asset, bool) {
+	for _, e := range t {
+		if e.net.Contains(ip) {
+			return e.asset, true
+		}
+	}
+	return nil, false
+}
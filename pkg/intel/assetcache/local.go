@@ -3,9 +3,11 @@ package assetcache
 import (
 	"context"
 	"math/rand"
+	"net"
 	"sync"
 	"time"
 
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -13,7 +15,8 @@ import (
 // Worker should ask from Global if entry is missing from map
 type LocalCache struct {
 	*sync.Mutex
-	assets map[string]Asset
+	// keyed by ipKey for addresses and string for host names
+	assets map[interface{}]Asset
 	parent *GlobalCache
 	prune
 	ctx     context.Context
@@ -28,7 +31,7 @@ func NewLocalCache(parent *GlobalCache, id int) *LocalCache {
 		ctx:     ctx,
 		stopper: cancel,
 		Mutex:   &sync.Mutex{},
-		assets:  make(map[string]Asset),
+		assets:  make(map[interface{}]Asset),
 		parent:  parent,
 		prune: prune{
 			enabled:  true,
@@ -49,7 +52,7 @@ func NewLocalCache(parent *GlobalCache, id int) *LocalCache {
 					lc.Lock()
 					count := 0
 					for k, v := range lc.assets {
-						if now.Sub(v.updated) > lc.prune.period && !v.IsAsset {
+						if v.Expired(now) || (now.Sub(v.updated) > lc.prune.period && !v.IsAsset) {
 							count++
 							delete(lc.assets, k)
 						}
@@ -67,45 +70,49 @@ func NewLocalCache(parent *GlobalCache, id int) *LocalCache {
 	return lc
 }
 
+// GetString looks up host name or textual address
 func (l LocalCache) GetString(key string) (*Asset, bool) {
-	if l.assets == nil {
-		return nil, false
-	}
-	l.Lock()
-	defer l.Unlock()
-	if val, ok := l.assets[key]; ok {
-		return &val, true
+	if ip := net.ParseIP(key); ip != nil {
+		return l.GetIP(ip)
 	}
-	if l.parent != nil {
+	return l.get(key, func() (*Asset, bool) { return l.parent.GetString(key) })
+}
 
-		if val, ok := l.parent.GetString(key); ok {
-			l.assets[key] = *val
-			return val, true
-		} else if !ok && val != nil {
-			l.assets[key] = *val
-		}
+// GetIP looks up address by its canonical form, so IPv4-mapped and differently written IPv6 notations share an entry
+func (l LocalCache) GetIP(ip net.IP) (*Asset, bool) {
+	k, ok := newIPKey(ip)
+	if !ok {
+		return nil, false
+	}
+	return l.get(k, func() (*Asset, bool) { return l.parent.GetIP(ip) })
+}
 
+// GetNetwork returns network segment of address, table is immutable so no local copy is needed
+func (l LocalCache) GetNetwork(ip net.IP) (*meta.NetSegment, bool) {
+	if l.parent == nil {
+		return nil, false
 	}
-	return nil, false
+	return l.parent.GetNetwork(ip)
 }
-func (l LocalCache) GetIP(key string) (*Asset, bool) {
+
+// get does not hold the lock while asking parent, so a slow lookup in one worker would not block its pruner
+func (l LocalCache) get(key interface{}, fn func() (*Asset, bool)) (*Asset, bool) {
 	if l.assets == nil {
 		return nil, false
 	}
 	l.Lock()
-	defer l.Unlock()
-	if val, ok := l.assets[key]; ok {
+	val, ok := l.assets[key]
+	l.Unlock()
+	if ok && !val.Expired(time.Now()) {
 		return &val, true
 	}
 	if l.parent != nil {
-
-		if val, ok := l.parent.GetString(key); ok {
+		if val, ok := fn(); ok && val != nil {
+			l.Lock()
 			l.assets[key] = *val
+			l.Unlock()
 			return val, true
-		} else if !ok && val != nil {
-			l.assets[key] = *val
 		}
-
 	}
 	return nil, false
 }
@@ -0,0 +1,133 @@
+package assetcache
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+// NetTable is a longest prefix match table of network segments
+// table is not safe for concurrent modification, build it fully before doing lookups
+type NetTable struct {
+	tree *utils.PrefixTree
+}
+
+func NewNetTable(networks []*meta.Network) *NetTable {
+	t := &NetTable{tree: utils.NewPrefixTree()}
+	for _, n := range networks {
+		v4, v6 := n.Shorthand()
+		for _, seg := range []*meta.NetSegment{v4, v6} {
+			t.Insert(seg.Net(), seg)
+		}
+	}
+	return t
+}
+
+// Insert adds a segment for network, ranges without address are ignored
+func (t *NetTable) Insert(n net.IPNet, seg *meta.NetSegment) { t.tree.Insert(n, seg) }
+
+// Lookup returns most specific segment containing the address
+func (t NetTable) Lookup(ip net.IP) (*meta.NetSegment, bool) {
+	if t.tree == nil {
+		return nil, false
+	}
+	val, ok := t.tree.Lookup(ip)
+	if !ok {
+		return nil, false
+	}
+	return val.(*meta.NetSegment), true
+}
+
+// Len returns number of distinct ranges in table
+func (t NetTable) Len() int {
+	if t.tree == nil {
+		return 0
+	}
+	return t.tree.Len()
+}
+
+// LoadNetworks reads exercise network table from pandas to_json() export or CSV file
+func LoadNetworks(path string) ([]*meta.Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		return parseNetworksCSV(f)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	var export meta.NetworkPandasExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("unable to parse network table %s: %s", path, err)
+	}
+	return export.Extract(), nil
+}
+
+// parseNetworksCSV expects header with column names as in pandas export, i.e. Name, VLAN/Portgroup, IPv4, IPv6, Team
+func parseNetworksCSV(r io.Reader) ([]*meta.Network, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*meta.Network, 0)
+	for id := 0; ; id++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		n := &meta.Network{ID: id}
+		for i, value := range record {
+			if i >= len(header) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch strings.ToLower(strings.TrimSpace(header[i])) {
+			case "id":
+				fmt.Sscanf(value, "%d", &n.ID)
+			case "name":
+				n.Name = value
+			case "abbreviation":
+				n.Abbreviation = value
+			case "vlan", "vlan/portgroup", "portgroup":
+				n.VLAN = value
+			case "ipv4":
+				if _, network, err := net.ParseCIDR(value); err == nil {
+					n.IPv4 = *network
+				}
+			case "ipv6":
+				if _, network, err := net.ParseCIDR(value); err == nil {
+					n.IPv6 = *network
+				}
+			case "description":
+				n.Desc = value
+			case "whois":
+				n.Whois = value
+			case "team":
+				n.Team = value
+			}
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package assetcache
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+var pandasNetworks = `{
+	"Name": {"0": "Blue team 1 DMZ", "1": "Blue team 1 internal", "2": "Simulated internet"},
+	"Abbreviation": {"0": "BT1-DMZ", "1": "BT1-INT", "2": "INET"},
+	"VLAN/Portgroup": {"0": "101", "1": "102", "2": "1"},
+	"IPv4": {"0": "10.1.1.0/24", "1": "10.1.0.0\\/16", "2": "0.0.0.0/0"},
+	"IPv6": {"0": "2001:db8:1:1::/64", "1": "", "2": null},
+	"Description": {"0": "", "1": "", "2": ""},
+	"WHOIS": {"0": "", "1": "", "2": ""},
+	"Team": {"0": "blue01", "1": "blue01", "2": ""}
+}`
+
+func TestNetTable(t *testing.T) {
+	var export meta.NetworkPandasExport
+	if err := json.Unmarshal([]byte(pandasNetworks), &export); err != nil {
+		t.Fatal(err)
+	}
+	table := NewNetTable(export.Extract())
+	if table.Len() != 4 {
+		t.Fatalf("expected 4 ranges, got %d", table.Len())
+	}
+	for addr, name := range map[string]string{
+		"10.1.1.5":         "Blue team 1 DMZ",
+		"::ffff:10.1.1.5":  "Blue team 1 DMZ",
+		"10.1.2.5":         "Blue team 1 internal",
+		"8.8.8.8":          "Simulated internet",
+		"2001:db8:1:1::10": "Blue team 1 DMZ",
+	} {
+		seg, ok := table.Lookup(net.ParseIP(addr))
+		if !ok || seg.Name != name {
+			t.Fatalf("%s should be in %s, got %+v", addr, name, seg)
+		}
+		if name != "Simulated internet" && (seg.Team != "blue01" || seg.VLAN == "") {
+			t.Fatalf("%s segment is missing team or vlan: %+v", addr, seg)
+		}
+	}
+	if seg, ok := table.Lookup(net.ParseIP("2001:db8:2::1")); ok {
+		t.Fatalf("IPv6 address outside known ranges matched %+v", seg)
+	}
+
+	networks, err := parseNetworksCSV(strings.NewReader(
+		"Name,VLAN/Portgroup,IPv4,IPv6,Team\nDMZ,101,10.1.1.0/24,,blue01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seg, ok := NewNetTable(networks).Lookup(net.ParseIP("10.1.1.1")); !ok || seg.VLAN != "101" {
+		t.Fatalf("CSV network table lookup failed, got %+v", seg)
+	}
+}
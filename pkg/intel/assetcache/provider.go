@@ -0,0 +1,77 @@
+package assetcache
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+// Provider is a source of asset information that GlobalCache consults on cache miss
+// Providers are queried in configured order and first positive answer wins
+type Provider interface {
+	// Name identifies provider in logs and cache entries
+	Name() string
+	// GetIP looks up an asset by address
+	GetIP(net.IP) (*meta.Asset, bool, error)
+	// GetHost looks up an asset by host name
+	GetHost(string) (*meta.Asset, bool, error)
+	// GetBatch looks up multiple keys at once, keys can be textual addresses or host names
+	// Missing keys are simply omitted from result
+	GetBatch([]string) (map[string]*meta.Asset, error)
+}
+
+// Runner is implemented by providers that need a background routine, such as file reloader
+// GlobalCache spawns the routine and cancels context on Close
+type Runner interface {
+	Run(context.Context, *utils.ErrChan)
+}
+
+// Breaker is implemented by remote providers that stop querying after repeated failures
+// async filler skips providers that report being unavailable instead of queueing doomed lookups
+type Breaker interface {
+	Unavailable() bool
+}
+
+// ProviderConfig attaches caching parameters to a provider in lookup chain
+type ProviderConfig struct {
+	Provider
+	// TTL is how long a positive answer is cached, zero means until restart
+	TTL time.Duration
+	// NegativeTTL is how long a miss is remembered before asking the provider again
+	NegativeTTL time.Duration
+	// Async providers are never queried from worker path
+	// keys are queued for background resolution and answer becomes visible on later lookups
+	Async bool
+	// BatchSize is maximum number of keys in single background query
+	BatchSize int
+	// BatchWait is how long background filler waits for more keys before sending a partial batch
+	BatchWait time.Duration
+}
+
+// lookup dispatches a textual key to address or host name lookup
+func lookup(p Provider, key string) (*meta.Asset, bool, error) {
+	if ip := net.ParseIP(key); ip != nil {
+		return p.GetIP(ip)
+	}
+	return p.GetHost(key)
+}
+
+// batchLookup is a fallback for providers without native batch queries
+func batchLookup(p Provider, keys []string) (map[string]*meta.Asset, error) {
+	out := make(map[string]*meta.Asset)
+	var last error
+	for _, key := range keys {
+		a, ok, err := lookup(p, key)
+		if err != nil {
+			last = err
+			continue
+		}
+		if ok && a != nil {
+			out[key] = a
+		}
+	}
+	return out, last
+}
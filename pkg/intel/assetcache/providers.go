@@ -0,0 +1,354 @@
+package assetcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/intel/inventory"
+	"github.com/ccdcoe/go-peek/pkg/intel/wise"
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+// staticIndex is an in-memory asset table keyed by canonical address and host name
+// network entries are used as fallback when address itself is not present
+type staticIndex struct {
+	mu *sync.RWMutex
+	*index
+}
+
+type index struct {
+	ips   map[ipKey]*meta.Asset
+	hosts map[string]*meta.Asset
+	nets  netTable
+}
+
+func newIndex() *index {
+	return &index{
+		ips:   make(map[ipKey]*meta.Asset),
+		hosts: make(map[string]*meta.Asset),
+		nets:  make(netTable, 0),
+	}
+}
+
+func newStaticIndex() *staticIndex {
+	return &staticIndex{
+		mu:    &sync.RWMutex{},
+		index: newIndex(),
+	}
+}
+
+func (i index) getIP(ip net.IP) (*meta.Asset, bool) {
+	if k, ok := newIPKey(ip); ok {
+		if val, ok := i.ips[k]; ok {
+			return val, true
+		}
+	}
+	if val, ok := i.nets.match(ip); ok {
+		// return a copy, so network entry would not be modified with single address
+		a := *val
+		a.IP = fields.CanonicalIP(ip)
+		return &a, true
+	}
+	return nil, false
+}
+
+func (i index) get(key string) (*meta.Asset, bool) {
+	if ip := net.ParseIP(key); ip != nil {
+		return i.getIP(ip)
+	}
+	val, ok := i.hosts[key]
+	return val, ok
+}
+
+func (s *staticIndex) GetIP(ip net.IP) (*meta.Asset, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.getIP(ip)
+	return a, ok, nil
+}
+
+func (s *staticIndex) GetHost(host string) (*meta.Asset, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.hosts[host]
+	return a, ok, nil
+}
+
+func (s *staticIndex) GetBatch(keys []string) (map[string]*meta.Asset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*meta.Asset)
+	for _, key := range keys {
+		if val, ok := s.get(key); ok {
+			out[key] = val
+		}
+	}
+	return out, nil
+}
+
+func indexAssets(idx *index, assets []meta.Asset) {
+	for i := range assets {
+		a := &assets[i]
+		if a.IP != nil {
+			if k, ok := newIPKey(a.IP); ok {
+				idx.ips[k] = a
+			}
+		}
+		if a.Net != nil {
+			idx.nets = idx.nets.add(a)
+		}
+		if a.Host != "" {
+			idx.hosts[a.Host] = a
+		}
+	}
+}
+
+// FileProvider serves assets from local inventory files
+type FileProvider struct {
+	*staticIndex
+	inv   *inventory.Inventory
+	files map[string][]meta.Asset
+}
+
+func NewFileProvider(c *inventory.Config) (*FileProvider, error) {
+	inv, err := inventory.New(c)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileProvider{
+		staticIndex: newStaticIndex(),
+		inv:         inv,
+		files:       make(map[string][]meta.Asset),
+	}
+	if err := inv.Load(f.load); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// load replaces content of a single file and rebuilds the index
+// later files in configuration take precedence for duplicate keys
+func (f *FileProvider) load(path string, assets []meta.Asset) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.files[path] = assets
+	idx := newIndex()
+	for _, pth := range f.inv.Paths() {
+		indexAssets(idx, f.files[pth])
+	}
+	f.index = idx
+	log.WithField("path", path).Infof(
+		"inventory provider now holds %d addresses, %d hosts and %d networks",
+		len(idx.ips), len(idx.hosts), len(idx.nets))
+}
+
+// Name implements Provider
+func (f FileProvider) Name() string { return "inventory" }
+
+// Run implements Runner
+func (f *FileProvider) Run(ctx context.Context, errs *utils.ErrChan) { f.inv.Run(ctx, f.load, errs) }
+
+// WiseProvider does asset lookups from Moloch WISE
+// WISE only supports address lookups for assets
+type WiseProvider struct {
+	handle *wise.Handle
+}
+
+// NewWiseProvider does asset lookups over handle that may be shared with other WISE clients
+// circuit breaker in handle keeps probing WISE until it becomes available
+func NewWiseProvider(h *wise.Handle) *WiseProvider { return &WiseProvider{handle: h} }
+
+// Name implements Provider
+func (w WiseProvider) Name() string { return "wise" }
+
+// GetIP implements Provider
+func (w WiseProvider) GetIP(ip net.IP) (*meta.Asset, bool, error) {
+	return wise.GetAsset(
+		*w.handle,
+		ip.String(),
+		FieldPrefix+".original",
+		FieldPrefix+".pretty",
+		FieldPrefix+".os",
+		FieldPrefix+".vm",
+	)
+}
+
+// GetHost implements Provider
+func (w WiseProvider) GetHost(string) (*meta.Asset, bool, error) { return nil, false, nil }
+
+// GetBatch implements Provider by doing concurrent address queries, host names are skipped
+func (w WiseProvider) GetBatch(keys []string) (map[string]*meta.Asset, error) {
+	addrs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if net.ParseIP(key) != nil {
+			addrs = append(addrs, key)
+		}
+	}
+	return wise.GetAssets(
+		*w.handle,
+		addrs,
+		FieldPrefix+".original",
+		FieldPrefix+".pretty",
+		FieldPrefix+".os",
+		FieldPrefix+".vm",
+	)
+}
+
+// Unavailable implements Breaker
+func (w WiseProvider) Unavailable() bool { return w.handle.Open() }
+
+type RedisConfig struct {
+	Host string
+	Port int
+	DB   int
+	// Prefix is prepended to lookup key, e.g. asset:10.0.0.1
+	Prefix string
+	// Threshold is number of consecutive failures that opens circuit breaker
+	Threshold int
+	// Cooldown is how long circuit stays open before redis is probed again
+	Cooldown time.Duration
+}
+
+func (c *RedisConfig) Validate() error {
+	if c == nil {
+		return fmt.Errorf("missing redis asset provider config")
+	}
+	if c.Host == "" {
+		c.Host = "localhost"
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		c.Port = 6379
+	}
+	if c.Threshold < 1 {
+		c.Threshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	return nil
+}
+
+// ErrRedisCircuitOpen is returned without contacting redis while too many recent queries have failed
+var ErrRedisCircuitOpen = errors.New("redis asset circuit breaker open")
+
+// RedisProvider looks up assets from redis hashes
+// hash fields are host, alias, os and vm, optionally prefixed with FieldPrefix as in WISE tagger files
+// provider is meant to be used as async, so remote round trips never happen on worker path
+type RedisProvider struct {
+	handle  *redis.Client
+	prefix  string
+	breaker *utils.Breaker
+}
+
+func NewRedisProvider(c *RedisConfig) (*RedisProvider, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	r := &RedisProvider{
+		prefix:  c.Prefix,
+		breaker: utils.NewBreaker(c.Threshold, c.Cooldown),
+		handle: redis.NewClient(&redis.Options{
+			Addr: fmt.Sprintf("%s:%d", c.Host, c.Port),
+			DB:   c.DB,
+		}),
+	}
+	// provider is returned even if redis is down, as circuit breaker will keep probing it
+	if _, err := r.handle.Ping().Result(); err != nil {
+		r.breaker.Trip()
+		return r, err
+	}
+	return r, nil
+}
+
+// Name implements Provider
+func (r RedisProvider) Name() string { return "redis" }
+
+// GetIP implements Provider
+func (r RedisProvider) GetIP(ip net.IP) (*meta.Asset, bool, error) { return r.get(ip.String()) }
+
+// GetHost implements Provider
+func (r RedisProvider) GetHost(host string) (*meta.Asset, bool, error) { return r.get(host) }
+
+func (r RedisProvider) get(key string) (*meta.Asset, bool, error) {
+	if !r.breaker.Allow() {
+		return nil, false, ErrRedisCircuitOpen
+	}
+	fields, err := r.handle.HGetAll(r.prefix + key).Result()
+	if err != nil && err != redis.Nil {
+		r.breaker.Failure()
+		return nil, false, err
+	}
+	r.breaker.Success()
+	a, ok := redisHashToAsset(key, fields)
+	return a, ok, nil
+}
+
+// GetBatch implements Provider using a single pipelined round trip
+func (r RedisProvider) GetBatch(keys []string) (map[string]*meta.Asset, error) {
+	if !r.breaker.Allow() {
+		return nil, ErrRedisCircuitOpen
+	}
+	pipe := r.handle.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGetAll(r.prefix + key)
+	}
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		r.breaker.Failure()
+		return nil, err
+	}
+	r.breaker.Success()
+	out := make(map[string]*meta.Asset)
+	for i, cmd := range cmds {
+		if fields, err := cmd.Result(); err == nil {
+			if a, ok := redisHashToAsset(keys[i], fields); ok {
+				out[keys[i]] = a
+			}
+		}
+	}
+	return out, nil
+}
+
+// Unavailable implements Breaker
+func (r RedisProvider) Unavailable() bool { return r.breaker.Open() }
+
+// Close implements io.Closer
+func (r RedisProvider) Close() error { return r.handle.Close() }
+
+func redisHashToAsset(key string, fields map[string]string) (*meta.Asset, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+	get := func(names ...string) string {
+		for _, n := range names {
+			if val, ok := fields[n]; ok {
+				return val
+			}
+			if val, ok := fields[FieldPrefix+"."+n]; ok {
+				return val
+			}
+		}
+		return ""
+	}
+	a := &meta.Asset{
+		Host:       get("host", "original"),
+		Alias:      get("alias", "pretty"),
+		OS:         get("os"),
+		VM:         get("vm"),
+		Indicators: meta.Indicators{IsAsset: true},
+	}
+	if ip := net.ParseIP(get("ip")); ip != nil {
+		a.IP = ip
+	} else if ip := net.ParseIP(key); ip != nil {
+		a.IP = ip
+	}
+	return a, true
+}
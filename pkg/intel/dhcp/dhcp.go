@@ -0,0 +1,291 @@
+package dhcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Lease is an address binding with validity interval
+// zero End means lease is open, e.g. lease time was not logged
+type Lease struct {
+	IP    net.IP    `json:"ip"`
+	MAC   string    `json:"mac"`
+	Host  string    `json:"host,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end,omitempty"`
+}
+
+// Valid reports if lease covers ts
+func (l Lease) Valid(ts time.Time) bool {
+	return !ts.Before(l.Start) && (l.End.IsZero() || ts.Before(l.End))
+}
+
+type Config struct {
+	// DefaultLease is used when log does not contain lease time, e.g. dhcpd syslog lines
+	DefaultLease time.Duration
+	// History is maximum number of leases kept per address
+	History int
+	// Retention is how long expired leases are kept, measured in event time
+	// should cover maximum expected delay between DHCP logs and other event streams
+	Retention time.Duration
+	// Persist is JSON lines dump file, empty disables persistence
+	Persist string
+	// Interval between dumps
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c.DefaultLease <= 0 {
+		c.DefaultLease = 24 * time.Hour
+	}
+	if c.History < 1 {
+		c.History = 32
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	return nil
+}
+
+// Table holds lease history per address
+// all decisions are made in event time rather than wall clock, so replayed logs resolve same as live ones
+type Table struct {
+	mu     *sync.RWMutex
+	leases map[string][]*Lease
+	// hosts maps client MAC to host name from requests, as acks do not always carry it
+	hosts map[string]string
+	clock time.Time
+	Config
+}
+
+func NewTable(c *Config) (*Table, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	t := &Table{
+		mu:     &sync.RWMutex{},
+		leases: make(map[string][]*Lease),
+		hosts:  make(map[string]string),
+		Config: *c,
+	}
+	if t.Persist != "" && !utils.FileNotExists(t.Persist) {
+		count, err := t.load(t.Persist)
+		if err != nil {
+			return t, err
+		}
+		log.WithField("path", t.Persist).Infof("loaded %d dhcp leases", count)
+	}
+	return t, nil
+}
+
+func key(ip net.IP) string { return fields.CanonicalIP(ip).String() }
+
+func normalizeMAC(mac string) string {
+	return strings.ToLower(strings.TrimSpace(mac))
+}
+
+func (t *Table) tick(ts time.Time) {
+	if ts.After(t.clock) {
+		t.clock = ts
+	}
+}
+
+// Request remembers host name that client announced
+func (t *Table) Request(mac, host string) {
+	mac, host = normalizeMAC(mac), strings.TrimSpace(host)
+	if mac == "" || host == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hosts[mac] = host
+}
+
+// Ack records lease given to client at ts
+// renewal by same client extends existing lease, while a different client closes previous lease at ts
+func (t *Table) Ack(ip net.IP, mac, host string, ts time.Time, duration time.Duration) {
+	if ip == nil || ip.IsUnspecified() || ts.IsZero() {
+		return
+	}
+	if duration <= 0 {
+		duration = t.DefaultLease
+	}
+	mac = normalizeMAC(mac)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tick(ts)
+	if host == "" {
+		host = t.hosts[mac]
+	} else if mac != "" {
+		t.hosts[mac] = host
+	}
+	t.ack(&Lease{IP: fields.CanonicalIP(ip), MAC: mac, Host: host, Start: ts, End: ts.Add(duration)})
+}
+
+func (t *Table) ack(lease *Lease) {
+	k := key(lease.IP)
+	history := t.leases[k]
+	ts, mac, host, end := lease.Start, lease.MAC, lease.Host, lease.End
+	for _, l := range history {
+		if !l.Valid(ts) {
+			continue
+		}
+		if l.MAC == mac {
+			if end.After(l.End) && !l.End.IsZero() {
+				l.End = end
+			}
+			if host != "" {
+				l.Host = host
+			}
+			return
+		}
+		l.End = ts
+	}
+	history = append(history, lease)
+	// logs from multiple relays may arrive out of order
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Start.Before(history[j].Start) })
+	// a later lease to someone else also bounds this one
+	for i, l := range history {
+		if l == lease && i+1 < len(history) && (lease.End.IsZero() || history[i+1].Start.Before(lease.End)) {
+			lease.End = history[i+1].Start
+		}
+	}
+	if len(history) > t.History {
+		history = history[len(history)-t.History:]
+	}
+	t.leases[k] = history
+}
+
+// Release closes lease held by client at ts
+func (t *Table) Release(ip net.IP, mac string, ts time.Time) {
+	if ip == nil || ts.IsZero() {
+		return
+	}
+	mac = normalizeMAC(mac)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tick(ts)
+	for _, l := range t.leases[key(ip)] {
+		if l.Valid(ts) && (mac == "" || l.MAC == mac) {
+			l.End = ts
+		}
+	}
+}
+
+// Resolve returns lease that was valid for address at ts
+func (t *Table) Resolve(ip net.IP, ts time.Time) (*Lease, bool) {
+	if ip == nil {
+		return nil, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	history := t.leases[key(ip)]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Valid(ts) {
+			l := *history[i]
+			return &l, true
+		}
+	}
+	return nil, false
+}
+
+// Len returns number of addresses in table
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.leases)
+}
+
+// Prune drops leases that ended before retention period, measured from newest observed event
+func (t *Table) Prune() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	for k, history := range t.leases {
+		keep := history[:0]
+		for _, l := range history {
+			if !l.End.IsZero() && t.clock.Sub(l.End) > t.Retention {
+				count++
+				continue
+			}
+			keep = append(keep, l)
+		}
+		if len(keep) == 0 {
+			delete(t.leases, k)
+		} else {
+			t.leases[k] = keep
+		}
+	}
+	return count
+}
+
+// Run periodically prunes old leases and dumps table, blocks until context is cancelled
+// final dump is written on exit
+func (t *Table) Run(ctx context.Context, errs *utils.ErrChan) {
+	utils.RunPersisted(ctx, t.Interval, func() {
+		log.Tracef("pruned %d dhcp leases", t.Prune())
+	}, t.dump, errs)
+}
+
+func (t *Table) dump() error {
+	if t.Persist == "" {
+		return nil
+	}
+	t.mu.RLock()
+	leases := make([]Lease, 0, len(t.leases))
+	for _, history := range t.leases {
+		for _, l := range history {
+			leases = append(leases, *l)
+		}
+	}
+	t.mu.RUnlock()
+	return utils.WriteJSONLines(t.Persist, func(enc *json.Encoder) error {
+		for _, l := range leases {
+			if err := enc.Encode(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// load reads dump written by Run
+func (t *Table) load(path string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	err := utils.ReadJSONLines(path, func(line []byte) error {
+		var l Lease
+		if err := json.Unmarshal(line, &l); err != nil {
+			return utils.ErrDecodeJson{Err: fmt.Errorf("dhcp dump: %s", err), Raw: append([]byte{}, line...)}
+		}
+		if l.IP == nil || l.Start.IsZero() {
+			return nil
+		}
+		l.IP = fields.CanonicalIP(l.IP)
+		t.tick(l.Start)
+		if l.MAC != "" && l.Host != "" {
+			t.hosts[l.MAC] = l.Host
+		}
+		t.ack(&l)
+		count++
+		return nil
+	})
+	return count, err
+}
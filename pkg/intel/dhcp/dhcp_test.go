@@ -0,0 +1,60 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTable(t *testing.T) {
+	table, err := NewTable(&Config{DefaultLease: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC)
+	ip := net.ParseIP("10.0.0.5")
+
+	table.ObserveSuricata(map[string]interface{}{
+		"type":       "request",
+		"dhcp_type":  "request",
+		"client_mac": "00:11:22:33:44:55",
+		"hostname":   "ws01",
+	}, base)
+	table.ObserveSuricata(map[string]interface{}{
+		"type":        "reply",
+		"dhcp_type":   "ack",
+		"client_mac":  "00:11:22:33:44:55",
+		"assigned_ip": "10.0.0.5",
+		"lease_time":  float64(600),
+	}, base)
+	table.ObserveDhcpd("DHCPACK on 10.0.0.5 to aa:bb:cc:dd:ee:ff (ws02) via eth0", base.Add(5*time.Minute))
+
+	for _, tc := range []struct {
+		offset time.Duration
+		host   string
+	}{
+		{-time.Minute, ""},
+		{time.Minute, "ws01"},
+		{6 * time.Minute, "ws02"},
+		{2 * time.Hour, ""},
+	} {
+		lease, ok := table.Resolve(ip, base.Add(tc.offset))
+		if tc.host == "" {
+			if ok {
+				t.Fatalf("offset %s should not resolve, got %+v", tc.offset, lease)
+			}
+			continue
+		}
+		if !ok || lease.Host != tc.host {
+			t.Fatalf("offset %s should resolve to %s, got %+v", tc.offset, tc.host, lease)
+		}
+	}
+
+	table.ObserveDhcpd("DHCPRELEASE of 10.0.0.5 from aa:bb:cc:dd:ee:ff (ws02) via eth0", base.Add(10*time.Minute))
+	if _, ok := table.Resolve(ip, base.Add(11*time.Minute)); ok {
+		t.Fatal("released lease should not resolve")
+	}
+	if lease, ok := table.Resolve(ip, base.Add(9*time.Minute)); !ok || lease.MAC != "aa:bb:cc:dd:ee:ff" {
+		t.Fatalf("lease before release should resolve, got %+v", lease)
+	}
+}
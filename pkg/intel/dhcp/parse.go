@@ -0,0 +1,63 @@
+package dhcp
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ObserveSuricata learns from dhcp object of Suricata EVE record seen at ts
+// basic logging only has reply type and assigned address, extended logging adds dhcp_type, lease_time and hostname
+func (t *Table) ObserveSuricata(dhcp map[string]interface{}, ts time.Time) {
+	if dhcp == nil {
+		return
+	}
+	str := func(key string) string {
+		if val, ok := dhcp[key].(string); ok {
+			return val
+		}
+		return ""
+	}
+	mac, host, kind := str("client_mac"), str("hostname"), strings.ToLower(str("dhcp_type"))
+	switch str("type") {
+	case "request":
+		switch kind {
+		case "release":
+			t.Release(net.ParseIP(str("client_ip")), mac, ts)
+		default:
+			t.Request(mac, host)
+		}
+	case "reply":
+		if kind != "" && kind != "ack" {
+			return
+		}
+		var duration time.Duration
+		if val, ok := dhcp["lease_time"].(float64); ok {
+			duration = time.Duration(val) * time.Second
+		}
+		t.Ack(net.ParseIP(str("assigned_ip")), mac, host, ts, duration)
+	}
+}
+
+var (
+	// DHCPACK on 10.0.0.5 to 00:11:22:33:44:55 (host) via eth0
+	dhcpdAck = regexp.MustCompile(`^DHCPACK on (\S+) to ([0-9a-fA-F:]+)(?: \(([^)]*)\))?`)
+	// DHCPREQUEST for 10.0.0.5 from 00:11:22:33:44:55 (host) via eth0
+	dhcpdRequest = regexp.MustCompile(`^DHCP(?:REQUEST for \S+|DISCOVER) from ([0-9a-fA-F:]+)(?: \(([^)]*)\))?`)
+	// DHCPRELEASE of 10.0.0.5 from 00:11:22:33:44:55 (host) via eth0
+	dhcpdRelease = regexp.MustCompile(`^DHCPRELEASE of (\S+) from ([0-9a-fA-F:]+)`)
+)
+
+// ObserveDhcpd learns from ISC dhcpd syslog message seen at ts
+// lease time is not logged, so configured default is assumed and renewals extend it
+func (t *Table) ObserveDhcpd(msg string, ts time.Time) {
+	msg = strings.TrimSpace(msg)
+	if m := dhcpdAck.FindStringSubmatch(msg); m != nil {
+		t.Ack(net.ParseIP(m[1]), m[2], m[3], ts, 0)
+	} else if m := dhcpdRequest.FindStringSubmatch(msg); m != nil {
+		t.Request(m[1], m[2])
+	} else if m := dhcpdRelease.FindStringSubmatch(msg); m != nil {
+		t.Release(net.ParseIP(m[1]), m[2], ts)
+	}
+}
@@ -0,0 +1,219 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	"github.com/oschwald/geoip2-golang"
+	log "github.com/sirupsen/logrus"
+)
+
+type Config struct {
+	// City is path to GeoLite2 or GeoIP2 City database
+	City string
+	// ASN is path to GeoLite2 ASN database
+	ASN string
+	// Interval for checking database files for modifications, zero disables reloading
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c == nil || (c.City == "" && c.ASN == "") {
+		return fmt.Errorf("neither city nor ASN database configured")
+	}
+	var err error
+	if c.City, err = utils.ExpandHome(c.City); err != nil {
+		return err
+	}
+	if c.ASN, err = utils.ExpandHome(c.ASN); err != nil {
+		return err
+	}
+	return nil
+}
+
+// database is a single mmdb file with modification time for reload
+type database struct {
+	path   string
+	mtime  time.Time
+	reader *geoip2.Reader
+}
+
+func (d *database) open() error {
+	if d.path == "" {
+		return nil
+	}
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return err
+	}
+	r, err := geoip2.Open(d.path)
+	if err != nil {
+		return err
+	}
+	d.reader, d.mtime = r, info.ModTime()
+	return nil
+}
+
+func (d database) modified() bool {
+	if d.path == "" {
+		return false
+	}
+	info, err := os.Stat(d.path)
+	return err == nil && !info.ModTime().Equal(d.mtime)
+}
+
+// Handle does GeoIP and ASN lookups from local MaxMind databases
+// databases are swapped under write lock on reload, so lookups never use a closed reader
+type Handle struct {
+	mu    *sync.RWMutex
+	city  *database
+	asn   *database
+	every time.Duration
+}
+
+func NewHandle(c *Config) (*Handle, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	h := &Handle{
+		mu:    &sync.RWMutex{},
+		city:  &database{path: c.City},
+		asn:   &database{path: c.ASN},
+		every: c.Interval,
+	}
+	for _, db := range []*database{h.city, h.asn} {
+		if err := db.open(); err != nil {
+			h.Close()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Lookup returns location and AS for public addresses, private and reserved ranges are skipped
+func (h *Handle) Lookup(ip net.IP) (*meta.Geo, bool) {
+	if ip == nil || !Public(ip) {
+		return nil, false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	g := &meta.Geo{}
+	var found bool
+	if h.city.reader != nil {
+		if rec, err := h.city.reader.City(ip); err == nil && rec.Country.IsoCode != "" {
+			g.Country = rec.Country.Names["en"]
+			g.CountryISO = rec.Country.IsoCode
+			g.City = rec.City.Names["en"]
+			g.Lat = rec.Location.Latitude
+			g.Lon = rec.Location.Longitude
+			found = true
+		}
+	}
+	if h.asn.reader != nil {
+		if rec, err := h.asn.reader.ASN(ip); err == nil && rec.AutonomousSystemNumber > 0 {
+			g.ASN = rec.AutonomousSystemNumber
+			g.Org = rec.AutonomousSystemOrganization
+			found = true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return g, true
+}
+
+// Run reopens databases when files are replaced, e.g. by geoipupdate, blocks until context is cancelled
+func (h *Handle) Run(ctx context.Context, errs *utils.ErrChan) {
+	if h.every == 0 {
+		return
+	}
+	tick := time.NewTicker(h.every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			for _, db := range []*database{h.city, h.asn} {
+				if !db.modified() {
+					continue
+				}
+				fresh := &database{path: db.path}
+				if err := fresh.open(); err != nil {
+					// file may be mid-write, try again on next tick
+					errs.Send(err)
+					continue
+				}
+				h.mu.Lock()
+				old := db.reader
+				*db = *fresh
+				h.mu.Unlock()
+				if old != nil {
+					old.Close()
+				}
+				log.WithField("path", db.path).Info("reloaded geoip database")
+			}
+		}
+	}
+}
+
+func (h *Handle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, db := range []*database{h.city, h.asn} {
+		if db != nil && db.reader != nil {
+			db.reader.Close()
+			db.reader = nil
+		}
+	}
+	return nil
+}
+
+var reserved = func() []*net.IPNet {
+	out := make([]*net.IPNet, 0)
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.0.2.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"198.51.100.0/24",
+		"203.0.113.0/24",
+		"224.0.0.0/4",
+		"240.0.0.0/4",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"ff00::/8",
+		"2001:db8::/32",
+	} {
+		_, n, _ := net.ParseCIDR(cidr)
+		out = append(out, n)
+	}
+	return out
+}()
+
+// Public reports if address is globally routable, private, documentation and multicast ranges are not
+func Public(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
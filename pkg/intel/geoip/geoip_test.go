@@ -0,0 +1,25 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+)
+
+func TestPublic(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"8.8.8.8":         true,
+		"::ffff:8.8.4.4":  true,
+		"2a00:1450::1":    true,
+		"10.1.2.3":        false,
+		"::ffff:10.1.2.3": false,
+		"172.20.0.1":      false,
+		"100.64.1.1":      false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"224.0.0.251":     false,
+	} {
+		if Public(net.ParseIP(addr)) != public {
+			t.Fatalf("%s public should be %t", addr, public)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"gopkg.in/yaml.v2"
+)
+
+// fileAsset is a loose representation of inventory entry, as written by humans in CSV or YAML
+// single host may have multiple addresses, each will be expanded into separate meta.Asset
+type fileAsset struct {
+	Host  string   `yaml:"host"`
+	Alias string   `yaml:"alias"`
+	OS    string   `yaml:"os"`
+	VM    string   `yaml:"vm"`
+	IP    string   `yaml:"ip"`
+	IPs   []string `yaml:"ips"`
+}
+
+func (f fileAsset) assets() []meta.Asset {
+	base := meta.Asset{
+		Host:  f.Host,
+		Alias: f.Alias,
+		OS:    f.OS,
+		VM:    f.VM,
+	}
+	addrs := f.IPs
+	if f.IP != "" {
+		addrs = append([]string{f.IP}, addrs...)
+	}
+	out := make([]meta.Asset, 0, len(addrs))
+	for _, raw := range addrs {
+		raw = strings.TrimSpace(raw)
+		if ip := fields.ParseIP(raw); ip != nil {
+			a := base
+			a.IP = ip
+			out = append(out, a)
+		} else if _, network, err := net.ParseCIDR(raw); err == nil {
+			// whole segment is attributed to same host, e.g. NAT pool or DHCP range of a workstation group
+			a := base
+			a.Net = &fields.StringNet{IPNet: *network}
+			out = append(out, a)
+		}
+	}
+	if len(out) == 0 && base.Host != "" {
+		out = append(out, base)
+	}
+	return out
+}
+
+// csvColumns maps known header names to asset fields
+// original, pretty, os and vm are field names used in WISE redis tagger scripts
+var csvColumns = map[string]string{
+	"ip":       "ip",
+	"addr":     "ip",
+	"address":  "ip",
+	"ipv4":     "ip",
+	"ipv6":     "ip",
+	"host":     "host",
+	"hostname": "host",
+	"fqdn":     "host",
+	"name":     "host",
+	"original": "host",
+	"alias":    "alias",
+	"pretty":   "alias",
+	"os":       "os",
+	"vm":       "vm",
+}
+
+func parseCSV(r io.Reader) ([]meta.Asset, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(header))
+	var hasAddr bool
+	for i, h := range header {
+		key := strings.ToLower(strings.TrimSpace(h))
+		if idx := strings.LastIndex(key, "."); idx != -1 {
+			// peek.pretty, target.os, etc
+			key = key[idx+1:]
+		}
+		columns[i] = csvColumns[key]
+		if columns[i] == "ip" || columns[i] == "host" {
+			hasAddr = true
+		}
+	}
+	if !hasAddr {
+		return nil, fmt.Errorf("CSV header %v has neither address nor host name column", header)
+	}
+
+	out := make([]meta.Asset, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		var a fileAsset
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "ip":
+				if value != "" {
+					a.IPs = append(a.IPs, value)
+				}
+			case "host":
+				if a.Host == "" {
+					a.Host = value
+				}
+			case "alias":
+				a.Alias = value
+			case "os":
+				a.OS = value
+			case "vm":
+				a.VM = value
+			}
+		}
+		out = append(out, a.assets()...)
+	}
+	return out, nil
+}
+
+func parseYAML(r io.Reader) ([]meta.Asset, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]meta.Asset, 0)
+	var list []fileAsset
+	if err := yaml.Unmarshal(data, &list); err == nil {
+		for _, a := range list {
+			out = append(out, a.assets()...)
+		}
+		return out, nil
+	}
+	var dict map[string]fileAsset
+	if err := yaml.Unmarshal(data, &dict); err != nil {
+		return nil, err
+	}
+	for host, a := range dict {
+		if a.Host == "" {
+			a.Host = host
+		}
+		out = append(out, a.assets()...)
+	}
+	return out, nil
+}
+
+// parseJSON handles a stream of JSON values
+// asset dump lines from assetcache, plain meta.Asset objects, SaltStack grains output and arrays of any of those are supported
+func parseJSON(r io.Reader) ([]meta.Asset, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	out := make([]meta.Asset, 0)
+	// grains are collected separately, as addresses shared by minions can only be found once whole file is read
+	minions := make([]meta.Asset, 0)
+	add := func(raw json.RawMessage) error {
+		assets, grains, err := parseJSONObject(raw)
+		if grains {
+			minions = append(minions, assets...)
+		} else {
+			out = append(out, assets...)
+		}
+		return err
+	}
+	done := func(err error) ([]meta.Asset, error) {
+		return append(out, dropSharedAddresses(minions)...), err
+	}
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return done(err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return done(err)
+			}
+			for _, item := range items {
+				if err := add(item); err != nil {
+					return done(err)
+				}
+			}
+			continue
+		}
+		if err := add(raw); err != nil {
+			return done(err)
+		}
+	}
+	return done(nil)
+}
+
+// parseJSONObject reports whether object was grains output, as those need extra filtering
+func parseJSONObject(raw json.RawMessage) ([]meta.Asset, bool, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, false, err
+	}
+	if data, ok := obj["Data"]; ok {
+		// assetcache dump
+		var a meta.Asset
+		if err := json.Unmarshal(data, &a); err != nil {
+			return nil, false, err
+		}
+		return []meta.Asset{a}, false, nil
+	}
+	_, hasHost := obj["Host"]
+	_, hasIP := obj["IP"]
+	_, hasNet := obj["Net"]
+	if hasHost || hasIP || hasNet {
+		var a meta.Asset
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return nil, false, err
+		}
+		return []meta.Asset{a}, false, nil
+	}
+	out := make([]meta.Asset, 0)
+	for id, val := range obj {
+		var g grains
+		if err := json.Unmarshal(val, &g); err != nil {
+			return nil, true, fmt.Errorf("unknown inventory object for key %s: %s", id, err)
+		}
+		out = append(out, g.assets(id)...)
+	}
+	return out, true, nil
+}
+
+// dropSharedAddresses removes addresses that more than one minion reports, e.g. NAT address of every vagrant box
+// such address does not identify any single host, so hosts are kept by their remaining addresses or by name
+func dropSharedAddresses(assets []meta.Asset) []meta.Asset {
+	hosts := make(map[string]map[string]bool)
+	for _, a := range assets {
+		if a.IP == nil {
+			continue
+		}
+		key := a.IP.String()
+		if hosts[key] == nil {
+			hosts[key] = make(map[string]bool)
+		}
+		hosts[key][a.Host] = true
+	}
+	out := make([]meta.Asset, 0, len(assets))
+	kept := make(map[string]bool)
+	for _, a := range assets {
+		if a.IP != nil && len(hosts[a.IP.String()]) > 1 {
+			continue
+		}
+		kept[a.Host] = true
+		out = append(out, a)
+	}
+	for _, a := range assets {
+		if !kept[a.Host] {
+			kept[a.Host] = true
+			a.IP = nil
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// grains is a subset of SaltStack grains.items output
+type grains struct {
+	ID       string   `json:"id"`
+	FQDN     string   `json:"fqdn"`
+	Host     string   `json:"host"`
+	OS       string   `json:"os"`
+	OSFinger string   `json:"osfinger"`
+	Virtual  string   `json:"virtual"`
+	IPv4     []string `json:"ipv4"`
+	IPv6     []string `json:"ipv6"`
+}
+
+func (g grains) assets(id string) []meta.Asset {
+	a := fileAsset{
+		Host: func() string {
+			for _, h := range []string{g.FQDN, g.ID, g.Host} {
+				if h != "" {
+					return h
+				}
+			}
+			return id
+		}(),
+		OS: func() string {
+			if g.OSFinger != "" {
+				return g.OSFinger
+			}
+			return g.OS
+		}(),
+		VM: g.Virtual,
+	}
+	for _, raw := range append(g.IPv4, g.IPv6...) {
+		ip := net.ParseIP(raw)
+		if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		a.IPs = append(a.IPs, raw)
+	}
+	return a.assets()
+}
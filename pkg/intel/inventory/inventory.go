@@ -0,0 +1,208 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Format is an enum for supported inventory file formats
+type Format int
+
+const (
+	// FormatAuto picks format from file extension, JSON content is sniffed per line
+	FormatAuto Format = iota
+	FormatCSV
+	FormatJSON
+	FormatYAML
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	default:
+		return "auto"
+	}
+}
+
+// NewFormat parses a textual format name or file extension
+func NewFormat(s string) Format {
+	switch strings.TrimPrefix(strings.ToLower(s), ".") {
+	case "csv":
+		return FormatCSV
+	case "json", "jsonl", "ndjson", "grains":
+		return FormatJSON
+	case "yml", "yaml":
+		return FormatYAML
+	default:
+		return FormatAuto
+	}
+}
+
+type Config struct {
+	Paths []string
+	// Watch enables periodic mtime checks, changed files are reloaded
+	Watch    bool
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c == nil {
+		return fmt.Errorf("missing inventory config")
+	}
+	if len(c.Paths) == 0 {
+		return fmt.Errorf("inventory is missing source files")
+	}
+	for i, p := range c.Paths {
+		pth, err := utils.ExpandHome(p)
+		if err != nil {
+			return err
+		}
+		c.Paths[i] = filepath.Clean(pth)
+	}
+	if c.Interval < time.Second {
+		c.Interval = 10 * time.Second
+	}
+	return nil
+}
+
+// LoadFunc is called with full content of a source file whenever it is (re)loaded
+type LoadFunc func(path string, assets []meta.Asset)
+
+// Inventory is a set of asset files with optional change tracking
+type Inventory struct {
+	paths []string
+	mtime map[string]time.Time
+	watch bool
+	every time.Duration
+}
+
+func New(c *Config) (*Inventory, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &Inventory{
+		paths: c.Paths,
+		mtime: make(map[string]time.Time),
+		watch: c.Watch,
+		every: c.Interval,
+	}, nil
+}
+
+// Paths returns configured source files in order
+func (i Inventory) Paths() []string { return i.paths }
+
+// Load reads all configured files and passes parsed assets to fn
+func (i *Inventory) Load(fn LoadFunc) error {
+	for _, pth := range i.paths {
+		stat, err := os.Stat(pth)
+		if err != nil {
+			return err
+		}
+		assets, err := LoadFile(pth, FormatAuto)
+		if err != nil {
+			return err
+		}
+		i.mtime[pth] = stat.ModTime()
+		fn(pth, assets)
+		log.WithFields(log.Fields{
+			"path":   pth,
+			"assets": len(assets),
+		}).Debug("inventory loaded")
+	}
+	return nil
+}
+
+// Run periodically checks source files for modifications and reloads them if watch is enabled
+// blocks until context is cancelled, errors are sent to errs channel if not nil
+func (i *Inventory) Run(ctx context.Context, fn LoadFunc, errs *utils.ErrChan) {
+	if !i.watch {
+		return
+	}
+	tick := time.NewTicker(i.every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			for _, pth := range i.paths {
+				stat, err := os.Stat(pth)
+				if err != nil {
+					if errs != nil {
+						errs.Send(err)
+					}
+					continue
+				}
+				if !stat.ModTime().After(i.mtime[pth]) {
+					continue
+				}
+				assets, err := LoadFile(pth, FormatAuto)
+				if err != nil {
+					if errs != nil {
+						errs.Send(err)
+					}
+					continue
+				}
+				i.mtime[pth] = stat.ModTime()
+				fn(pth, assets)
+				log.WithFields(log.Fields{
+					"path":   pth,
+					"assets": len(assets),
+				}).Info("inventory reloaded")
+			}
+		}
+	}
+}
+
+// LoadFile parses a single inventory file
+// every returned asset is marked as real asset, as opposed to unknown address learned from events
+func LoadFile(path string, f Format) ([]meta.Asset, error) {
+	if f == FormatAuto {
+		f = NewFormat(filepath.Ext(path))
+	}
+	fh, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var assets []meta.Asset
+	switch f {
+	case FormatCSV:
+		assets, err = parseCSV(fh)
+	case FormatYAML:
+		assets, err = parseYAML(fh)
+	default:
+		assets, err = parseJSON(fh)
+	}
+	if err != nil {
+		return nil, ErrParseInventory{Path: path, Format: f, Err: err}
+	}
+	for i := range assets {
+		assets[i].IsAsset = true
+	}
+	return assets, nil
+}
+
+type ErrParseInventory struct {
+	Path   string
+	Format Format
+	Err    error
+}
+
+func (e ErrParseInventory) Error() string {
+	return fmt.Sprintf("Unable to parse inventory file %s as %s: %s", e.Path, e.Format, e.Err)
+}
@@ -0,0 +1,69 @@
+package inventory
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadGrains(t *testing.T) {
+	assets, err := LoadFile("../../../test/data/grains.json", FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) == 0 {
+		t.Fatal("grains file should produce assets")
+	}
+	for _, a := range assets {
+		if !a.IsAsset || a.Host == "" || a.IP == nil {
+			t.Fatalf("invalid asset from grains: %+v", a)
+		}
+		if a.IP.IsLoopback() || a.IP.IsLinkLocalUnicast() {
+			t.Fatalf("loopback and link-local addresses should be skipped: %+v", a)
+		}
+		if a.IP.String() == "10.0.2.15" {
+			t.Fatalf("NAT address shared by every minion should be dropped: %+v", a)
+		}
+	}
+	hosts := make(map[string]string)
+	for _, a := range assets {
+		hosts[a.IP.String()] = a.Host
+	}
+	for ip, want := range map[string]string{
+		"192.168.56.170": "es-master-0",
+		"192.168.56.171": "es-data-0",
+		"192.168.56.172": "es-gw-0",
+		"192.168.56.173": "es-proxy-0",
+	} {
+		if hosts[ip] != want {
+			t.Fatalf("%s: got host %q, want %q", ip, hosts[ip], want)
+		}
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	csvData := "ip,peek.original,peek.pretty,peek.os\n10.0.0.1,ws-01.corp.ex,WS1,Windows 10\n10.0.0.2,,,\n"
+	assets, err := parseCSV(strings.NewReader(csvData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].Host != "ws-01.corp.ex" || assets[0].Alias != "WS1" {
+		t.Fatalf("unexpected CSV assets: %+v", assets)
+	}
+
+	dump := `{"Data":{"Host":"dc","Alias":"","OS":"","VM":"","IP":"10.0.0.3","is_asset":true},"IsAsset":true}
+{"Host":"web","IP":"2001:db8::1"}`
+	if assets, err = parseJSON(strings.NewReader(dump)); err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].Host != "dc" || assets[1].IP.String() != "2001:db8::1" {
+		t.Fatalf("unexpected JSON assets: %+v", assets)
+	}
+
+	yamlData := "web:\n  alias: WEB\n  ips: [10.0.1.1, 10.0.1.2]\n"
+	if assets, err = parseYAML(strings.NewReader(yamlData)); err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[1].Host != "web" || assets[1].Alias != "WEB" {
+		t.Fatalf("unexpected YAML assets: %+v", assets)
+	}
+}
@@ -0,0 +1,303 @@
+package ioc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// Detect guesses indicator kind from raw value, as plain lists rarely say what they contain
+func Detect(value string) string {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return ""
+	case net.ParseIP(value) != nil:
+		return meta.ObservableIP
+	case strings.Contains(value, "/") && !strings.Contains(value, "://"):
+		if _, _, err := net.ParseCIDR(value); err == nil {
+			return meta.ObservableIP
+		}
+		return meta.ObservableURL
+	case strings.Contains(value, "://"):
+		return meta.ObservableURL
+	case strings.Contains(value, "@"):
+		return meta.ObservableEmail
+	case isHex(value):
+		switch len(value) {
+		case 32:
+			return meta.ObservableMD5
+		case 40:
+			return meta.ObservableSHA1
+		case 64:
+			return meta.ObservableSHA256
+		}
+	}
+	if strings.Contains(value, ".") && !strings.ContainsAny(value, ` \/:`) {
+		return meta.ObservableDomain
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseText reads one indicator per line, text after # is a comment
+func parseText(r io.Reader) ([]Indicator, error) {
+	out := make([]Indicator, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if kind := Detect(line); kind != "" {
+			out = append(out, Indicator{Kind: kind, Value: line})
+		}
+	}
+	return out, scanner.Err()
+}
+
+// parseCSV expects a header with value column, named value, indicator or ioc
+// optional type column holds MISP or plain kind names, description or comment column is kept as description
+func parseCSV(r io.Reader) ([]Indicator, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	valueCol, typeCol, descCol := -1, -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "value", "indicator", "ioc":
+			valueCol = i
+		case "type", "kind":
+			typeCol = i
+		case "description", "comment":
+			descCol = i
+		}
+	}
+	if valueCol == -1 {
+		return nil, fmt.Errorf("CSV header %v has no value column", header)
+	}
+	out := make([]Indicator, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out, err
+		}
+		if valueCol >= len(record) {
+			continue
+		}
+		var kind, desc string
+		if typeCol != -1 && typeCol < len(record) {
+			kind = record[typeCol]
+		}
+		if descCol != -1 && descCol < len(record) {
+			desc = record[descCol]
+		}
+		out = append(out, attributeIndicators(kind, record[valueCol], desc)...)
+	}
+	return out, nil
+}
+
+// mispTypes maps MISP attribute types to observable kinds
+var mispTypes = map[string]string{
+	"ip-src":    meta.ObservableIP,
+	"ip-dst":    meta.ObservableIP,
+	"ip":        meta.ObservableIP,
+	"domain":    meta.ObservableDomain,
+	"hostname":  meta.ObservableDomain,
+	"url":       meta.ObservableURL,
+	"uri":       meta.ObservableURL,
+	"md5":       meta.ObservableMD5,
+	"sha1":      meta.ObservableSHA1,
+	"sha256":    meta.ObservableSHA256,
+	"email":     meta.ObservableEmail,
+	"email-src": meta.ObservableEmail,
+	"email-dst": meta.ObservableEmail,
+	"filename":  meta.ObservableFilename,
+}
+
+// attributeIndicators converts a typed value into indicators
+// MISP composite types such as filename|md5 or ip-dst|port hold multiple values separated by pipe
+func attributeIndicators(kind, value, desc string) []Indicator {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.TrimSpace(value)
+	if kind == "" {
+		if k := Detect(value); k != "" {
+			return []Indicator{{Kind: k, Value: value, Description: desc}}
+		}
+		return nil
+	}
+	kinds := strings.Split(kind, "|")
+	values := strings.Split(value, "|")
+	if len(kinds) != len(values) {
+		kinds, values = kinds[:1], values[:1]
+	}
+	out := make([]Indicator, 0, len(kinds))
+	for i, k := range kinds {
+		if mapped, ok := mispTypes[k]; ok {
+			out = append(out, Indicator{Kind: mapped, Value: values[i], Description: desc})
+		}
+	}
+	return out
+}
+
+type mispAttribute struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Comment string `json:"comment"`
+	ToIDS   *bool  `json:"to_ids"`
+}
+
+type mispEvent struct {
+	Info      string          `json:"info"`
+	Attribute []mispAttribute `json:"Attribute"`
+	Object    []struct {
+		Attribute []mispAttribute `json:"Attribute"`
+	} `json:"Object"`
+}
+
+func (e mispEvent) indicators() []Indicator {
+	out := make([]Indicator, 0)
+	attrs := e.Attribute
+	for _, obj := range e.Object {
+		attrs = append(attrs, obj.Attribute...)
+	}
+	for _, a := range attrs {
+		if a.ToIDS != nil && !*a.ToIDS {
+			// analyst has explicitly marked attribute as context only
+			continue
+		}
+		desc := a.Comment
+		if desc == "" {
+			desc = e.Info
+		}
+		out = append(out, attributeIndicators(a.Type, a.Value, desc)...)
+	}
+	return out
+}
+
+type stixObject struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Pattern     string `json:"pattern"`
+	PatternType string `json:"pattern_type"`
+	Revoked     bool   `json:"revoked"`
+}
+
+// stixComparison matches simple equality comparisons in STIX patterning language
+// e.g. [file:hashes.'SHA-256' = '...'] or [domain-name:value = 'example.com']
+var stixComparison = regexp.MustCompile(`([a-z0-9-]+):([A-Za-z0-9_.'-]+)\s*=\s*'((?:[^'\\]|\\.)*)'`)
+
+func stixIndicators(o stixObject) []Indicator {
+	if o.Type != "indicator" || o.Revoked || (o.PatternType != "" && o.PatternType != "stix") {
+		return nil
+	}
+	desc := o.Name
+	if desc == "" {
+		desc = o.Description
+	}
+	out := make([]Indicator, 0)
+	for _, m := range stixComparison.FindAllStringSubmatch(o.Pattern, -1) {
+		object, prop := m[1], strings.ToLower(strings.Replace(m[2], "'", "", -1))
+		value := strings.Replace(m[3], `\'`, "'", -1)
+		var kind string
+		switch {
+		case object == "ipv4-addr" || object == "ipv6-addr":
+			kind = meta.ObservableIP
+		case object == "domain-name":
+			kind = meta.ObservableDomain
+		case object == "url":
+			kind = meta.ObservableURL
+		case object == "email-addr":
+			kind = meta.ObservableEmail
+		case object == "file" && prop == "hashes.md5":
+			kind = meta.ObservableMD5
+		case object == "file" && (prop == "hashes.sha-1" || prop == "hashes.sha1"):
+			kind = meta.ObservableSHA1
+		case object == "file" && (prop == "hashes.sha-256" || prop == "hashes.sha256"):
+			kind = meta.ObservableSHA256
+		case object == "file" && prop == "name":
+			kind = meta.ObservableFilename
+		default:
+			continue
+		}
+		out = append(out, Indicator{Kind: kind, Value: value, Description: desc})
+	}
+	return out
+}
+
+// parseJSON handles MISP event exports, either a single event, a list or REST search response, and STIX 2 bundles
+func parseJSON(r io.Reader) ([]Indicator, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Type     string            `json:"type"`
+		Objects  []stixObject      `json:"objects"`
+		Event    *mispEvent        `json:"Event"`
+		Response []json.RawMessage `json:"response"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		// export of multiple events
+		var list []struct {
+			Event mispEvent `json:"Event"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		out := make([]Indicator, 0)
+		for _, item := range list {
+			out = append(out, item.Event.indicators()...)
+		}
+		return out, nil
+	}
+	out := make([]Indicator, 0)
+	switch {
+	case doc.Type == "bundle":
+		for _, o := range doc.Objects {
+			out = append(out, stixIndicators(o)...)
+		}
+	case doc.Event != nil:
+		out = append(out, doc.Event.indicators()...)
+	case doc.Response != nil:
+		for _, raw := range doc.Response {
+			var item struct {
+				Event mispEvent `json:"Event"`
+			}
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return out, err
+			}
+			out = append(out, item.Event.indicators()...)
+		}
+	default:
+		return nil, fmt.Errorf("neither MISP export nor STIX bundle")
+	}
+	return out, nil
+}
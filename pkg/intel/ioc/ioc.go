@@ -0,0 +1,265 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Indicator is a single entry from indicator list
+type Indicator struct {
+	Kind        string
+	Value       string
+	Source      string
+	Description string
+}
+
+// Set is an immutable lookup structure of indicators
+// exact values are kept in per-kind hash sets and address ranges in a prefix tree
+type Set struct {
+	exact map[string]map[string]*Indicator
+	nets  *utils.PrefixTree
+	count int
+}
+
+func NewSet(indicators []Indicator) *Set {
+	s := &Set{
+		exact: make(map[string]map[string]*Indicator),
+		nets:  utils.NewPrefixTree(),
+	}
+	for i := range indicators {
+		ind := &indicators[i]
+		if ind.Kind == meta.ObservableIP {
+			if _, network, err := net.ParseCIDR(ind.Value); err == nil {
+				s.nets.Insert(*network, ind)
+				continue
+			}
+		}
+		value := normalize(ind.Kind, ind.Value)
+		if value == "" {
+			continue
+		}
+		if s.exact[ind.Kind] == nil {
+			s.exact[ind.Kind] = make(map[string]*Indicator)
+		}
+		if _, ok := s.exact[ind.Kind][value]; !ok {
+			s.count++
+		}
+		s.exact[ind.Kind][value] = ind
+	}
+	return s
+}
+
+// Len returns number of distinct indicators in set
+func (s Set) Len() int { return s.count + s.nets.Len() }
+
+// Match checks single observable, most specific range wins for addresses
+func (s Set) Match(o meta.Observable) (*Indicator, bool) {
+	value := normalize(o.Kind, o.Value)
+	if kind, ok := s.exact[o.Kind]; ok {
+		if ind, ok := kind[value]; ok {
+			return ind, true
+		}
+	}
+	if o.Kind == meta.ObservableIP && s.nets.Len() > 0 {
+		if ip := net.ParseIP(value); ip != nil {
+			if ind, ok := s.nets.Lookup(ip); ok {
+				return ind.(*Indicator), true
+			}
+		}
+	}
+	if o.Kind == meta.ObservableDomain {
+		// list entry for a domain also covers its subdomains
+		for i := strings.IndexByte(value, '.'); i != -1; i = strings.IndexByte(value, '.') {
+			value = value[i+1:]
+			if ind, ok := s.exact[o.Kind][value]; ok {
+				return ind, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// normalize brings indicator and observable values into comparable form
+func normalize(kind, value string) string {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case meta.ObservableIP:
+		if ip := net.ParseIP(value); ip != nil {
+			if v4 := ip.To4(); v4 != nil {
+				return v4.String()
+			}
+			return ip.String()
+		}
+		return ""
+	case meta.ObservableDomain:
+		return strings.ToLower(strings.TrimSuffix(value, "."))
+	case meta.ObservableMD5, meta.ObservableSHA1, meta.ObservableSHA256, meta.ObservableEmail:
+		return strings.ToLower(value)
+	case meta.ObservableURL:
+		// scheme is not known for most observables
+		if i := strings.Index(value, "://"); i != -1 {
+			value = value[i+3:]
+		}
+		return strings.TrimSuffix(value, "/")
+	case meta.ObservableFilename:
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+type Config struct {
+	Paths []string
+	// Interval for checking indicator files for modifications, zero disables reloading
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c == nil || len(c.Paths) == 0 {
+		return fmt.Errorf("no indicator files configured")
+	}
+	for i, pth := range c.Paths {
+		expanded, err := utils.ExpandHome(pth)
+		if err != nil {
+			return err
+		}
+		c.Paths[i] = expanded
+	}
+	return nil
+}
+
+// Matcher checks event observables against indicators from multiple files
+// set is swapped as a whole on reload, so lookups never see a partially loaded file
+type Matcher struct {
+	mu    *sync.RWMutex
+	set   *Set
+	paths []string
+	mtime map[string]time.Time
+	every time.Duration
+}
+
+func NewMatcher(c *Config) (*Matcher, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	m := &Matcher{
+		mu:    &sync.RWMutex{},
+		paths: c.Paths,
+		mtime: make(map[string]time.Time),
+		every: c.Interval,
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Matcher) load() error {
+	all := make([]Indicator, 0)
+	for _, pth := range m.paths {
+		info, err := os.Stat(pth)
+		if err != nil {
+			return err
+		}
+		indicators, err := LoadFile(pth)
+		if err != nil {
+			return err
+		}
+		m.mtime[pth] = info.ModTime()
+		log.WithField("path", pth).Debugf("loaded %d indicators", len(indicators))
+		all = append(all, indicators...)
+	}
+	set := NewSet(all)
+	m.mu.Lock()
+	m.set = set
+	m.mu.Unlock()
+	log.Infof("ioc matcher loaded %d distinct indicators from %d files", set.Len(), len(m.paths))
+	return nil
+}
+
+func (m *Matcher) modified() bool {
+	for _, pth := range m.paths {
+		if info, err := os.Stat(pth); err == nil && !info.ModTime().Equal(m.mtime[pth]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run reloads indicator files when modified, blocks until context is cancelled
+func (m *Matcher) Run(ctx context.Context, errs *utils.ErrChan) {
+	if m.every == 0 {
+		return
+	}
+	tick := time.NewTicker(m.every)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			if m.modified() {
+				if err := m.load(); err != nil {
+					errs.Send(err)
+				}
+			}
+		}
+	}
+}
+
+// Match returns all observables that are present in indicator lists
+func (m *Matcher) Match(observables []meta.Observable) []meta.IOCMatch {
+	m.mu.RLock()
+	set := m.set
+	m.mu.RUnlock()
+	var out []meta.IOCMatch
+	for _, o := range observables {
+		if ind, ok := set.Match(o); ok {
+			out = append(out, meta.IOCMatch{
+				Observable:  o,
+				Indicator:   ind.Value,
+				Source:      ind.Source,
+				Description: ind.Description,
+			})
+		}
+	}
+	return out
+}
+
+// LoadFile parses indicator file, format is decided by extension
+// .json may be either MISP export or STIX 2 bundle, .csv needs a header and anything else is plain text
+func LoadFile(path string) ([]Indicator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	source := filepath.Base(path)
+	var out []Indicator
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		out, err = parseJSON(f)
+	case ".csv":
+		out, err = parseCSV(f)
+	default:
+		out, err = parseText(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse indicator file %s: %s", path, err)
+	}
+	for i := range out {
+		if out[i].Source == "" {
+			out[i].Source = source
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package ioc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+const mispExport = `{"response": [{"Event": {
+	"info": "red team c2",
+	"Attribute": [
+		{"type": "ip-dst|port", "value": "198.51.100.7|443", "comment": ""},
+		{"type": "domain", "value": "c2.example", "comment": "beacon"},
+		{"type": "url", "value": "http://c2.example/stage2", "to_ids": false}
+	],
+	"Object": [{"Attribute": [{"type": "filename|md5", "value": "evil.dll|D41D8CD98F00B204E9800998ECF8427E"}]}]
+}}]}`
+
+const stixBundle = `{"type": "bundle", "objects": [
+	{"type": "indicator", "name": "dropper", "pattern": "[file:hashes.'SHA-256' = 'E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855'] OR [url:value = 'http://dl.example/x.sh']", "pattern_type": "stix"},
+	{"type": "indicator", "pattern": "[ipv4-addr:value = '203.0.113.0/24']"},
+	{"type": "indicator", "revoked": true, "pattern": "[domain-name:value = 'old.example']"},
+	{"type": "malware", "name": "ignored"}
+]}`
+
+func TestParseFormats(t *testing.T) {
+	misp, err := parseJSON(strings.NewReader(mispExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(misp) != 4 {
+		t.Fatalf("expected 4 MISP indicators, got %+v", misp)
+	}
+	stix, err := parseJSON(strings.NewReader(stixBundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stix) != 3 {
+		t.Fatalf("expected 3 STIX indicators, got %+v", stix)
+	}
+	text, err := parseText(strings.NewReader("# list\n10.66.0.0/16\nbad.example # phishing\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, err := parseCSV(strings.NewReader("type,value,comment\nsha1,da39a3ee5e6b4b0d3255bfef95601890afd80709,tool\n,mail@bad.example,\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := NewSet(append(append(append(misp, stix...), text...), csv...))
+
+	for _, o := range []meta.Observable{
+		{Kind: meta.ObservableIP, Value: "198.51.100.7"},
+		{Kind: meta.ObservableIP, Value: "::ffff:203.0.113.9"},
+		{Kind: meta.ObservableIP, Value: "10.66.1.1"},
+		{Kind: meta.ObservableDomain, Value: "beacon.c2.example."},
+		{Kind: meta.ObservableDomain, Value: "bad.example"},
+		{Kind: meta.ObservableMD5, Value: "d41d8cd98f00b204e9800998ecf8427e"},
+		{Kind: meta.ObservableFilename, Value: "EVIL.DLL"},
+		{Kind: meta.ObservableSHA256, Value: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{Kind: meta.ObservableURL, Value: "dl.example/x.sh"},
+		{Kind: meta.ObservableSHA1, Value: "DA39A3EE5E6B4B0D3255BFEF95601890AFD80709"},
+		{Kind: meta.ObservableEmail, Value: "mail@bad.example"},
+	} {
+		if _, ok := set.Match(o); !ok {
+			t.Fatalf("%+v should match", o)
+		}
+	}
+	for _, o := range []meta.Observable{
+		{Kind: meta.ObservableURL, Value: "c2.example/stage2"},
+		{Kind: meta.ObservableDomain, Value: "old.example"},
+		{Kind: meta.ObservableDomain, Value: "notc2.example"},
+		{Kind: meta.ObservableIP, Value: "10.67.0.1"},
+	} {
+		if ind, ok := set.Match(o); ok {
+			t.Fatalf("%+v should not match, got %+v", o, ind)
+		}
+	}
+
+	nested := NewSet([]Indicator{
+		{Kind: meta.ObservableIP, Value: "10.66.1.0/24", Source: "narrow"},
+		{Kind: meta.ObservableIP, Value: "10.66.0.0/16", Source: "wide"},
+	})
+	if ind, ok := nested.Match(meta.Observable{Kind: meta.ObservableIP, Value: "10.66.1.1"}); !ok || ind.Source != "narrow" {
+		t.Fatalf("most specific range should win, got %+v", ind)
+	}
+	if ind, ok := nested.Match(meta.Observable{Kind: meta.ObservableIP, Value: "10.66.2.1"}); !ok || ind.Source != "wide" {
+		t.Fatalf("address outside narrow range should match wide one, got %+v", ind)
+	}
+}
@@ -0,0 +1,252 @@
+package pdns
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Record is a single observed name to address binding
+type Record struct {
+	IP        net.IP    `json:"ip"`
+	Name      string    `json:"name"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type entry struct {
+	key     string
+	records map[string]*Record
+	elem    *list.Element
+}
+
+type Config struct {
+	// Size is maximum number of addresses in table, least recently resolved are evicted first
+	Size int
+	// Names is maximum number of names kept per address
+	Names int
+	// TTL is how long a binding is kept after it was last seen
+	// age is measured against newest observed event timestamp, so replayed logs behave like live ones
+	TTL time.Duration
+	// Persist is JSON lines dump file, empty disables persistence
+	Persist string
+	// Interval between dumps
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c.Size < 1 {
+		c.Size = 100000
+	}
+	if c.Names < 1 {
+		c.Names = 16
+	}
+	if c.TTL <= 0 {
+		c.TTL = 24 * time.Hour
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	return nil
+}
+
+// Table is a bounded passive DNS store keyed by canonical address
+type Table struct {
+	mu    *sync.RWMutex
+	data  map[string]*entry
+	order *list.List
+	clock time.Time
+	Config
+}
+
+func NewTable(c *Config) (*Table, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	t := &Table{
+		mu:     &sync.RWMutex{},
+		data:   make(map[string]*entry),
+		order:  list.New(),
+		Config: *c,
+	}
+	if t.Persist != "" && !utils.FileNotExists(t.Persist) {
+		count, err := t.load(t.Persist)
+		if err != nil {
+			return t, err
+		}
+		log.WithField("path", t.Persist).Infof("loaded %d passive dns records", count)
+	}
+	return t, nil
+}
+
+func key(ip net.IP) string { return fields.CanonicalIP(ip).String() }
+
+// Learn stores binding seen at ts
+func (t *Table) Learn(name string, ip net.IP, ts time.Time) {
+	if name == "" || ip == nil {
+		return
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.learn(&Record{IP: fields.CanonicalIP(ip), Name: name, FirstSeen: ts, LastSeen: ts})
+}
+
+func (t *Table) learn(r *Record) {
+	if r.LastSeen.After(t.clock) {
+		t.clock = r.LastSeen
+	}
+	k := key(r.IP)
+	e, ok := t.data[k]
+	if !ok {
+		e = &entry{key: k, records: make(map[string]*Record)}
+		e.elem = t.order.PushFront(e)
+		t.data[k] = e
+	} else {
+		t.order.MoveToFront(e.elem)
+	}
+	if existing, ok := e.records[r.Name]; ok {
+		if r.FirstSeen.Before(existing.FirstSeen) {
+			existing.FirstSeen = r.FirstSeen
+		}
+		if r.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = r.LastSeen
+		}
+	} else {
+		if len(e.records) >= t.Names {
+			// drop stalest name to make room
+			var stale *Record
+			for _, rec := range e.records {
+				if stale == nil || rec.LastSeen.Before(stale.LastSeen) {
+					stale = rec
+				}
+			}
+			delete(e.records, stale.Name)
+		}
+		e.records[r.Name] = r
+	}
+	for t.order.Len() > t.Size {
+		t.remove(t.order.Back().Value.(*entry))
+	}
+}
+
+func (t *Table) remove(e *entry) {
+	t.order.Remove(e.elem)
+	delete(t.data, e.key)
+}
+
+// Lookup returns names for address that have not expired, most recently seen first
+func (t *Table) Lookup(ip net.IP) []string {
+	if ip == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	e, ok := t.data[key(ip)]
+	if !ok {
+		return nil
+	}
+	records := make([]*Record, 0, len(e.records))
+	for _, r := range e.records {
+		if t.clock.Sub(r.LastSeen) <= t.TTL {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].LastSeen.After(records[j].LastSeen) })
+	out := make([]string, len(records))
+	for i, r := range records {
+		out[i] = r.Name
+	}
+	return out
+}
+
+// Len returns number of addresses in table
+func (t *Table) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.data)
+}
+
+// expire removes bindings older than TTL, relative to newest observed timestamp
+func (t *Table) expire() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	for _, e := range t.data {
+		for name, r := range e.records {
+			if t.clock.Sub(r.LastSeen) > t.TTL {
+				delete(e.records, name)
+				count++
+			}
+		}
+		if len(e.records) == 0 {
+			t.remove(e)
+		}
+	}
+	return count
+}
+
+// Run periodically expires old bindings and dumps table, blocks until context is cancelled
+// final dump is written on exit
+func (t *Table) Run(ctx context.Context, errs *utils.ErrChan) {
+	utils.RunPersisted(ctx, t.Interval, func() {
+		log.Tracef("expired %d passive dns records", t.expire())
+	}, t.dump, errs)
+}
+
+func (t *Table) dump() error {
+	if t.Persist == "" {
+		return nil
+	}
+	t.mu.RLock()
+	records := make([]Record, 0, len(t.data))
+	for e := t.order.Back(); e != nil; e = e.Prev() {
+		for _, r := range e.Value.(*entry).records {
+			records = append(records, *r)
+		}
+	}
+	t.mu.RUnlock()
+	return utils.WriteJSONLines(t.Persist, func(enc *json.Encoder) error {
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// load reads dump written by Run, records are in LRU order from oldest to newest
+func (t *Table) load(path string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	err := utils.ReadJSONLines(path, func(line []byte) error {
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return utils.ErrDecodeJson{Err: fmt.Errorf("passive dns dump: %s", err), Raw: append([]byte{}, line...)}
+		}
+		if r.IP == nil || r.Name == "" {
+			return nil
+		}
+		r.IP = fields.CanonicalIP(r.IP)
+		t.learn(&r)
+		count++
+		return nil
+	})
+	return count, err
+}
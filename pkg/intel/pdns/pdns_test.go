@@ -0,0 +1,57 @@
+package pdns
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	persist := filepath.Join(dir, "pdns.json")
+
+	table, err := NewTable(&Config{Size: 2, TTL: time.Hour, Persist: persist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC)
+	table.Learn("old.example", net.ParseIP("198.51.100.1"), start)
+	table.Learn("www.example", net.ParseIP("198.51.100.1"), start.Add(time.Minute))
+	table.Learn("mail.example", net.ParseIP("::ffff:198.51.100.2"), start.Add(2*time.Minute))
+
+	if names := table.Lookup(net.ParseIP("198.51.100.1")); !reflect.DeepEqual(names, []string{"www.example", "old.example"}) {
+		t.Fatalf("expected most recent name first, got %v", names)
+	}
+	if names := table.Lookup(net.ParseIP("198.51.100.2")); len(names) != 1 {
+		t.Fatalf("mapped address should share entry, got %v", names)
+	}
+	table.Learn("new.example", net.ParseIP("198.51.100.3"), start.Add(90*time.Minute))
+	if table.Len() != 2 {
+		t.Fatalf("table should be bounded to 2 addresses, got %d", table.Len())
+	}
+	if names := table.Lookup(net.ParseIP("198.51.100.1")); names != nil {
+		t.Fatalf("least recently resolved address should be evicted, got %v", names)
+	}
+	if names := table.Lookup(net.ParseIP("198.51.100.2")); len(names) != 0 {
+		t.Fatalf("binding older than ttl in event time should not be returned, got %v", names)
+	}
+
+	if err := table.dump(); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewTable(&Config{Size: 2, TTL: time.Hour, Persist: persist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := restored.Lookup(net.ParseIP("198.51.100.3")); len(names) != 1 || names[0] != "new.example" {
+		t.Fatalf("table should be restored from dump, got %v", names)
+	}
+}
@@ -0,0 +1,375 @@
+package proctree
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/ccdcoe/go-peek/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Record is a single process in table
+type Record struct {
+	Host string `json:"host"`
+	// Key identifies process within host, sysmon process GUID or PID of snoopy session leader
+	Key      string       `json:"key"`
+	Parent   string       `json:"parent,omitempty"`
+	Process  meta.Process `json:"process"`
+	Start    time.Time    `json:"start"`
+	End      *time.Time   `json:"end,omitempty"`
+	LastSeen time.Time    `json:"last_seen"`
+}
+
+type entry struct {
+	key  string
+	rec  *Record
+	elem *list.Element
+}
+
+type Config struct {
+	// Size is maximum number of processes in table, least recently seen are evicted first
+	Size int
+	// Depth is maximum number of ancestors attached to event
+	Depth int
+	// Retention is how long terminated process is kept for resolving delayed events of its children
+	// for snoopy sessions, it is measured from last command in session
+	// age is measured against newest observed event timestamp, so replayed logs behave like live ones
+	Retention time.Duration
+	// Persist is JSON lines dump file, empty disables persistence
+	Persist string
+	// Interval between dumps
+	Interval time.Duration
+}
+
+func (c *Config) Validate() error {
+	if c.Size < 1 {
+		c.Size = 100000
+	}
+	if c.Depth < 1 {
+		c.Depth = 16
+	}
+	if c.Retention <= 0 {
+		c.Retention = time.Hour
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Minute
+	}
+	return nil
+}
+
+// Table is a bounded per-host process table for reconstructing process ancestry
+type Table struct {
+	mu    *sync.Mutex
+	data  map[string]*entry
+	order *list.List
+	clock time.Time
+	Config
+}
+
+func NewTable(c *Config) (*Table, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	t := &Table{
+		mu:     &sync.Mutex{},
+		data:   make(map[string]*entry),
+		order:  list.New(),
+		Config: *c,
+	}
+	if t.Persist != "" && !utils.FileNotExists(t.Persist) {
+		count, err := t.load(t.Persist)
+		if err != nil {
+			return t, err
+		}
+		log.WithField("path", t.Persist).Infof("loaded %d process records", count)
+	}
+	return t, nil
+}
+
+func tableKey(host, key string) string { return strings.ToLower(host) + "|" + key }
+
+func guidKey(guid string) string {
+	if guid == "" {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(guid, "{}"))
+}
+
+func pidKey(pid string) string {
+	if pid == "" {
+		return ""
+	}
+	return "pid:" + pid
+}
+
+// ObserveSysmon learns process from sysmon process creation and termination events
+// ancestors of process that generated event are returned, parent first
+func (t *Table) ObserveSysmon(host string, ev atomic.SysmonEvent, ts time.Time) []meta.Process {
+	if ev == nil || host == "" {
+		return nil
+	}
+	base := ev.Base()
+	if base.ProcessGuid == "" {
+		return nil
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tick(ts)
+	key := guidKey(base.ProcessGuid)
+
+	switch obj := ev.(type) {
+	case *atomic.SysmonProcessCreate:
+		parent := guidKey(obj.ParentProcessGuid)
+		if parent != "" {
+			if _, ok := t.data[tableKey(host, parent)]; !ok {
+				// parent was started before logging began, creation event still names it
+				t.learn(&Record{
+					Host: host,
+					Key:  parent,
+					Process: meta.Process{
+						PID:         obj.ParentProcessID,
+						GUID:        obj.ParentProcessGuid,
+						Image:       obj.ParentImage,
+						CommandLine: obj.ParentCommandLine,
+					},
+					LastSeen: ts,
+				})
+			}
+		}
+		start := obj.UtcTime
+		if start.IsZero() {
+			start = ts
+		}
+		t.learn(&Record{
+			Host:   host,
+			Key:    key,
+			Parent: parent,
+			Process: meta.Process{
+				PID:         obj.ProcessID,
+				GUID:        obj.ProcessGuid,
+				Image:       obj.Image,
+				CommandLine: obj.CommandLine,
+				User:        obj.User,
+			},
+			Start:    start,
+			LastSeen: ts,
+		})
+	case *atomic.SysmonProcessTerminate:
+		if e, ok := t.data[tableKey(host, key)]; ok {
+			end := ts
+			e.rec.End = &end
+		}
+	}
+	e, ok := t.data[tableKey(host, key)]
+	if !ok {
+		return nil
+	}
+	return t.ancestors(host, e.rec.Parent, ts)
+}
+
+// ObserveSnoopy learns session from snoopy command log and returns its ancestors
+// snoopy does not log process or parent ID, so commands are linked to session leader whose PID equals session ID
+// leader is named after login shell if session start was logged, otherwise only user of first seen command is known
+// snoopy does not log session start time either, so leader idle for longer than retention is replaced as a recycled session
+func (t *Table) ObserveSnoopy(host string, ev *atomic.Snoopy, ts time.Time) []meta.Process {
+	if ev == nil || host == "" || ev.Sid == "" {
+		return nil
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tick(ts)
+	key := pidKey(ev.Sid)
+	user := ev.Username
+	if user == "" {
+		user = ev.UID
+	}
+	pid, _ := strconv.Atoi(ev.Sid)
+	if strings.HasPrefix(ev.Cmd, "-") {
+		// login shell is executed with dash prefixed argv[0], so it starts a new session
+		// previous leader with same ID belongs to recycled session and is replaced
+		if e, ok := t.data[tableKey(host, key)]; ok {
+			t.remove(e)
+		}
+		t.learn(&Record{
+			Host: host,
+			Key:  key,
+			Process: meta.Process{
+				PID:         pid,
+				Image:       ev.Filename,
+				CommandLine: ev.Cmd,
+				User:        user,
+			},
+			Start:    ts,
+			LastSeen: ts,
+		})
+		return nil
+	}
+	e, ok := t.data[tableKey(host, key)]
+	if ok && ts.Sub(e.rec.LastSeen) > t.Retention {
+		// session ID was recycled after leader went idle, new session must not inherit previous ancestry
+		t.remove(e)
+		ok = false
+	}
+	if !ok {
+		// session started before logging began
+		t.learn(&Record{
+			Host:     host,
+			Key:      key,
+			Process:  meta.Process{PID: pid, User: user},
+			Start:    ts,
+			LastSeen: ts,
+		})
+	}
+	return t.ancestors(host, key, ts)
+}
+
+// ancestors walks parent links starting from key, visited processes are kept fresh in LRU
+func (t *Table) ancestors(host, key string, ts time.Time) []meta.Process {
+	var out []meta.Process
+	seen := make(map[string]bool)
+	for key != "" && len(out) < t.Depth && !seen[key] {
+		seen[key] = true
+		e, ok := t.data[tableKey(host, key)]
+		if !ok {
+			break
+		}
+		if ts.After(e.rec.LastSeen) {
+			e.rec.LastSeen = ts
+		}
+		t.order.MoveToFront(e.elem)
+		out = append(out, e.rec.Process)
+		key = e.rec.Parent
+	}
+	return out
+}
+
+func (t *Table) tick(ts time.Time) {
+	if ts.After(t.clock) {
+		t.clock = ts
+	}
+}
+
+func (t *Table) learn(r *Record) {
+	t.tick(r.LastSeen)
+	k := tableKey(r.Host, r.Key)
+	if e, ok := t.data[k]; ok {
+		if e.rec.Parent == "" {
+			e.rec.Parent = r.Parent
+		}
+		if r.Start.After(e.rec.Start) {
+			// placeholder learned from child is replaced by creation event of process itself
+			e.rec = r
+		} else if r.LastSeen.After(e.rec.LastSeen) {
+			e.rec.LastSeen = r.LastSeen
+		}
+		t.order.MoveToFront(e.elem)
+	} else {
+		e = &entry{key: k, rec: r}
+		e.elem = t.order.PushFront(e)
+		t.data[k] = e
+	}
+	for t.order.Len() > t.Size {
+		t.remove(t.order.Back().Value.(*entry))
+	}
+}
+
+func (t *Table) remove(e *entry) {
+	t.order.Remove(e.elem)
+	delete(t.data, e.key)
+}
+
+// Len returns number of processes in table
+func (t *Table) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.data)
+}
+
+// expire removes terminated processes and idle snoopy sessions older than retention
+// live sysmon processes are only evicted by table size, as long running parents are the ones most often asked about
+func (t *Table) expire() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	for _, e := range t.data {
+		var since time.Time
+		switch {
+		case e.rec.End != nil:
+			since = *e.rec.End
+		case strings.HasPrefix(e.rec.Key, "pid:"):
+			since = e.rec.LastSeen
+		default:
+			continue
+		}
+		if t.clock.Sub(since) > t.Retention {
+			t.remove(e)
+			count++
+		}
+	}
+	return count
+}
+
+// Run periodically expires old processes and dumps table, blocks until context is cancelled
+// final dump is written on exit
+func (t *Table) Run(ctx context.Context, errs *utils.ErrChan) {
+	utils.RunPersisted(ctx, t.Interval, func() {
+		log.Tracef("expired %d process records", t.expire())
+	}, t.dump, errs)
+}
+
+func (t *Table) dump() error {
+	if t.Persist == "" {
+		return nil
+	}
+	t.mu.Lock()
+	records := make([]Record, 0, len(t.data))
+	for e := t.order.Back(); e != nil; e = e.Prev() {
+		records = append(records, *e.Value.(*entry).rec)
+	}
+	t.mu.Unlock()
+	return utils.WriteJSONLines(t.Persist, func(enc *json.Encoder) error {
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// load reads dump written by Run, records are in LRU order from oldest to newest
+func (t *Table) load(path string) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var count int
+	err := utils.ReadJSONLines(path, func(line []byte) error {
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return utils.ErrDecodeJson{Err: fmt.Errorf("process table dump: %s", err), Raw: append([]byte{}, line...)}
+		}
+		if r.Host == "" || r.Key == "" {
+			return nil
+		}
+		t.learn(&r)
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,86 @@
+package proctree
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+)
+
+func TestTable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "proctree")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	persist := filepath.Join(dir, "proctree.json")
+
+	table, err := NewTable(&Config{Size: 10, Retention: time.Hour, Persist: persist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC)
+	create := func(guid, parent, image string, offset time.Duration) *atomic.SysmonProcessCreate {
+		ev := &atomic.SysmonProcessCreate{
+			ParentProcessGuid: parent,
+			ParentImage:       `C:\Windows\explorer.exe`,
+			CommandLine:       image + " /c",
+		}
+		ev.EventID = atomic.SysmonProcessCreateID
+		ev.ProcessGuid = guid
+		ev.Image = image
+		ev.User = `CORP\alice`
+		ev.UtcTime = start.Add(offset)
+		return ev
+	}
+	if chain := table.ObserveSysmon("ws01", create("{A}", "{EXPLORER}", "cmd.exe", 0), start); len(chain) != 1 || chain[0].Image != `C:\Windows\explorer.exe` {
+		t.Fatalf("parent should be known from creation event, got %+v", chain)
+	}
+	table.ObserveSysmon("ws01", create("{B}", "{A}", "powershell.exe", time.Second), start.Add(time.Second))
+	conn := &atomic.SysmonNetworkConnect{}
+	conn.ProcessGuid = "{b}"
+	chain := table.ObserveSysmon("WS01", conn, start.Add(2*time.Second))
+	if len(chain) != 2 || chain[0].Image != "cmd.exe" || chain[0].User != `CORP\alice` || chain[1].Image != `C:\Windows\explorer.exe` {
+		t.Fatalf("expected cmd.exe and explorer.exe as ancestors, got %+v", chain)
+	}
+	if chain := table.ObserveSysmon("ws02", conn, start.Add(2*time.Second)); chain != nil {
+		t.Fatalf("processes should not leak between hosts, got %+v", chain)
+	}
+
+	table.ObserveSnoopy("srv01", &atomic.Snoopy{Sid: "4242", Username: "bob", Cmd: "-bash", Filename: "/bin/bash"}, start)
+	if chain := table.ObserveSnoopy("srv01", &atomic.Snoopy{Sid: "4242", Username: "root", Cmd: "id", Filename: "/usr/bin/id"}, start.Add(time.Second)); len(chain) != 1 || chain[0].CommandLine != "-bash" || chain[0].User != "bob" {
+		t.Fatalf("snoopy command should be linked to login shell, got %+v", chain)
+	}
+
+	term := &atomic.SysmonProcessTerminate{}
+	term.ProcessGuid = "{A}"
+	table.ObserveSysmon("ws01", term, start.Add(time.Minute))
+	table.ObserveSysmon("ws01", create("{C}", "", "notepad.exe", 2*time.Hour), start.Add(2*time.Hour))
+	if n := table.expire(); n != 2 {
+		t.Fatalf("terminated process and idle session should expire, %d removed", n)
+	}
+
+	if err := table.dump(); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewTable(&Config{Size: 10, Retention: time.Hour, Persist: persist})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Len() != table.Len() {
+		t.Fatalf("restored %d processes, want %d", restored.Len(), table.Len())
+	}
+	if chain := restored.ObserveSysmon("ws01", conn, start.Add(2*time.Hour)); len(chain) != 0 {
+		t.Fatalf("terminated parent should be gone after restore, got %+v", chain)
+	}
+
+	recycled, _ := NewTable(&Config{Retention: time.Hour})
+	recycled.ObserveSnoopy("srv01", &atomic.Snoopy{Sid: "4242", Username: "bob", Cmd: "-bash", Filename: "/bin/bash"}, start)
+	recycled.ObserveSnoopy("srv01", &atomic.Snoopy{Sid: "4242", Username: "bob", Cmd: "id"}, start.Add(time.Minute))
+	if chain := recycled.ObserveSnoopy("srv01", &atomic.Snoopy{Sid: "4242", Username: "carol", Cmd: "id"}, start.Add(3*time.Hour)); len(chain) != 1 || chain[0].CommandLine != "" || chain[0].User != "carol" {
+		t.Fatalf("idle session with reused ID should not inherit previous leader, got %+v", chain)
+	}
+}
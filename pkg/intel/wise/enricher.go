@@ -0,0 +1,151 @@
+package wise
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+type EnricherConfig struct {
+	// TTL is how long a response with fields is cached
+	TTL time.Duration
+	// NegativeTTL is how long an empty response is cached
+	NegativeTTL time.Duration
+	// Workers is number of concurrent background queries
+	Workers int
+}
+
+func (c *EnricherConfig) Validate() error {
+	if c.TTL <= 0 {
+		c.TTL = 10 * time.Minute
+	}
+	if c.NegativeTTL <= 0 {
+		c.NegativeTTL = 2 * time.Minute
+	}
+	if c.Workers < 1 {
+		c.Workers = 4
+	}
+	return nil
+}
+
+type lookup struct {
+	kind Kind
+	key  string
+}
+
+type cached struct {
+	resp    APIResponse
+	expires time.Time
+}
+
+// Enricher resolves threat intel observables from WISE in background
+// Get never blocks, so first occurrence of an observable is not enriched but later ones are
+type Enricher struct {
+	handle  *Handle
+	cache   *sync.Map
+	pending *sync.Map
+	queue   chan lookup
+	EnricherConfig
+}
+
+func NewEnricher(h *Handle, c *EnricherConfig) (*Enricher, error) {
+	if c == nil {
+		c = &EnricherConfig{}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &Enricher{
+		handle:         h,
+		cache:          &sync.Map{},
+		pending:        &sync.Map{},
+		queue:          make(chan lookup, 4096),
+		EnricherConfig: *c,
+	}, nil
+}
+
+// Get returns cached response or schedules a lookup and reports a miss
+func (e *Enricher) Get(kind Kind, key string) (APIResponse, bool) {
+	if !kind.Valid() || key == "" {
+		return nil, false
+	}
+	l := lookup{kind: kind, key: key}
+	if val, ok := e.cache.Load(l); ok {
+		if c := val.(cached); time.Now().Before(c.expires) {
+			return c.resp, true
+		}
+	}
+	if e.handle.Open() {
+		return nil, false
+	}
+	if _, loaded := e.pending.LoadOrStore(l, true); !loaded {
+		select {
+		case e.queue <- l:
+		default:
+			e.pending.Delete(l)
+		}
+	}
+	return nil, false
+}
+
+// Run spawns query workers and cache pruner, blocks until context is cancelled
+func (e *Enricher) Run(ctx context.Context, errs *utils.ErrChan) {
+	var wg sync.WaitGroup
+	for i := 0; i < e.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case l := <-e.queue:
+					resp, err := Query(*e.handle, l.kind, l.key)
+					e.pending.Delete(l)
+					if err != nil {
+						if err != ErrCircuitOpen {
+							errs.Send(err)
+						}
+						continue
+					}
+					ttl := e.TTL
+					if len(resp) == 0 {
+						ttl = e.NegativeTTL
+					}
+					e.cache.Store(l, cached{resp: resp, expires: time.Now().Add(ttl)})
+				}
+			}
+		}()
+	}
+	tick := time.NewTicker(e.NegativeTTL)
+	defer tick.Stop()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-tick.C:
+			now := time.Now()
+			e.cache.Range(func(k, v interface{}) bool {
+				if c := v.(cached); now.After(c.expires) {
+					e.cache.Delete(k)
+				}
+				return true
+			})
+		}
+	}
+	wg.Wait()
+}
+
+// Fields converts response into field map, FieldPrefix is stripped from field names
+// as matches are already stored under prefix key in event meta
+func (r APIResponse) Fields() map[string]string {
+	out := make(map[string]string)
+	for _, resp := range r {
+		out[strings.TrimPrefix(resp.Field, FieldPrefix+".")] = resp.Value
+	}
+	return out
+}
@@ -3,9 +3,13 @@ package wise
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/ccdcoe/go-peek/pkg/models/meta"
@@ -14,6 +18,9 @@ import (
 
 var FieldPrefix = "peek"
 
+// ErrCircuitOpen is returned without contacting WISE while too many recent queries have failed
+var ErrCircuitOpen = errors.New("wise circuit breaker open")
+
 type Buffer struct {
 	Field  string `json:"field"`
 	Value  string `json:"value"`
@@ -42,59 +49,158 @@ const (
 type Config struct {
 	Host string
 	Plugin
+	// Timeout is applied to every single query
+	Timeout time.Duration
+	// HealthPath is requested on startup to verify that WISE is reachable
+	// endpoint should not trigger any lookups in WISE plugins
+	HealthPath string
+	// Threshold is number of consecutive failures that opens circuit breaker
+	Threshold int
+	// Cooldown is how long circuit stays open before WISE is probed again
+	Cooldown time.Duration
+	// Parallel limits concurrent requests in batch queries
+	Parallel int
 }
 
 func (c *Config) Validate() error {
+	if c.Timeout <= 0 {
+		c.Timeout = 500 * time.Millisecond
+	}
+	if c.HealthPath == "" {
+		c.HealthPath = "/views"
+	}
+	if c.Threshold < 1 {
+		c.Threshold = 5
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	if c.Parallel < 1 {
+		c.Parallel = 8
+	}
 	return nil
 }
 
 type Handle struct {
-	alive  bool
-	url    url.URL
-	client http.Client
+	url      url.URL
+	client   http.Client
+	timeout  time.Duration
+	parallel int
+	breaker  *utils.Breaker
 	Plugin
 }
 
+// NewHandle verifies that WISE is reachable
+// handle is returned even if health check fails, as circuit breaker will keep probing it
 func NewHandle(c *Config) (*Handle, error) {
 	if err := c.Validate(); err != nil {
 		return nil, err
 	}
 	h := &Handle{
-		Plugin: c.Plugin,
-		client: http.Client{},
+		Plugin:   c.Plugin,
+		client:   http.Client{},
+		timeout:  c.Timeout,
+		parallel: c.Parallel,
+		breaker:  utils.NewBreaker(c.Threshold, c.Cooldown),
 	}
 	if u, err := url.Parse(c.Host); err != nil {
 		return nil, err
 	} else {
 		h.url = *u
 	}
-	if _, err := QueryIP(*h, "8.8.8.8"); err != nil {
-		h.alive = false
+	if err := h.HealthCheck(c.HealthPath); err != nil {
+		// no point in waiting for timeouts on every worker if WISE is down from the start
+		h.breaker.Trip()
 		return h, err
 	}
 	return h, nil
 }
 
-func QueryIP(h Handle, key string) (APIResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+// HealthCheck requests a static WISE endpoint and expects a successful status code
+func (h Handle) HealthCheck(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
 	defer cancel()
-	req, err := http.NewRequest("GET", h.url.String()+"/ip/"+key, nil)
+	req, err := http.NewRequest("GET", h.url.String()+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("wise health check %s returned %s", path, resp.Status)
+	}
+	return nil
+}
+
+// Open reports if queries are currently short-circuited
+func (h Handle) Open() bool { return h.breaker != nil && h.breaker.Open() }
+
+// Kind is WISE query type, used as URL path segment
+type Kind string
+
+const (
+	KindIP     Kind = "ip"
+	KindDomain Kind = "domain"
+	KindMD5    Kind = "md5"
+	KindSHA256 Kind = "sha256"
+	KindEmail  Kind = "email"
+	KindURL    Kind = "url"
+)
+
+// Valid reports if WISE serves lookups for kind
+func (k Kind) Valid() bool {
+	switch k {
+	case KindIP, KindDomain, KindMD5, KindSHA256, KindEmail, KindURL:
+		return true
+	}
+	return false
+}
+
+func QueryIP(h Handle, key string) (APIResponse, error) { return Query(h, KindIP, key) }
+
+// Query does a single lookup of kind, key is escaped so URLs and emails can be passed as-is
+func Query(h Handle, kind Kind, key string) (APIResponse, error) {
+	if !kind.Valid() {
+		return nil, fmt.Errorf("unsupported wise query type %s", kind)
+	}
+	if h.breaker != nil && !h.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+	timeout := h.timeout
+	if timeout == 0 {
+		timeout = 500 * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequest("GET", h.url.String()+"/"+string(kind)+"/"+url.PathEscape(key), nil)
 	if err != nil {
 		return nil, err
 	}
 	req = req.WithContext(ctx)
 
-	// TODO - count timeouts for metrix
 	resp, err := h.client.Do(req)
 	if err != nil {
+		if h.breaker != nil {
+			h.breaker.Failure()
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		if h.breaker != nil {
+			h.breaker.Failure()
+		}
 		return nil, err
 	}
+	if h.breaker != nil {
+		h.breaker.Success()
+	}
 
 	var data APIResponse
 	if err := json.Unmarshal(body, &data); err != nil {
@@ -106,6 +212,40 @@ func QueryIP(h Handle, key string) (APIResponse, error) {
 	return data, nil
 }
 
+// QueryIPBatch looks up multiple addresses concurrently, limited by configured parallelism
+// failed keys are omitted from result and last error is returned
+func QueryIPBatch(h Handle, keys []string) (map[string]APIResponse, error) {
+	parallel := h.parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		last error
+		sem  = make(chan struct{}, parallel)
+		out  = make(map[string]APIResponse)
+	)
+	for _, key := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resp, err := QueryIP(h, key)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				last = err
+				return
+			}
+			out[key] = resp
+		}(key)
+	}
+	wg.Wait()
+	return out, last
+}
+
 func GetAsset(
 	h Handle,
 	key string,
@@ -115,6 +255,30 @@ func GetAsset(
 	if err != nil {
 		return nil, false, err
 	}
+	m, ok := responseToAsset(resp, hostKey, aliasKey, osKey, vmKey)
+	return m, ok, nil
+}
+
+// GetAssets is batch variant of GetAsset, keys without asset information are omitted
+func GetAssets(
+	h Handle,
+	keys []string,
+	hostKey, aliasKey, osKey, vmKey string,
+) (map[string]*meta.Asset, error) {
+	resp, err := QueryIPBatch(h, keys)
+	out := make(map[string]*meta.Asset)
+	for key, r := range resp {
+		if m, ok := responseToAsset(r, hostKey, aliasKey, osKey, vmKey); ok {
+			out[key] = m
+		}
+	}
+	return out, err
+}
+
+func responseToAsset(
+	resp APIResponse,
+	hostKey, aliasKey, osKey, vmKey string,
+) (*meta.Asset, bool) {
 	if len(resp) > 0 {
 		m := &meta.Asset{Indicators: meta.Indicators{
 			IsAsset: true,
@@ -133,9 +297,9 @@ func GetAsset(
 				m.VM = field.Value
 			}
 		}
-		return m, true, nil
+		return m, true
 	}
-	return nil, false, nil
+	return nil, false
 }
 
 type callResp struct {
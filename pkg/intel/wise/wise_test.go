@@ -0,0 +1,103 @@
+package wise
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+func newTestServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/views", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/ip/10.0.0.5", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"field":"peek.original","value":"ws01","len":4},{"field":"peek.pretty","value":"alias01","len":7}]`))
+	})
+	mux.HandleFunc("/url/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/url/evil.example/a b" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"field":"peek.feed","value":"phishing","len":8}]`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`[]`)) })
+	return httptest.NewServer(mux)
+}
+
+func TestQuery(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	h, err := NewHandle(&Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ok, err := GetAsset(*h, "10.0.0.5", "peek.original", "peek.pretty", "", "")
+	if err != nil || !ok || a.Host != "ws01" || a.Alias != "alias01" || !a.IsAsset {
+		t.Fatalf("bad asset %+v %v", a, err)
+	}
+	if _, ok, err := GetAsset(*h, "10.0.0.6", "peek.original", "", "", ""); err != nil || ok {
+		t.Fatal("empty response should not produce an asset")
+	}
+	resp, err := Query(*h, KindURL, "evil.example/a b")
+	if err != nil || resp.Map()["peek.feed"] != "phishing" {
+		t.Fatalf("key should be escaped, got %+v %v", resp, err)
+	}
+	if fields := resp.Fields(); fields["feed"] != "phishing" {
+		t.Fatalf("field prefix should be stripped, got %+v", fields)
+	}
+	if _, err := Query(*h, Kind("sha1"), "x"); err == nil {
+		t.Fatal("unsupported kind should be rejected")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	srv := newTestServer()
+	srv.Close()
+	h, err := NewHandle(&Config{Host: srv.URL, Threshold: 2, Cooldown: time.Minute})
+	if h == nil || err == nil {
+		t.Fatalf("unreachable WISE should return handle with error, got %v %v", h, err)
+	}
+	if !h.Open() {
+		t.Fatal("failed health check should open circuit")
+	}
+	if _, err := QueryIP(*h, "10.0.0.5"); err != ErrCircuitOpen {
+		t.Fatalf("open circuit should short-circuit queries, got %v", err)
+	}
+	e, _ := NewEnricher(h, nil)
+	e.Get(KindDomain, "evil.example")
+	if len(e.queue) != 0 {
+		t.Fatal("enricher should not queue lookups while circuit is open")
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	h, err := NewHandle(&Config{Host: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewEnricher(h, &EnricherConfig{Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := e.Get(KindURL, "evil.example/a b"); ok {
+		t.Fatal("first lookup should miss")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Run(ctx, utils.NewErrChan(10, "test"))
+	for i := 0; i < 100; i++ {
+		if resp, ok := e.Get(KindURL, "evil.example/a b"); ok {
+			if resp.Map()["peek.feed"] != "phishing" {
+				t.Fatalf("bad cached response %+v", resp)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("lookup was not cached")
+}
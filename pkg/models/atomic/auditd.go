@@ -0,0 +1,512 @@
+package atomic
+
+import (
+	"container/list"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	auditdSource = "auditd"
+	// auditdEnrichedSeparator starts interpreted fields that auditd appends in ENRICHED log format
+	auditdEnrichedSeparator = '\x1d'
+	// auditdKeySeparator separates multiple rule keys in key field
+	auditdKeySeparator = "\x01"
+)
+
+// auditdEncoded are fields that kernel logs as hex if value contains spaces, quotes or control characters
+// EXECVE arguments are handled separately
+var auditdEncoded = map[string]bool{
+	"acct":      true,
+	"cmd":       true,
+	"comm":      true,
+	"cwd":       true,
+	"data":      true,
+	"dir":       true,
+	"exe":       true,
+	"file":      true,
+	"key":       true,
+	"name":      true,
+	"ocomm":     true,
+	"path":      true,
+	"proctitle": true,
+	"root_dir":  true,
+	"vm":        true,
+	"watch":     true,
+}
+
+// auditdSyscallRecords are kernel record types that belong to syscall event, which is terminated by EOE record
+// any other record type is standalone, e.g. user space messages from PAM or sshd
+var auditdSyscallRecords = map[string]bool{
+	"SYSCALL":       true,
+	"EXECVE":        true,
+	"CWD":           true,
+	"PATH":          true,
+	"PROCTITLE":     true,
+	"SOCKADDR":      true,
+	"SOCKETCALL":    true,
+	"FD_PAIR":       true,
+	"MMAP":          true,
+	"BPRM_FCAPS":    true,
+	"CAPSET":        true,
+	"OBJ_PID":       true,
+	"IPC":           true,
+	"IPC_SET_PERM":  true,
+	"MQ_OPEN":       true,
+	"MQ_SENDRECV":   true,
+	"MQ_NOTIFY":     true,
+	"MQ_GETSETATTR": true,
+	"KERN_MODULE":   true,
+	"NETFILTER_CFG": true,
+	"AVC":           true,
+	"SELINUX_ERR":   true,
+}
+
+// AuditdRecord is a single line of linux audit log
+// hex encoded values are decoded, fields of user space message are merged into record
+type AuditdRecord struct {
+	Node   string            `json:"-"`
+	Time   time.Time         `json:"-"`
+	Serial uint64            `json:"-"`
+	Type   string            `json:"type"`
+	Fields map[string]string `json:"fields"`
+}
+
+// ParseAuditdRecord parses a line such as node=host type=SYSCALL msg=audit(1586858400.123:2048): arch=c000003e ...
+func ParseAuditdRecord(line string) (*AuditdRecord, error) {
+	line = strings.TrimSpace(line)
+	i := strings.Index(line, "msg=audit(")
+	if i == -1 {
+		return nil, fmt.Errorf("auditd record missing header: %s", line)
+	}
+	r := &AuditdRecord{Fields: make(map[string]string)}
+	for k, v := range parseAuditdFields(line[:i]) {
+		switch k {
+		case "node":
+			r.Node = v.value
+		case "type":
+			r.Type = v.value
+		}
+	}
+	if r.Type == "" {
+		return nil, fmt.Errorf("auditd record missing type: %s", line)
+	}
+	header := line[i+len("msg=audit("):]
+	end := strings.Index(header, "):")
+	if end == -1 {
+		return nil, fmt.Errorf("auditd record with unterminated header: %s", line)
+	}
+	bits := strings.SplitN(header[:end], ":", 2)
+	if len(bits) != 2 {
+		return nil, fmt.Errorf("auditd record missing serial: %s", line)
+	}
+	serial, err := strconv.ParseUint(bits[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("auditd record serial: %s", err)
+	}
+	r.Serial = serial
+	ts, err := parseAuditdTime(bits[0])
+	if err != nil {
+		return nil, err
+	}
+	r.Time = ts
+
+	body := header[end+2:]
+	var enriched string
+	if j := strings.IndexByte(body, auditdEnrichedSeparator); j != -1 {
+		body, enriched = body[:j], body[j+1:]
+	}
+	for k, v := range parseAuditdFields(body) {
+		if k == "msg" {
+			// user space message is quoted list of its own fields
+			for k, v := range parseAuditdFields(v.value) {
+				r.Fields[k] = r.decode(k, v)
+			}
+			continue
+		}
+		r.Fields[k] = r.decode(k, v)
+	}
+	// interpreted values, e.g. AUID="alice" SYSCALL=execve
+	for k, v := range parseAuditdFields(enriched) {
+		r.Fields[k] = v.value
+	}
+	return r, nil
+}
+
+// parseAuditdTime parses epoch with millisecond fraction, as in 1586858400.123
+func parseAuditdTime(raw string) (time.Time, error) {
+	bits := strings.SplitN(raw, ".", 2)
+	sec, err := strconv.ParseInt(bits[0], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("auditd record timestamp: %s", err)
+	}
+	var nsec int64
+	if len(bits) == 2 && bits[1] != "" {
+		frac := bits[1]
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		if nsec, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64); err != nil {
+			return time.Time{}, fmt.Errorf("auditd record timestamp: %s", err)
+		}
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+type auditdValue struct {
+	value  string
+	quoted bool
+}
+
+// parseAuditdFields splits key=value list, values may be double or single quoted
+func parseAuditdFields(s string) map[string]auditdValue {
+	out := make(map[string]auditdValue)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ")
+		eq := strings.IndexByte(s, '=')
+		if eq == -1 {
+			break
+		}
+		key := s[:eq]
+		if sp := strings.LastIndexByte(key, ' '); sp != -1 {
+			// stray token without value
+			key = key[sp+1:]
+		}
+		s = s[eq+1:]
+		var val auditdValue
+		if len(s) > 0 && (s[0] == '"' || s[0] == '\'') {
+			quote := s[0]
+			end := strings.IndexByte(s[1:], quote)
+			if end == -1 {
+				end = len(s) - 1
+			}
+			val = auditdValue{value: s[1 : end+1], quoted: true}
+			if end+2 < len(s) {
+				s = s[end+2:]
+			} else {
+				s = ""
+			}
+		} else {
+			end := strings.IndexByte(s, ' ')
+			if end == -1 {
+				end = len(s)
+			}
+			val = auditdValue{value: s[:end]}
+			s = s[end:]
+		}
+		if key != "" {
+			out[key] = val
+		}
+	}
+	return out
+}
+
+func (r AuditdRecord) decode(key string, v auditdValue) string {
+	if v.quoted || v.value == "(null)" {
+		return v.value
+	}
+	encoded := auditdEncoded[key]
+	if !encoded && r.Type == "EXECVE" {
+		encoded = isExecveArg(key)
+	}
+	if !encoded {
+		return v.value
+	}
+	raw, err := hex.DecodeString(v.value)
+	if err != nil {
+		return v.value
+	}
+	switch key {
+	case "proctitle":
+		// arguments are separated by null bytes
+		return strings.TrimRight(strings.Replace(string(raw), "\x00", " ", -1), " ")
+	case "key":
+		return strings.Replace(string(raw), auditdKeySeparator, ",", -1)
+	}
+	return string(raw)
+}
+
+// isExecveArg matches argument keys such as a0, and a1[0] for arguments that are logged in chunks
+func isExecveArg(key string) bool {
+	if len(key) < 2 || key[0] != 'a' {
+		return false
+	}
+	key = key[1:]
+	if i := strings.IndexByte(key, '['); i != -1 && strings.HasSuffix(key, "]") {
+		if _, err := strconv.Atoi(key[i+1 : len(key)-1]); err != nil {
+			return false
+		}
+		key = key[:i]
+	}
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// Get returns decoded field value
+func (r AuditdRecord) Get(key string) (string, bool) {
+	if r.Fields == nil {
+		return "", false
+	}
+	val, ok := r.Fields[key]
+	return val, ok
+}
+
+// SockAddr decodes address from hex encoded saddr field of SOCKADDR record
+// false is returned for families other than inet and inet6, e.g. unix sockets
+func (r AuditdRecord) SockAddr() (net.IP, int, bool) {
+	val, ok := r.Get("saddr")
+	if !ok {
+		return nil, 0, false
+	}
+	raw, err := hex.DecodeString(val)
+	if err != nil || len(raw) < 2 {
+		return nil, 0, false
+	}
+	switch binary.LittleEndian.Uint16(raw[:2]) {
+	case 2:
+		if len(raw) < 8 {
+			return nil, 0, false
+		}
+		return net.IP(raw[4:8]).To4(), int(binary.BigEndian.Uint16(raw[2:4])), true
+	case 10:
+		if len(raw) < 24 {
+			return nil, 0, false
+		}
+		return net.IP(raw[8:24]), int(binary.BigEndian.Uint16(raw[2:4])), true
+	}
+	return nil, 0, false
+}
+
+// Auditd is linux audit event, made up of records that share serial
+type Auditd struct {
+	Timestamp time.Time      `json:"@timestamp"`
+	Node      string         `json:"node,omitempty"`
+	Serial    uint64         `json:"serial"`
+	Records   []AuditdRecord `json:"records"`
+}
+
+// NewAuditd builds event from its records
+func NewAuditd(records ...*AuditdRecord) *Auditd {
+	a := &Auditd{Records: make([]AuditdRecord, 0, len(records))}
+	for _, r := range records {
+		if r.Type == "EOE" {
+			continue
+		}
+		if a.Timestamp.IsZero() {
+			a.Timestamp, a.Node, a.Serial = r.Time, r.Node, r.Serial
+		}
+		a.Records = append(a.Records, *r)
+	}
+	return a
+}
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (a Auditd) Time() time.Time { return a.Timestamp }
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (a Auditd) Source() string { return auditdSource }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (a Auditd) Sender() string { return a.Node }
+
+// Primary returns record that describes event, SYSCALL record if present
+func (a Auditd) Primary() *AuditdRecord {
+	if r := a.Record("SYSCALL"); r != nil {
+		return r
+	}
+	if len(a.Records) > 0 {
+		return &a.Records[0]
+	}
+	return nil
+}
+
+// Type returns type of primary record
+func (a Auditd) Type() string {
+	if r := a.Primary(); r != nil {
+		return r.Type
+	}
+	return ""
+}
+
+// Record returns first record of type, nil if event has none
+func (a Auditd) Record(typ string) *AuditdRecord {
+	for i := range a.Records {
+		if a.Records[i].Type == typ {
+			return &a.Records[i]
+		}
+	}
+	return nil
+}
+
+// Field looks up value from primary record, falling back to other records in order
+func (a Auditd) Field(key string) (string, bool) {
+	primary := a.Primary()
+	if primary != nil {
+		if val, ok := primary.Get(key); ok {
+			return val, true
+		}
+	}
+	for i := range a.Records {
+		if &a.Records[i] == primary {
+			continue
+		}
+		if val, ok := a.Records[i].Get(key); ok {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// Args returns command line from EXECVE record, or from PROCTITLE if execve was not logged
+func (a Auditd) Args() []string {
+	if r := a.Record("EXECVE"); r != nil {
+		argc, _ := strconv.Atoi(r.Fields["argc"])
+		args := make([]string, 0, argc)
+		for i := 0; i < argc; i++ {
+			if val, ok := r.Get("a" + strconv.Itoa(i)); ok {
+				args = append(args, val)
+			}
+		}
+		if len(args) > 0 {
+			return args
+		}
+	}
+	if r := a.Record("PROCTITLE"); r != nil {
+		if val, ok := r.Get("proctitle"); ok && val != "" {
+			return strings.Fields(val)
+		}
+	}
+	return nil
+}
+
+type auditdGroup struct {
+	key     string
+	records []*AuditdRecord
+	last    time.Time
+	elem    *list.Element
+}
+
+// AuditdAssembler groups records that share serial into events
+// syscall events are complete when their EOE record arrives, other record types are events on their own
+// EOE may be lost or records may arrive out of order, so incomplete events are handed out after timeout measured in event time
+// or when too many are pending
+type AuditdAssembler struct {
+	mu      sync.Mutex
+	pending map[string]*auditdGroup
+	order   *list.List
+	size    int
+	timeout time.Duration
+	// clock is newest observed record time, wall is when it was observed
+	clock, wall time.Time
+}
+
+func NewAuditdAssembler(size int, timeout time.Duration) *AuditdAssembler {
+	if size < 1 {
+		size = 10000
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &AuditdAssembler{
+		pending: make(map[string]*auditdGroup),
+		order:   list.New(),
+		size:    size,
+		timeout: timeout,
+	}
+}
+
+// Timeout is how long incomplete events are kept pending
+func (a *AuditdAssembler) Timeout() time.Duration { return a.timeout }
+
+// Add stores record and returns events that were completed by it, along with all pending events that went stale
+// returned events are not necessarily the one record belongs to
+func (a *AuditdAssembler) Add(r *AuditdRecord) []*Auditd {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if r.Time.After(a.clock) {
+		a.clock, a.wall = r.Time, time.Now()
+	}
+	var out []*Auditd
+	key := r.Node + "|" + strconv.FormatUint(r.Serial, 10)
+	g, ok := a.pending[key]
+	switch {
+	case r.Type == "EOE":
+		if ok {
+			a.remove(g)
+			out = append(out, NewAuditd(g.records...))
+		}
+	case ok:
+		g.records = append(g.records, r)
+		if r.Time.After(g.last) {
+			g.last = r.Time
+		}
+	case auditdSyscallRecords[r.Type]:
+		g = &auditdGroup{key: key, records: []*AuditdRecord{r}, last: r.Time}
+		g.elem = a.order.PushBack(g)
+		a.pending[key] = g
+	default:
+		out = append(out, NewAuditd(r))
+	}
+	return a.expire(a.now(), out)
+}
+
+// Expire returns pending events that went stale, so they are not held back when no more records arrive
+func (a *AuditdAssembler) Expire() []*Auditd {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.expire(a.now(), nil)
+}
+
+// Flush returns all pending events, complete or not, e.g. on shutdown
+func (a *AuditdAssembler) Flush() []*Auditd {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []*Auditd
+	for a.order.Len() > 0 {
+		g := a.order.Front().Value.(*auditdGroup)
+		a.remove(g)
+		out = append(out, NewAuditd(g.records...))
+	}
+	return out
+}
+
+// now is event clock advanced by wall time since newest record, caller must hold lock
+func (a *AuditdAssembler) now() time.Time {
+	if a.clock.IsZero() {
+		return time.Now()
+	}
+	return a.clock.Add(time.Since(a.wall))
+}
+
+// expire appends stale and excess events to out, caller must hold lock
+// groups are ordered by first record, but late records extend them, so every group is checked
+func (a *AuditdAssembler) expire(now time.Time, out []*Auditd) []*Auditd {
+	for e := a.order.Front(); e != nil; {
+		g := e.Value.(*auditdGroup)
+		e = e.Next()
+		if now.Sub(g.last) > a.timeout {
+			a.remove(g)
+			out = append(out, NewAuditd(g.records...))
+		}
+	}
+	for a.order.Len() > a.size {
+		g := a.order.Front().Value.(*auditdGroup)
+		a.remove(g)
+		out = append(out, NewAuditd(g.records...))
+	}
+	return out
+}
+
+func (a *AuditdAssembler) remove(g *auditdGroup) {
+	a.order.Remove(g.elem)
+	delete(a.pending, g.key)
+}
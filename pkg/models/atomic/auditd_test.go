@@ -0,0 +1,104 @@
+package atomic
+
+import (
+	"testing"
+	"time"
+)
+
+var auditdExec = []string{
+	`type=SYSCALL msg=audit(1586858400.123:2048): arch=c000003e syscall=59 success=yes exit=0 a0=55d0 a1=55d1 a2=55d2 a3=0 items=2 ppid=4242 pid=4343 auid=1000 uid=0 gid=0 euid=0 tty=pts0 ses=3 comm="chmod" exe="/usr/bin/chmod" key=70726976657363`,
+	`type=EXECVE msg=audit(1586858400.123:2048): argc=3 a0="chmod" a1="777" a2=2F746D702F6D792066696C65`,
+	`type=CWD msg=audit(1586858400.123:2048): cwd="/root"`,
+	`type=SOCKADDR msg=audit(1586858400.123:2048): saddr=02000050C00002100000000000000000`,
+	`type=PROCTITLE msg=audit(1586858400.123:2048): proctitle=63686D6F6400373737002F746D702F6D792066696C65`,
+	`type=EOE msg=audit(1586858400.123:2048): `,
+}
+
+func TestAuditdAssembler(t *testing.T) {
+	a := NewAuditdAssembler(10, time.Second)
+	var ev *Auditd
+	for i, line := range auditdExec {
+		r, err := ParseAuditdRecord(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if evs := a.Add(r); len(evs) > 0 && i != len(auditdExec)-1 {
+			t.Fatalf("event completed early on record %d", i)
+		} else if len(evs) > 0 {
+			ev = evs[0]
+		}
+	}
+	if ev == nil {
+		t.Fatal("EOE should complete event")
+	}
+	if len(ev.Records) != 5 || ev.Serial != 2048 || ev.Type() != "SYSCALL" {
+		t.Fatalf("bad event %+v", ev)
+	}
+	if want := time.Date(2020, 4, 14, 10, 0, 0, 123000000, time.UTC); !ev.Time().Equal(want) {
+		t.Fatalf("got ts %s, want %s", ev.Time(), want)
+	}
+	for key, want := range map[string]string{
+		"key":       "privesc",
+		"proctitle": "chmod 777 /tmp/my file",
+		"cwd":       "/root",
+		"exe":       "/usr/bin/chmod",
+	} {
+		if val, _ := ev.Field(key); val != want {
+			t.Fatalf("%s: got %q, want %q", key, val, want)
+		}
+	}
+	if val, _ := ev.Record("EXECVE").Get("a2"); val != "/tmp/my file" {
+		t.Fatalf("execve argument should be decoded, got %q", val)
+	}
+	if val, _ := ev.Field("a2"); val != "55d2" {
+		t.Fatalf("syscall record should take precedence, got %q", val)
+	}
+	if args := ev.Args(); len(args) != 3 || args[2] != "/tmp/my file" {
+		t.Fatalf("bad args %q", args)
+	}
+	if ip, port, ok := ev.Record("SOCKADDR").SockAddr(); !ok || ip.String() != "192.0.2.16" || port != 80 {
+		t.Fatalf("bad sockaddr %s:%d", ip, port)
+	}
+
+	login, err := ParseAuditdRecord(`node=srv01 type=USER_LOGIN msg=audit(1586858401.000:2049): pid=5000 uid=0 auid=1000 ses=4 msg='op=login acct=626F62 exe="/usr/sbin/sshd" hostname=? addr=198.51.100.7 terminal=ssh res=success'` + "\x1d" + `UID="root" AUID="bob"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs := a.Add(login)
+	if len(evs) != 1 || evs[0].Node != "srv01" {
+		t.Fatalf("user space message should be an event on its own, got %+v", evs)
+	}
+	ev = evs[0]
+	for key, want := range map[string]string{"acct": "bob", "addr": "198.51.100.7", "AUID": "bob", "res": "success"} {
+		if val, _ := ev.Field(key); val != want {
+			t.Fatalf("%s: got %q, want %q", key, val, want)
+		}
+	}
+
+	// lost EOE, every stale event is released by any later record
+	for _, line := range auditdExec[:2] {
+		r, _ := ParseAuditdRecord(line)
+		a.Add(r)
+	}
+	other, _ := ParseAuditdRecord(`type=SYSCALL msg=audit(1586858400.500:2049): syscall=2 success=yes`)
+	a.Add(other)
+	next, _ := ParseAuditdRecord(`type=USER_AUTH msg=audit(1586858405.000:2050): pid=5000 res=success`)
+	if evs = a.Add(next); len(evs) != 3 || evs[0].Serial != 2050 || evs[1].Serial != 2048 || len(evs[1].Records) != 2 || evs[2].Serial != 2049 {
+		t.Fatalf("stale events should be released, got %+v", evs)
+	}
+
+	// nothing arrives after incomplete event
+	last, _ := ParseAuditdRecord(`type=SYSCALL msg=audit(1586858405.000:2051): syscall=2 success=yes`)
+	if evs = a.Add(last); len(evs) != 0 {
+		t.Fatalf("fresh event should be pending, got %+v", evs)
+	}
+	if evs = a.Expire(); len(evs) != 0 {
+		t.Fatalf("fresh event should not expire, got %+v", evs)
+	}
+	if evs = a.Flush(); len(evs) != 1 || evs[0].Serial != 2051 {
+		t.Fatalf("pending event should be flushed, got %+v", evs)
+	}
+	if evs = a.Flush(); len(evs) != 0 {
+		t.Fatalf("nothing should be pending after flush, got %+v", evs)
+	}
+}
@@ -0,0 +1,143 @@
+package atomic
+
+import (
+	"encoding/json"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+)
+
+// JSONField returns value from struct or map by its JSON key, fields of embedded structs are searched as well
+// keys of some formats, like zeek id.orig_h, contain dots, so whole key is tried before it is split for nested lookup
+// list elements are selected by numeric index, e.g. vlan.0 or answers.1.rdata
+// addresses are returned as strings, timestamps as time.Time and json.Number as int64 or float64, for uniform rule matching
+func JSONField(v interface{}, key string) (interface{}, bool) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	lookup := func(name string) (reflect.Value, bool) {
+		switch val.Kind() {
+		case reflect.Struct:
+			return structField(val, name)
+		case reflect.Map:
+			if val.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			item := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+			return item, item.IsValid()
+		case reflect.Slice, reflect.Array:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= val.Len() {
+				return reflect.Value{}, false
+			}
+			return val.Index(i), true
+		}
+		return reflect.Value{}, false
+	}
+	if item, ok := lookup(key); ok {
+		return fieldValue(item)
+	}
+	for i := strings.IndexByte(key, '.'); i != -1; {
+		if item, ok := lookup(key[:i]); ok && item.CanInterface() {
+			if out, ok := JSONField(item.Interface(), key[i+1:]); ok {
+				return out, true
+			}
+		}
+		next := strings.IndexByte(key[i+1:], '.')
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+	return nil, false
+}
+
+// structField finds exported field by json tag name, or by field name if tag is missing
+func structField(val reflect.Value, name string) (reflect.Value, bool) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			embedded := val.Field(i)
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if item, ok := structField(embedded, name); ok {
+					return item, true
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if tag == name {
+			return val.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func fieldValue(val reflect.Value) (interface{}, bool) {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	if !val.CanInterface() {
+		return nil, false
+	}
+	switch v := val.Interface().(type) {
+	case fields.StringIP:
+		if v.IP == nil {
+			return nil, false
+		}
+		return v.IP.String(), true
+	case net.IP:
+		return v.String(), true
+	case fields.ZeekTime:
+		return v.Time, true
+	case fields.QuotedRFC3339:
+		return v.Time, true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		if f, err := v.Float64(); err == nil {
+			return f, true
+		}
+		return v.String(), true
+	case []fields.StringIP:
+		out := make([]string, 0, len(v))
+		for _, ip := range v {
+			if ip.IP != nil {
+				out = append(out, ip.IP.String())
+			}
+		}
+		return out, true
+	default:
+		return v, true
+	}
+}
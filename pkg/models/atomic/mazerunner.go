@@ -14,7 +14,7 @@ type MazeRunner struct {
 
 func (m MazeRunner) GetSrcIP() net.IP {
 	if val, ok := m.Cef.Extensions["src"]; ok {
-		if ip, err := fields.ParseStringIP(val); err == nil {
+		if ip := fields.ParseIP(val); ip != nil {
 			return ip
 		}
 	}
@@ -23,7 +23,7 @@ func (m MazeRunner) GetSrcIP() net.IP {
 
 func (m MazeRunner) GetDstIP() net.IP {
 	if val, ok := m.Cef.Extensions["dst"]; ok {
-		if ip, err := fields.ParseStringIP(val); err == nil {
+		if ip := fields.ParseIP(val); ip != nil {
 			return ip
 		}
 	}
@@ -0,0 +1,131 @@
+package atomic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	osquerySource = "osquery"
+	// osqueryCalendarFormat is calendarTime format in osquery results, e.g. Tue Apr 14 10:00:00 2020 UTC
+	osqueryCalendarFormat = "Mon Jan 2 15:04:05 2006 MST"
+)
+
+// Osquery actions
+const (
+	OsqueryAdded    = "added"
+	OsqueryRemoved  = "removed"
+	OsquerySnapshot = "snapshot"
+)
+
+// OsqueryRow is a single result row, values are strings unless osquery is configured to log numerics
+type OsqueryRow map[string]interface{}
+
+// OsqueryDiff is batched differential result, logged when osquery event format is disabled
+type OsqueryDiff struct {
+	Added   []OsqueryRow `json:"added,omitempty"`
+	Removed []OsqueryRow `json:"removed,omitempty"`
+}
+
+// Osquery is scheduled query result from osqueryd results log
+// differential results carry single row in columns, snapshots and batched differentials carry many
+type Osquery struct {
+	Name           string            `json:"name"`
+	HostIdentifier string            `json:"hostIdentifier"`
+	CalendarTime   string            `json:"calendarTime,omitempty"`
+	UnixTime       json.Number       `json:"unixTime,omitempty"`
+	Epoch          json.Number       `json:"epoch,omitempty"`
+	Counter        json.Number       `json:"counter,omitempty"`
+	Numerics       bool              `json:"numerics,omitempty"`
+	Decorations    map[string]string `json:"decorations,omitempty"`
+	Action         string            `json:"action,omitempty"`
+	Columns        OsqueryRow        `json:"columns,omitempty"`
+	Snapshot       []OsqueryRow      `json:"snapshot,omitempty"`
+	DiffResults    *OsqueryDiff      `json:"diffResults,omitempty"`
+}
+
+// NewOsquery decodes result log line, lines that are not query results, such as status logs, are rejected
+func NewOsquery(data []byte) (*Osquery, error) {
+	var obj Osquery
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	if obj.Name == "" || (obj.Columns == nil && obj.Snapshot == nil && obj.DiffResults == nil) {
+		return nil, fmt.Errorf("not an osquery result: %s", string(data))
+	}
+	return &obj, nil
+}
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (o Osquery) Time() time.Time {
+	if sec, err := o.UnixTime.Int64(); err == nil && sec > 0 {
+		return time.Unix(sec, 0).UTC()
+	}
+	if ts, err := time.Parse(osqueryCalendarFormat, o.CalendarTime); err == nil {
+		return ts.UTC()
+	}
+	return time.Time{}
+}
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (o Osquery) Source() string { return osquerySource }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (o Osquery) Sender() string { return o.HostIdentifier }
+
+// OsqueryResult is a result row with action that produced it
+type OsqueryResult struct {
+	Action string
+	Row    OsqueryRow
+}
+
+// Rows returns every result row in log line
+func (o Osquery) Rows() []OsqueryResult {
+	var out []OsqueryResult
+	if o.Columns != nil {
+		out = append(out, OsqueryResult{Action: o.Action, Row: o.Columns})
+	}
+	for _, row := range o.Snapshot {
+		out = append(out, OsqueryResult{Action: OsquerySnapshot, Row: row})
+	}
+	if o.DiffResults != nil {
+		for _, row := range o.DiffResults.Added {
+			out = append(out, OsqueryResult{Action: OsqueryAdded, Row: row})
+		}
+		for _, row := range o.DiffResults.Removed {
+			out = append(out, OsqueryResult{Action: OsqueryRemoved, Row: row})
+		}
+	}
+	return out
+}
+
+// Column returns value of column from first row that has it, as string
+func (o Osquery) Column(key string) (string, bool) {
+	for _, r := range o.Rows() {
+		if val, ok := r.Row.String(key); ok {
+			return val, true
+		}
+	}
+	return "", false
+}
+
+// String returns column value as string, numbers are formatted as osquery would log them without numerics
+func (r OsqueryRow) String(key string) (string, bool) {
+	val, ok := r[key]
+	if !ok || val == nil {
+		return "", false
+	}
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
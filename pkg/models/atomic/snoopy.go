@@ -2,7 +2,6 @@ package atomic
 
 import (
 	"fmt"
-	"net"
 	"strings"
 	"time"
 
@@ -106,9 +105,9 @@ func ParseSnoopy(m string) (*Snoopy, error) {
 				if cuts := strings.Split(f, "("); len(cuts) == 2 {
 					if cuts = strings.Fields(cuts[1]); len(cuts) == 4 {
 						obj.SSH = &SnoopySSH{
-							SrcIP:   &fields.StringIP{IP: net.ParseIP(cuts[0])},
+							SrcIP:   &fields.StringIP{IP: fields.ParseIP(cuts[0])},
 							SrcPort: cuts[1],
-							DstIP:   &fields.StringIP{IP: net.ParseIP(cuts[2])},
+							DstIP:   &fields.StringIP{IP: fields.ParseIP(cuts[2])},
 							DstPort: cuts[3],
 						}
 					}
@@ -1,6 +1,10 @@
 package atomic
 
 import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/ccdcoe/go-peek/pkg/models/fields"
@@ -36,6 +40,135 @@ type StaticSuricataEve struct {
 	Tunnel     map[string]interface{} `json:"tunnel,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	Anomaly    map[string]interface{} `json:"anomaly,omitempty"`
+
+	// Extra holds top-level sections that are not modeled above, such as http2, quic or stats
+	// they are kept as decoded so that newer eve formats pass through without losing data
+	Extra map[string]interface{} `json:"-"`
+}
+
+// suricataEveFields maps top-level keys handled by typed fields of StaticSuricataEve to field index
+// keys are lower case, lookups are folded the same way
+var suricataEveFields = jsonFields(reflect.TypeOf(StaticSuricataEve{}), nil, map[string][]int{})
+
+func jsonFields(t reflect.Type, parent []int, fields map[string][]int) map[string][]int {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		idx := append(append([]int{}, parent...), i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch {
+		case tag == "-":
+		case f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct:
+			jsonFields(f.Type, idx, fields)
+		case tag != "":
+			fields[strings.ToLower(tag)] = idx
+		case f.PkgPath == "":
+			fields[strings.ToLower(f.Name)] = idx
+		}
+	}
+	return fields
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *StaticSuricataEve) UnmarshalJSON(data []byte) error { return s.DecodeJSON(data, nil) }
+
+// DecodeJSON walks eve object once, typed sections are decoded in place and anything else is collected into Extra
+// wrapped holds targets for keys that a wrapping type adds next to eve sections, so those do not end up in Extra
+// keys are matched case-insensitively, like encoding/json does for struct fields
+// numbers in unknown sections are kept as json.Number, so large counters survive re-encoding
+func (s *StaticSuricataEve) DecodeJSON(data []byte, wrapped map[string]interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return &json.UnmarshalTypeError{Value: "non-object", Type: reflect.TypeOf(s).Elem()}
+	}
+	folded := make(map[string]interface{}, len(wrapped))
+	for key, target := range wrapped {
+		folded[strings.ToLower(key)] = target
+	}
+	var obj StaticSuricataEve
+	val := reflect.ValueOf(&obj).Elem()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if target, ok := folded[strings.ToLower(key)]; ok {
+			if err := dec.Decode(target); err != nil {
+				return err
+			}
+			continue
+		}
+		if idx, ok := suricataEveFields[strings.ToLower(key)]; ok {
+			if err := dec.Decode(val.FieldByIndex(idx).Addr().Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var item interface{}
+		sub := json.NewDecoder(bytes.NewReader(raw))
+		sub.UseNumber()
+		if err := sub.Decode(&item); err != nil {
+			return err
+		}
+		if obj.Extra == nil {
+			obj.Extra = make(map[string]interface{})
+		}
+		obj.Extra[key] = item
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	*s = obj
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+// unknown sections from Extra are spliced back next to typed ones
+func (s StaticSuricataEve) MarshalJSON() ([]byte, error) {
+	type alias StaticSuricataEve
+	data, err := json.Marshal(alias(s))
+	if err != nil || len(s.Extra) == 0 {
+		return data, err
+	}
+	extra, err := json.Marshal(s.Extra)
+	if err != nil {
+		return nil, err
+	}
+	return JoinJSONObjects(data, extra), nil
+}
+
+// JoinJSONObjects merges encoded JSON objects into one, keys are not deduplicated
+// inputs that are not objects are skipped
+func JoinJSONObjects(objs ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, obj := range objs {
+		obj = bytes.TrimSpace(obj)
+		if len(obj) < 2 || obj[0] != '{' || obj[len(obj)-1] != '}' {
+			continue
+		}
+		body := bytes.TrimSpace(obj[1 : len(obj)-1])
+		if len(body) == 0 {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(body)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
 }
 
 // Time implements atomic.Event
@@ -78,7 +211,9 @@ type EveBase struct {
 func (e EveBase) GetField(key string) (interface{}, bool) {
 	switch key {
 	case "timestamp":
-		return e.Timestamp.String(), true
+		if e.Timestamp != nil {
+			return e.Timestamp.String(), true
+		}
 	case "host":
 		return e.Host, true
 	case "event_type":
@@ -92,7 +227,7 @@ func (e EveBase) GetField(key string) (interface{}, bool) {
 			return e.SrcIP.IP.String(), true
 		}
 	case "src_port":
-		return e.DestPort, true
+		return e.SrcPort, true
 	case "dest_ip":
 		if e.DestIP != nil {
 			return e.DestIP.IP.String(), true
@@ -148,6 +283,29 @@ func (e EveAlert) GetField(key string) (interface{}, bool) {
 	return nil, false
 }
 
+// GetMetadataStrings returns all string values for a rule metadata key
+// Suricata encodes every metadata key as a list, even if rule only has a single value
+func (e EveAlert) GetMetadataStrings(key string) []string {
+	if e.Metadata == nil {
+		return nil
+	}
+	switch val := e.Metadata[key].(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
 type NetInfo struct {
 	Src  []string `json:"src,omitempty"`
 	Dest []string `json:"dest,omitempty"`
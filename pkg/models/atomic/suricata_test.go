@@ -0,0 +1,71 @@
+package atomic
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const suricataEveRaw = `{"timestamp":"2020-04-14T10:00:00.123456+0000","flow_id":1234,"event_type":"http2","src_ip":"10.0.0.5","src_port":51000,"dest_ip":"192.0.2.10","dest_port":443,"proto":"TCP","http":{"hostname":"example.com","status":200},"http2":{"stream_id":18446744073709551615,"request":{"settings":[]}}}`
+
+func TestSuricataUnmarshal(t *testing.T) {
+	var e StaticSuricataEve
+	if err := json.Unmarshal([]byte(suricataEveRaw), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.FlowID != 1234 || e.DestPort != 443 || e.SrcIP == nil || e.SrcIP.String() != "10.0.0.5" {
+		t.Fatalf("typed fields not decoded: %+v", e.EveBase)
+	}
+	if e.HTTP["status"] != float64(200) {
+		t.Fatalf("typed section got %v", e.HTTP)
+	}
+	if len(e.Extra) != 1 {
+		t.Fatalf("want only http2 in extra, got %v", e.Extra)
+	}
+	http2, ok := e.Extra["http2"].(map[string]interface{})
+	if !ok || http2["stream_id"] != json.Number("18446744073709551615") {
+		t.Fatalf("unknown section got %v", e.Extra["http2"])
+	}
+	if err := json.Unmarshal([]byte(`{"Flow_ID":7,"Event_Type":"alert","QUIC":{}}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.FlowID != 7 || e.EventType != "alert" || e.Extra["QUIC"] == nil {
+		t.Fatalf("keys should match typed fields case-insensitively: %+v %v", e.EveBase, e.Extra)
+	}
+	if err := json.Unmarshal([]byte(`[]`), &e); err == nil {
+		t.Fatal("array should not decode into eve")
+	}
+}
+
+func BenchmarkSuricataUnmarshal(b *testing.B) {
+	data := []byte(suricataEveRaw)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var e StaticSuricataEve
+		if err := json.Unmarshal(data, &e); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGetMetadataStrings(t *testing.T) {
+	var e EveAlert
+	if err := json.Unmarshal([]byte(`{"metadata":{"mitre_technique_id":["T1059","","T1059.001"],"mitre_tactic_id":["TA0002"],"updated_at":[1]}}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.GetMetadataStrings("mitre_technique_id"); len(got) != 2 || got[0] != "T1059" || got[1] != "T1059.001" {
+		t.Fatalf("list values got %v", got)
+	}
+	if got := e.GetMetadataStrings("updated_at"); len(got) != 0 {
+		t.Fatalf("non-string values should be skipped, got %v", got)
+	}
+	if got := e.GetMetadataStrings("missing"); got != nil {
+		t.Fatalf("missing key got %v", got)
+	}
+	e.Metadata["single"] = "value"
+	if got := e.GetMetadataStrings("single"); len(got) != 1 || got[0] != "value" {
+		t.Fatalf("plain string got %v", got)
+	}
+	if got := (EveAlert{}).GetMetadataStrings("single"); got != nil {
+		t.Fatalf("alert without metadata got %v", got)
+	}
+}
@@ -0,0 +1,471 @@
+package atomic
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+)
+
+// Sysmon event IDs that have typed models
+const (
+	SysmonProcessCreateID      = 1
+	SysmonNetworkConnectID     = 3
+	SysmonProcessTerminateID   = 5
+	SysmonImageLoadID          = 7
+	SysmonCreateRemoteThreadID = 8
+	SysmonProcessAccessID      = 10
+	SysmonFileCreateID         = 11
+	SysmonRegistryAddDeleteID  = 12
+	SysmonRegistrySetID        = 13
+	SysmonRegistryRenameID     = 14
+	SysmonFileCreateStreamID   = 15
+	SysmonDNSQueryID           = 22
+	SysmonFileDeleteID         = 23
+)
+
+const (
+	sysmonTimeFormat            = "2006-01-02 15:04:05.999"
+	sysmonProvider              = "Microsoft-Windows-Sysmon"
+	sysmonHashSeparator         = ","
+	sysmonQueryResultsSeparator = ";"
+)
+
+// IsSysmonProvider reports if windows event provider or channel belongs to sysmon
+func IsSysmonProvider(provider string) bool {
+	return strings.HasPrefix(provider, sysmonProvider)
+}
+
+// Hashes is parsed sysmon Hashes field
+type Hashes struct {
+	MD5     string `json:"md5,omitempty"`
+	SHA1    string `json:"sha1,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	IMPHASH string `json:"imphash,omitempty"`
+}
+
+// ParseHashes parses sysmon hash list, e.g. SHA1=...,MD5=...,SHA256=...,IMPHASH=...
+// algorithms that are not configured in sysmon are left empty
+func ParseHashes(raw string) Hashes {
+	var h Hashes
+	for _, item := range strings.Split(raw, sysmonHashSeparator) {
+		bits := strings.SplitN(item, "=", 2)
+		if len(bits) != 2 {
+			continue
+		}
+		val := strings.ToLower(strings.TrimSpace(bits[1]))
+		switch strings.ToUpper(strings.TrimSpace(bits[0])) {
+		case "MD5":
+			h.MD5 = val
+		case "SHA1":
+			h.SHA1 = val
+		case "SHA256":
+			h.SHA256 = val
+		case "IMPHASH":
+			h.IMPHASH = val
+		}
+	}
+	return h
+}
+
+// SysmonEvent is a typed sysmon event
+type SysmonEvent interface {
+	// Base returns fields common to all event IDs
+	Base() *SysmonBase
+	// Fields returns most descriptive values of event, for quick filtering
+	Fields() []string
+}
+
+// SysmonBase holds fields that most sysmon events share
+// for process access and remote thread events, process is the source process
+type SysmonBase struct {
+	EventID     int       `json:"EventID"`
+	RuleName    string    `json:"RuleName,omitempty"`
+	UtcTime     time.Time `json:"UtcTime"`
+	ProcessGuid string    `json:"ProcessGuid,omitempty"`
+	ProcessID   int       `json:"ProcessId,omitempty"`
+	Image       string    `json:"Image,omitempty"`
+	User        string    `json:"User,omitempty"`
+}
+
+// Base implements SysmonEvent
+func (s *SysmonBase) Base() *SysmonBase { return s }
+
+// SysmonProcessCreate is event 1
+type SysmonProcessCreate struct {
+	SysmonBase
+	FileVersion       string `json:"FileVersion,omitempty"`
+	Description       string `json:"Description,omitempty"`
+	Product           string `json:"Product,omitempty"`
+	Company           string `json:"Company,omitempty"`
+	OriginalFileName  string `json:"OriginalFileName,omitempty"`
+	CommandLine       string `json:"CommandLine,omitempty"`
+	CurrentDirectory  string `json:"CurrentDirectory,omitempty"`
+	LogonGuid         string `json:"LogonGuid,omitempty"`
+	LogonID           string `json:"LogonId,omitempty"`
+	TerminalSessionID int    `json:"TerminalSessionId,omitempty"`
+	IntegrityLevel    string `json:"IntegrityLevel,omitempty"`
+	Hashes            Hashes `json:"Hashes"`
+	ParentProcessGuid string `json:"ParentProcessGuid,omitempty"`
+	ParentProcessID   int    `json:"ParentProcessId,omitempty"`
+	ParentImage       string `json:"ParentImage,omitempty"`
+	ParentCommandLine string `json:"ParentCommandLine,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonProcessCreate) Fields() []string {
+	return nonEmpty(s.Image, s.CommandLine, s.ParentImage, s.User)
+}
+
+// SysmonNetworkConnect is event 3
+type SysmonNetworkConnect struct {
+	SysmonBase
+	Protocol            string `json:"Protocol,omitempty"`
+	Initiated           bool   `json:"Initiated"`
+	SourceIP            net.IP `json:"SourceIp,omitempty"`
+	SourceHostname      string `json:"SourceHostname,omitempty"`
+	SourcePort          int    `json:"SourcePort,omitempty"`
+	SourcePortName      string `json:"SourcePortName,omitempty"`
+	DestinationIP       net.IP `json:"DestinationIp,omitempty"`
+	DestinationHostname string `json:"DestinationHostname,omitempty"`
+	DestinationPort     int    `json:"DestinationPort,omitempty"`
+	DestinationPortName string `json:"DestinationPortName,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonNetworkConnect) Fields() []string {
+	dest := ""
+	if s.DestinationIP != nil {
+		dest = net.JoinHostPort(s.DestinationIP.String(), strconv.Itoa(s.DestinationPort))
+	}
+	return nonEmpty(s.Image, s.Protocol, dest, s.DestinationHostname)
+}
+
+// SysmonProcessTerminate is event 5
+type SysmonProcessTerminate struct {
+	SysmonBase
+}
+
+// Fields implements SysmonEvent
+func (s SysmonProcessTerminate) Fields() []string { return nonEmpty(s.Image) }
+
+// SysmonImageLoad is event 7
+type SysmonImageLoad struct {
+	SysmonBase
+	ImageLoaded      string `json:"ImageLoaded,omitempty"`
+	FileVersion      string `json:"FileVersion,omitempty"`
+	Description      string `json:"Description,omitempty"`
+	Product          string `json:"Product,omitempty"`
+	Company          string `json:"Company,omitempty"`
+	OriginalFileName string `json:"OriginalFileName,omitempty"`
+	Hashes           Hashes `json:"Hashes"`
+	Signed           bool   `json:"Signed"`
+	Signature        string `json:"Signature,omitempty"`
+	SignatureStatus  string `json:"SignatureStatus,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonImageLoad) Fields() []string {
+	return nonEmpty(s.Image, s.ImageLoaded, s.Signature)
+}
+
+// SysmonCreateRemoteThread is event 8
+type SysmonCreateRemoteThread struct {
+	SysmonBase
+	TargetProcessGuid string `json:"TargetProcessGuid,omitempty"`
+	TargetProcessID   int    `json:"TargetProcessId,omitempty"`
+	TargetImage       string `json:"TargetImage,omitempty"`
+	NewThreadID       int    `json:"NewThreadId,omitempty"`
+	StartAddress      string `json:"StartAddress,omitempty"`
+	StartModule       string `json:"StartModule,omitempty"`
+	StartFunction     string `json:"StartFunction,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonCreateRemoteThread) Fields() []string {
+	return nonEmpty(s.Image, s.TargetImage, s.StartModule, s.StartFunction)
+}
+
+// SysmonProcessAccess is event 10
+type SysmonProcessAccess struct {
+	SysmonBase
+	SourceThreadID    int    `json:"SourceThreadId,omitempty"`
+	TargetProcessGuid string `json:"TargetProcessGUID,omitempty"`
+	TargetProcessID   int    `json:"TargetProcessId,omitempty"`
+	TargetImage       string `json:"TargetImage,omitempty"`
+	GrantedAccess     string `json:"GrantedAccess,omitempty"`
+	CallTrace         string `json:"CallTrace,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonProcessAccess) Fields() []string {
+	return nonEmpty(s.Image, s.TargetImage, s.GrantedAccess)
+}
+
+// SysmonFileCreate is event 11
+type SysmonFileCreate struct {
+	SysmonBase
+	TargetFilename  string    `json:"TargetFilename,omitempty"`
+	CreationUtcTime time.Time `json:"CreationUtcTime"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonFileCreate) Fields() []string { return nonEmpty(s.Image, s.TargetFilename) }
+
+// SysmonRegistry covers events 12, 13 and 14, details are only set for value set and new name only for rename
+type SysmonRegistry struct {
+	SysmonBase
+	EventType    string `json:"EventType,omitempty"`
+	TargetObject string `json:"TargetObject,omitempty"`
+	Details      string `json:"Details,omitempty"`
+	NewName      string `json:"NewName,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonRegistry) Fields() []string {
+	return nonEmpty(s.EventType, s.Image, s.TargetObject, s.Details, s.NewName)
+}
+
+// SysmonFileCreateStream is event 15
+type SysmonFileCreateStream struct {
+	SysmonBase
+	TargetFilename  string    `json:"TargetFilename,omitempty"`
+	CreationUtcTime time.Time `json:"CreationUtcTime"`
+	Hashes          Hashes    `json:"Hash"`
+	Contents        string    `json:"Contents,omitempty"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonFileCreateStream) Fields() []string {
+	return nonEmpty(s.Image, s.TargetFilename, s.Hashes.SHA256)
+}
+
+// SysmonDNSQuery is event 22
+type SysmonDNSQuery struct {
+	SysmonBase
+	QueryName    string `json:"QueryName,omitempty"`
+	QueryStatus  string `json:"QueryStatus,omitempty"`
+	QueryResults string `json:"QueryResults,omitempty"`
+}
+
+// Addresses returns resolved addresses from query results
+// results are separated by semicolons and addresses are logged in IPv4-mapped notation, e.g. ::ffff:192.0.2.10;
+// other records are logged as type: name, e.g. type:  5 example.com
+func (s SysmonDNSQuery) Addresses() []net.IP {
+	out := make([]net.IP, 0)
+	for _, item := range strings.Split(s.QueryResults, sysmonQueryResultsSeparator) {
+		if ip := fields.ParseIP(item); ip != nil {
+			out = append(out, ip)
+		}
+	}
+	return out
+}
+
+// Fields implements SysmonEvent
+func (s SysmonDNSQuery) Fields() []string {
+	return nonEmpty(s.Image, s.QueryName, s.QueryResults)
+}
+
+// SysmonFileDelete is event 23
+type SysmonFileDelete struct {
+	SysmonBase
+	TargetFilename string `json:"TargetFilename,omitempty"`
+	Hashes         Hashes `json:"Hashes"`
+	IsExecutable   bool   `json:"IsExecutable"`
+	Archived       bool   `json:"Archived"`
+}
+
+// Fields implements SysmonEvent
+func (s SysmonFileDelete) Fields() []string {
+	return nonEmpty(s.Image, s.TargetFilename, s.User)
+}
+
+// NewSysmonEvent builds typed event from event data, nil is returned for event IDs without model
+// values are strings in winlogbeat and may be numbers in other shippers, so both are accepted
+func NewSysmonEvent(id int, data map[string]interface{}) SysmonEvent {
+	d := sysmonData(data)
+	base := SysmonBase{
+		EventID:     id,
+		RuleName:    d.str("RuleName"),
+		UtcTime:     d.time("UtcTime"),
+		ProcessGuid: d.str("ProcessGuid"),
+		ProcessID:   d.num("ProcessId"),
+		Image:       d.str("Image"),
+		User:        d.str("User"),
+	}
+	switch id {
+	case SysmonProcessCreateID:
+		return &SysmonProcessCreate{
+			SysmonBase:        base,
+			FileVersion:       d.str("FileVersion"),
+			Description:       d.str("Description"),
+			Product:           d.str("Product"),
+			Company:           d.str("Company"),
+			OriginalFileName:  d.str("OriginalFileName"),
+			CommandLine:       d.str("CommandLine"),
+			CurrentDirectory:  d.str("CurrentDirectory"),
+			LogonGuid:         d.str("LogonGuid"),
+			LogonID:           d.str("LogonId"),
+			TerminalSessionID: d.num("TerminalSessionId"),
+			IntegrityLevel:    d.str("IntegrityLevel"),
+			Hashes:            ParseHashes(d.str("Hashes")),
+			ParentProcessGuid: d.str("ParentProcessGuid"),
+			ParentProcessID:   d.num("ParentProcessId"),
+			ParentImage:       d.str("ParentImage"),
+			ParentCommandLine: d.str("ParentCommandLine"),
+		}
+	case SysmonNetworkConnectID:
+		return &SysmonNetworkConnect{
+			SysmonBase:          base,
+			Protocol:            d.str("Protocol"),
+			Initiated:           d.boolean("Initiated"),
+			SourceIP:            fields.ParseIP(d.str("SourceIp")),
+			SourceHostname:      d.str("SourceHostname"),
+			SourcePort:          d.num("SourcePort"),
+			SourcePortName:      d.str("SourcePortName"),
+			DestinationIP:       fields.ParseIP(d.str("DestinationIp")),
+			DestinationHostname: d.str("DestinationHostname"),
+			DestinationPort:     d.num("DestinationPort"),
+			DestinationPortName: d.str("DestinationPortName"),
+		}
+	case SysmonProcessTerminateID:
+		return &SysmonProcessTerminate{SysmonBase: base}
+	case SysmonImageLoadID:
+		return &SysmonImageLoad{
+			SysmonBase:       base,
+			ImageLoaded:      d.str("ImageLoaded"),
+			FileVersion:      d.str("FileVersion"),
+			Description:      d.str("Description"),
+			Product:          d.str("Product"),
+			Company:          d.str("Company"),
+			OriginalFileName: d.str("OriginalFileName"),
+			Hashes:           ParseHashes(d.str("Hashes")),
+			Signed:           d.boolean("Signed"),
+			Signature:        d.str("Signature"),
+			SignatureStatus:  d.str("SignatureStatus"),
+		}
+	case SysmonCreateRemoteThreadID:
+		base.ProcessGuid, base.ProcessID, base.Image = d.str("SourceProcessGuid"), d.num("SourceProcessId"), d.str("SourceImage")
+		return &SysmonCreateRemoteThread{
+			SysmonBase:        base,
+			TargetProcessGuid: d.str("TargetProcessGuid"),
+			TargetProcessID:   d.num("TargetProcessId"),
+			TargetImage:       d.str("TargetImage"),
+			NewThreadID:       d.num("NewThreadId"),
+			StartAddress:      d.str("StartAddress"),
+			StartModule:       d.str("StartModule"),
+			StartFunction:     d.str("StartFunction"),
+		}
+	case SysmonProcessAccessID:
+		base.ProcessGuid, base.ProcessID, base.Image = d.str("SourceProcessGUID"), d.num("SourceProcessId"), d.str("SourceImage")
+		return &SysmonProcessAccess{
+			SysmonBase:        base,
+			SourceThreadID:    d.num("SourceThreadId"),
+			TargetProcessGuid: d.str("TargetProcessGUID"),
+			TargetProcessID:   d.num("TargetProcessId"),
+			TargetImage:       d.str("TargetImage"),
+			GrantedAccess:     d.str("GrantedAccess"),
+			CallTrace:         d.str("CallTrace"),
+		}
+	case SysmonFileCreateID:
+		return &SysmonFileCreate{
+			SysmonBase:      base,
+			TargetFilename:  d.str("TargetFilename"),
+			CreationUtcTime: d.time("CreationUtcTime"),
+		}
+	case SysmonRegistryAddDeleteID, SysmonRegistrySetID, SysmonRegistryRenameID:
+		return &SysmonRegistry{
+			SysmonBase:   base,
+			EventType:    d.str("EventType"),
+			TargetObject: d.str("TargetObject"),
+			Details:      d.str("Details"),
+			NewName:      d.str("NewName"),
+		}
+	case SysmonFileCreateStreamID:
+		return &SysmonFileCreateStream{
+			SysmonBase:      base,
+			TargetFilename:  d.str("TargetFilename"),
+			CreationUtcTime: d.time("CreationUtcTime"),
+			Hashes:          ParseHashes(d.str("Hash")),
+			Contents:        d.str("Contents"),
+		}
+	case SysmonDNSQueryID:
+		return &SysmonDNSQuery{
+			SysmonBase:   base,
+			QueryName:    d.str("QueryName"),
+			QueryStatus:  d.str("QueryStatus"),
+			QueryResults: d.str("QueryResults"),
+		}
+	case SysmonFileDeleteID:
+		return &SysmonFileDelete{
+			SysmonBase:     base,
+			TargetFilename: d.str("TargetFilename"),
+			Hashes:         ParseHashes(d.str("Hashes")),
+			IsExecutable:   d.boolean("IsExecutable"),
+			Archived:       d.boolean("Archived"),
+		}
+	}
+	return nil
+}
+
+// sysmonData is event data map with lenient typed getters
+type sysmonData map[string]interface{}
+
+func (d sysmonData) str(key string) string {
+	switch v := d[key].(type) {
+	case string:
+		if v == "-" {
+			return ""
+		}
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (d sysmonData) num(key string) int {
+	switch v := d[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		num, _ := strconv.Atoi(strings.TrimSpace(v))
+		return num
+	}
+	return 0
+}
+
+func (d sysmonData) boolean(key string) bool {
+	switch v := d[key].(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(v))
+		return b
+	}
+	return false
+}
+
+func (d sysmonData) time(key string) time.Time {
+	ts, err := time.Parse(sysmonTimeFormat, d.str(key))
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
+
+func nonEmpty(items ...string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" && item != "-" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package atomic
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestParseSysmon(t *testing.T) {
+	raw := `{"@timestamp":"2020-04-14T10:00:00Z","winlog":{"channel":"Microsoft-Windows-Sysmon/Operational","provider_name":"Microsoft-Windows-Sysmon","computer_name":"ws01","event_id":%s,"event_data":%s}}`
+	parse := func(id, data string) SysmonEvent {
+		var obj DynamicWinlogbeat
+		if err := json.Unmarshal([]byte(fmt.Sprintf(raw, id, data)), &obj); err != nil {
+			t.Fatal(err)
+		}
+		return obj.ParseSysmon()
+	}
+
+	proc, ok := parse("1", `{"UtcTime":"2020-04-14 10:00:00.123","ProcessGuid":"{a}","ProcessId":"4242","Image":"C:\\Windows\\System32\\cmd.exe","CommandLine":"cmd.exe /c whoami","Hashes":"SHA1=AA,MD5=BB,SHA256=CC,IMPHASH=DD","ParentProcessGuid":"{b}","ParentImage":"C:\\Windows\\explorer.exe"}`).(*SysmonProcessCreate)
+	if !ok {
+		t.Fatal("event 1 should be process create")
+	}
+	if proc.ProcessID != 4242 || proc.ParentProcessGuid != "{b}" || proc.UtcTime.Nanosecond() != 123000000 {
+		t.Fatalf("bad process create %+v", proc)
+	}
+	if proc.Hashes != (Hashes{MD5: "bb", SHA1: "aa", SHA256: "cc", IMPHASH: "dd"}) {
+		t.Fatalf("bad hashes %+v", proc.Hashes)
+	}
+
+	conn, ok := parse(`"3"`, `{"Protocol":"tcp","Initiated":"true","SourceIp":"10.0.0.5","SourcePort":"51000","DestinationIp":"::ffff:192.0.2.10","DestinationPort":"443"}`).(*SysmonNetworkConnect)
+	if !ok {
+		t.Fatal("event 3 should be network connect")
+	}
+	if !conn.Initiated || conn.DestinationIP.String() != "192.0.2.10" || conn.SourcePort != 51000 {
+		t.Fatalf("bad network connect %+v", conn)
+	}
+
+	query, ok := parse("22", `{"QueryName":"example.com","QueryStatus":"0","QueryResults":"type:  5 example.net;::ffff:192.0.2.10;::ffff:192.0.2.11;"}`).(*SysmonDNSQuery)
+	if !ok {
+		t.Fatal("event 22 should be dns query")
+	}
+	if addrs := query.Addresses(); len(addrs) != 2 || addrs[0].String() != "192.0.2.10" {
+		t.Fatalf("bad query results %v", addrs)
+	}
+
+	if parse("255", `{}`) != nil {
+		t.Fatal("event without model should not be parsed")
+	}
+}
@@ -3,6 +3,7 @@ package atomic
 import (
 	"encoding/json"
 	"net"
+	"strconv"
 	"time"
 )
 
@@ -165,3 +166,28 @@ func (e DynamicEventLog) Sender() string {
 	}
 	return ""
 }
+
+// ParseSysmon builds typed model from sysmon event, nil is returned for other providers and unsupported event IDs
+func (d DynamicWinlogbeat) ParseSysmon() SysmonEvent {
+	w := d.GetWinlog()
+	if w == nil {
+		return nil
+	}
+	provider, _ := w["provider_name"].(string)
+	channel, _ := w["channel"].(string)
+	if !IsSysmonProvider(provider) && !IsSysmonProvider(channel) {
+		return nil
+	}
+	data, ok := w["event_data"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var id int
+	switch v := w["event_id"].(type) {
+	case float64:
+		id = int(v)
+	case string:
+		id, _ = strconv.Atoi(v)
+	}
+	return NewSysmonEvent(id, data)
+}
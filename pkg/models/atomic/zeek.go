@@ -1,7 +1,12 @@
 package atomic
 
 import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/ccdcoe/go-peek/pkg/models/fields"
 )
@@ -40,3 +45,376 @@ func (z ZeekCobalt) Source() string { return z.IDOrigH.String() }
 // Sender implements atomic.Event
 // Sender of message, usually a host
 func (z ZeekCobalt) Sender() string { return "cobalt" }
+
+// ZeekLog is a record from one of supported zeek logs
+type ZeekLog interface {
+	Event
+	// Base returns fields common to all logs
+	Base() *ZeekBase
+}
+
+// ZeekBase holds fields that are common to zeek logs
+// connection id is missing from logs that are not bound to a single connection, such as x509
+type ZeekBase struct {
+	Timestamp  fields.ZeekTime  `json:"ts"`
+	Path       string           `json:"_path,omitempty"`
+	SystemName string           `json:"_system_name,omitempty"`
+	UID        string           `json:"uid,omitempty"`
+	IDOrigH    *fields.StringIP `json:"id.orig_h,omitempty"`
+	IDOrigP    int              `json:"id.orig_p,omitempty"`
+	IDRespH    *fields.StringIP `json:"id.resp_h,omitempty"`
+	IDRespP    int              `json:"id.resp_p,omitempty"`
+}
+
+// Base implements ZeekLog
+func (z *ZeekBase) Base() *ZeekBase { return z }
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (z ZeekBase) Time() time.Time { return z.Timestamp.Time }
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (z ZeekBase) Source() string { return z.Path }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (z ZeekBase) Sender() string { return z.SystemName }
+
+// ZeekConn is a conn.log record
+type ZeekConn struct {
+	ZeekBase
+	Proto         string   `json:"proto,omitempty"`
+	Service       string   `json:"service,omitempty"`
+	Duration      float64  `json:"duration,omitempty"`
+	OrigBytes     int64    `json:"orig_bytes,omitempty"`
+	RespBytes     int64    `json:"resp_bytes,omitempty"`
+	ConnState     string   `json:"conn_state,omitempty"`
+	LocalOrig     *bool    `json:"local_orig,omitempty"`
+	LocalResp     *bool    `json:"local_resp,omitempty"`
+	MissedBytes   int64    `json:"missed_bytes,omitempty"`
+	History       string   `json:"history,omitempty"`
+	OrigPkts      int64    `json:"orig_pkts,omitempty"`
+	OrigIPBytes   int64    `json:"orig_ip_bytes,omitempty"`
+	RespPkts      int64    `json:"resp_pkts,omitempty"`
+	RespIPBytes   int64    `json:"resp_ip_bytes,omitempty"`
+	TunnelParents []string `json:"tunnel_parents,omitempty"`
+	OrigL2Addr    string   `json:"orig_l2_addr,omitempty"`
+	RespL2Addr    string   `json:"resp_l2_addr,omitempty"`
+	CommunityID   string   `json:"community_id,omitempty"`
+}
+
+// ZeekDNS is a dns.log record
+type ZeekDNS struct {
+	ZeekBase
+	Proto      string    `json:"proto,omitempty"`
+	TransID    int       `json:"trans_id,omitempty"`
+	RTT        float64   `json:"rtt,omitempty"`
+	Query      string    `json:"query,omitempty"`
+	QClass     int       `json:"qclass,omitempty"`
+	QClassName string    `json:"qclass_name,omitempty"`
+	QType      int       `json:"qtype,omitempty"`
+	QTypeName  string    `json:"qtype_name,omitempty"`
+	RCode      *int      `json:"rcode,omitempty"`
+	RCodeName  string    `json:"rcode_name,omitempty"`
+	AA         bool      `json:"AA"`
+	TC         bool      `json:"TC"`
+	RD         bool      `json:"RD"`
+	RA         bool      `json:"RA"`
+	Z          int       `json:"Z"`
+	Answers    []string  `json:"answers,omitempty"`
+	TTLs       []float64 `json:"TTLs,omitempty"`
+	Rejected   bool      `json:"rejected"`
+}
+
+// ZeekHTTP is a http.log record
+type ZeekHTTP struct {
+	ZeekBase
+	TransDepth      int      `json:"trans_depth,omitempty"`
+	Method          string   `json:"method,omitempty"`
+	Host            string   `json:"host,omitempty"`
+	URI             string   `json:"uri,omitempty"`
+	Referrer        string   `json:"referrer,omitempty"`
+	Version         string   `json:"version,omitempty"`
+	UserAgent       string   `json:"user_agent,omitempty"`
+	Origin          string   `json:"origin,omitempty"`
+	RequestBodyLen  int64    `json:"request_body_len"`
+	ResponseBodyLen int64    `json:"response_body_len"`
+	StatusCode      int      `json:"status_code,omitempty"`
+	StatusMsg       string   `json:"status_msg,omitempty"`
+	InfoCode        int      `json:"info_code,omitempty"`
+	InfoMsg         string   `json:"info_msg,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Username        string   `json:"username,omitempty"`
+	Password        string   `json:"password,omitempty"`
+	Proxied         []string `json:"proxied,omitempty"`
+	OrigFUIDs       []string `json:"orig_fuids,omitempty"`
+	OrigFilenames   []string `json:"orig_filenames,omitempty"`
+	OrigMimeTypes   []string `json:"orig_mime_types,omitempty"`
+	RespFUIDs       []string `json:"resp_fuids,omitempty"`
+	RespFilenames   []string `json:"resp_filenames,omitempty"`
+	RespMimeTypes   []string `json:"resp_mime_types,omitempty"`
+}
+
+// ZeekSSL is a ssl.log record, ja3 fields are filled by salesforce ja3 package
+type ZeekSSL struct {
+	ZeekBase
+	Version            string   `json:"version,omitempty"`
+	Cipher             string   `json:"cipher,omitempty"`
+	Curve              string   `json:"curve,omitempty"`
+	ServerName         string   `json:"server_name,omitempty"`
+	Resumed            bool     `json:"resumed"`
+	LastAlert          string   `json:"last_alert,omitempty"`
+	NextProtocol       string   `json:"next_protocol,omitempty"`
+	Established        bool     `json:"established"`
+	SSLHistory         string   `json:"ssl_history,omitempty"`
+	CertChainFps       []string `json:"cert_chain_fps,omitempty"`
+	ClientCertChainFps []string `json:"client_cert_chain_fps,omitempty"`
+	CertChainFUIDs     []string `json:"cert_chain_fuids,omitempty"`
+	Subject            string   `json:"subject,omitempty"`
+	Issuer             string   `json:"issuer,omitempty"`
+	ClientSubject      string   `json:"client_subject,omitempty"`
+	ClientIssuer       string   `json:"client_issuer,omitempty"`
+	SNIMatchesCert     *bool    `json:"sni_matches_cert,omitempty"`
+	ValidationStatus   string   `json:"validation_status,omitempty"`
+	JA3                string   `json:"ja3,omitempty"`
+	JA3S               string   `json:"ja3s,omitempty"`
+}
+
+// ZeekX509 is a x509.log record, certificate is identified by fingerprint since zeek 4.2 and by file id before that
+type ZeekX509 struct {
+	ZeekBase
+	Fingerprint          string            `json:"fingerprint,omitempty"`
+	ID                   string            `json:"id,omitempty"`
+	CertificateVersion   int               `json:"certificate.version,omitempty"`
+	CertificateSerial    string            `json:"certificate.serial,omitempty"`
+	CertificateSubject   string            `json:"certificate.subject,omitempty"`
+	CertificateIssuer    string            `json:"certificate.issuer,omitempty"`
+	CertificateNotBefore *fields.ZeekTime  `json:"certificate.not_valid_before,omitempty"`
+	CertificateNotAfter  *fields.ZeekTime  `json:"certificate.not_valid_after,omitempty"`
+	CertificateKeyAlg    string            `json:"certificate.key_alg,omitempty"`
+	CertificateSigAlg    string            `json:"certificate.sig_alg,omitempty"`
+	CertificateKeyType   string            `json:"certificate.key_type,omitempty"`
+	CertificateKeyLength int               `json:"certificate.key_length,omitempty"`
+	CertificateExponent  string            `json:"certificate.exponent,omitempty"`
+	CertificateCurve     string            `json:"certificate.curve,omitempty"`
+	SANDNS               []string          `json:"san.dns,omitempty"`
+	SANURI               []string          `json:"san.uri,omitempty"`
+	SANEmail             []string          `json:"san.email,omitempty"`
+	SANIP                []fields.StringIP `json:"san.ip,omitempty"`
+	BasicConstraintsCA   *bool             `json:"basic_constraints.ca,omitempty"`
+	BasicConstraintsPath *int              `json:"basic_constraints.path_len,omitempty"`
+	HostCert             bool              `json:"host_cert"`
+	ClientCert           bool              `json:"client_cert"`
+}
+
+// ZeekFiles is a files.log record
+// transfer hosts are only logged by zeek versions that predate connection id in files.log
+type ZeekFiles struct {
+	ZeekBase
+	FUID          string            `json:"fuid,omitempty"`
+	TxHosts       []fields.StringIP `json:"tx_hosts,omitempty"`
+	RxHosts       []fields.StringIP `json:"rx_hosts,omitempty"`
+	ConnUIDs      []string          `json:"conn_uids,omitempty"`
+	Src           string            `json:"source,omitempty"`
+	Depth         int               `json:"depth"`
+	Analyzers     []string          `json:"analyzers,omitempty"`
+	MimeType      string            `json:"mime_type,omitempty"`
+	Filename      string            `json:"filename,omitempty"`
+	Duration      float64           `json:"duration,omitempty"`
+	LocalOrig     *bool             `json:"local_orig,omitempty"`
+	IsOrig        *bool             `json:"is_orig,omitempty"`
+	SeenBytes     int64             `json:"seen_bytes"`
+	TotalBytes    int64             `json:"total_bytes,omitempty"`
+	MissingBytes  int64             `json:"missing_bytes"`
+	OverflowBytes int64             `json:"overflow_bytes"`
+	Timedout      bool              `json:"timedout"`
+	ParentFUID    string            `json:"parent_fuid,omitempty"`
+	MD5           string            `json:"md5,omitempty"`
+	SHA1          string            `json:"sha1,omitempty"`
+	SHA256        string            `json:"sha256,omitempty"`
+	Extracted     string            `json:"extracted,omitempty"`
+	ExtractedCut  *bool             `json:"extracted_cutoff,omitempty"`
+	ExtractedSize int64             `json:"extracted_size,omitempty"`
+}
+
+// ZeekNotice is a notice.log record
+type ZeekNotice struct {
+	ZeekBase
+	FUID         string           `json:"fuid,omitempty"`
+	FileMimeType string           `json:"file_mime_type,omitempty"`
+	FileDesc     string           `json:"file_desc,omitempty"`
+	Proto        string           `json:"proto,omitempty"`
+	Note         string           `json:"note,omitempty"`
+	Msg          string           `json:"msg,omitempty"`
+	Sub          string           `json:"sub,omitempty"`
+	Src          *fields.StringIP `json:"src,omitempty"`
+	Dst          *fields.StringIP `json:"dst,omitempty"`
+	P            int              `json:"p,omitempty"`
+	N            int              `json:"n,omitempty"`
+	PeerDescr    string           `json:"peer_descr,omitempty"`
+	Actions      []string         `json:"actions,omitempty"`
+	EmailDest    []string         `json:"email_dest,omitempty"`
+	SuppressFor  float64          `json:"suppress_for,omitempty"`
+	Dropped      bool             `json:"dropped"`
+}
+
+// ZeekWeird is a weird.log record
+type ZeekWeird struct {
+	ZeekBase
+	Name   string `json:"name,omitempty"`
+	Addl   string `json:"addl,omitempty"`
+	Notice bool   `json:"notice"`
+	Peer   string `json:"peer,omitempty"`
+	Src    string `json:"source,omitempty"`
+}
+
+// ZeekSSH is a ssh.log record
+type ZeekSSH struct {
+	ZeekBase
+	Version        int    `json:"version,omitempty"`
+	AuthSuccess    *bool  `json:"auth_success,omitempty"`
+	AuthAttempts   int    `json:"auth_attempts"`
+	Direction      string `json:"direction,omitempty"`
+	Client         string `json:"client,omitempty"`
+	Server         string `json:"server,omitempty"`
+	CipherAlg      string `json:"cipher_alg,omitempty"`
+	MacAlg         string `json:"mac_alg,omitempty"`
+	CompressionAlg string `json:"compression_alg,omitempty"`
+	KexAlg         string `json:"kex_alg,omitempty"`
+	HostKeyAlg     string `json:"host_key_alg,omitempty"`
+	HostKey        string `json:"host_key,omitempty"`
+	HasshVersion   string `json:"hasshVersion,omitempty"`
+	Hassh          string `json:"hassh,omitempty"`
+	HasshServer    string `json:"hasshServer,omitempty"`
+}
+
+// ZeekSMBFiles is a smb_files.log record
+type ZeekSMBFiles struct {
+	ZeekBase
+	FUID     string           `json:"fuid,omitempty"`
+	Action   string           `json:"action,omitempty"`
+	Name     string           `json:"name,omitempty"`
+	FilePath string           `json:"path,omitempty"`
+	Size     int64            `json:"size"`
+	PrevName string           `json:"prev_name,omitempty"`
+	Modified *fields.ZeekTime `json:"times.modified,omitempty"`
+	Accessed *fields.ZeekTime `json:"times.accessed,omitempty"`
+	Created  *fields.ZeekTime `json:"times.created,omitempty"`
+	Changed  *fields.ZeekTime `json:"times.changed,omitempty"`
+}
+
+// ZeekSMBMapping is a smb_mapping.log record
+type ZeekSMBMapping struct {
+	ZeekBase
+	FilePath         string `json:"path,omitempty"`
+	Service          string `json:"service,omitempty"`
+	NativeFileSystem string `json:"native_file_system,omitempty"`
+	ShareType        string `json:"share_type,omitempty"`
+}
+
+// ZeekKerberos is a kerberos.log record
+type ZeekKerberos struct {
+	ZeekBase
+	RequestType       string           `json:"request_type,omitempty"`
+	Client            string           `json:"client,omitempty"`
+	Service           string           `json:"service,omitempty"`
+	Success           *bool            `json:"success,omitempty"`
+	ErrorMsg          string           `json:"error_msg,omitempty"`
+	From              *fields.ZeekTime `json:"from,omitempty"`
+	Till              *fields.ZeekTime `json:"till,omitempty"`
+	Cipher            string           `json:"cipher,omitempty"`
+	Forwardable       *bool            `json:"forwardable,omitempty"`
+	Renewable         *bool            `json:"renewable,omitempty"`
+	ClientCertSubject string           `json:"client_cert_subject,omitempty"`
+	ClientCertFUID    string           `json:"client_cert_fuid,omitempty"`
+	ServerCertSubject string           `json:"server_cert_subject,omitempty"`
+	ServerCertFUID    string           `json:"server_cert_fuid,omitempty"`
+}
+
+// ZeekPaths lists supported zeek log paths
+var ZeekPaths = []string{
+	"conn", "dns", "http", "ssl", "x509", "files", "notice", "weird", "ssh", "smb_files", "smb_mapping", "kerberos",
+}
+
+// NewZeekLog returns empty record for log path, nil is returned for unsupported logs
+func NewZeekLog(path string) ZeekLog {
+	switch path {
+	case "conn":
+		return &ZeekConn{}
+	case "dns":
+		return &ZeekDNS{}
+	case "http":
+		return &ZeekHTTP{}
+	case "ssl":
+		return &ZeekSSL{}
+	case "x509":
+		return &ZeekX509{}
+	case "files":
+		return &ZeekFiles{}
+	case "notice":
+		return &ZeekNotice{}
+	case "weird":
+		return &ZeekWeird{}
+	case "ssh":
+		return &ZeekSSH{}
+	case "smb_files":
+		return &ZeekSMBFiles{}
+	case "smb_mapping":
+		return &ZeekSMBMapping{}
+	case "kerberos":
+		return &ZeekKerberos{}
+	}
+	return nil
+}
+
+// ParseZeekLog decodes zeek JSON record
+// log is chosen by _path field, by single top level key if record is tagged like in zeek kafka plugin, or by fallback path
+func ParseZeekLog(data []byte, fallback string) (ZeekLog, error) {
+	var header struct {
+		Path string `json:"_path"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	path := header.Path
+	if path == "" {
+		var tagged map[string]json.RawMessage
+		if err := json.Unmarshal(data, &tagged); err == nil && len(tagged) == 1 {
+			for key, inner := range tagged {
+				if NewZeekLog(key) != nil && len(inner) > 0 && inner[0] == '{' {
+					path, data = key, inner
+				}
+			}
+		}
+	}
+	if path == "" {
+		path = fallback
+	}
+	if path == "" {
+		return nil, fmt.Errorf("zeek log path missing from record and not configured for source")
+	}
+	log := NewZeekLog(path)
+	if log == nil {
+		return nil, fmt.Errorf("unsupported zeek log %s", path)
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, err
+	}
+	log.Base().Path = path
+	return log, nil
+}
+
+// ZeekPathFromSource guesses log path from file name or kafka topic, e.g. conn.log, conn.09:00:00-10:00:00.log or zeek-conn
+// empty string is returned if no supported path is found
+func ZeekPathFromSource(src string) string {
+	tokens := strings.FieldsFunc(filepath.Base(src), func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+	for _, token := range tokens {
+		if NewZeekLog(strings.ToLower(token)) != nil {
+			return strings.ToLower(token)
+		}
+	}
+	return ""
+}
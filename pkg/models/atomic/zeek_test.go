@@ -0,0 +1,39 @@
+package atomic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseZeekLog(t *testing.T) {
+	for _, tc := range []struct {
+		raw, fallback, path string
+	}{
+		{`{"_path":"conn","ts":1586858400.123456,"uid":"CAbc1","id.orig_h":"10.0.0.5","id.orig_p":51000,"id.resp_h":"192.0.2.10","id.resp_p":443,"proto":"tcp","conn_state":"SF"}`, "", "conn"},
+		{`{"conn":{"ts":1586858400.123456,"uid":"CAbc1","id.orig_h":"10.0.0.5","id.orig_p":51000,"id.resp_h":"192.0.2.10","id.resp_p":443,"proto":"tcp"}}`, "", "conn"},
+		{`{"ts":"2020-04-14T10:00:00.123456Z","uid":"CAbc1","id.orig_h":"10.0.0.5","id.orig_p":51000,"id.resp_h":"192.0.2.10","id.resp_p":443,"proto":"tcp"}`, ZeekPathFromSource("/var/log/zeek/conn.09:00:00-10:00:00.log"), "conn"},
+	} {
+		log, err := ParseZeekLog([]byte(tc.raw), tc.fallback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log.Source() != tc.path {
+			t.Fatalf("got path %s, want %s", log.Source(), tc.path)
+		}
+		if want := time.Date(2020, 4, 14, 10, 0, 0, 123456000, time.UTC); !log.Time().Equal(want) {
+			t.Fatalf("got ts %s, want %s", log.Time(), want)
+		}
+		if val, ok := JSONField(log, "id.resp_h"); !ok || val != "192.0.2.10" {
+			t.Fatalf("id.resp_h lookup got %v", val)
+		}
+		if val, ok := JSONField(log, "id.resp_p"); !ok || val != 443 {
+			t.Fatalf("id.resp_p lookup got %v", val)
+		}
+	}
+	if _, err := ParseZeekLog([]byte(`{"ts":1586858400.0,"uid":"CAbc1"}`), ""); err == nil {
+		t.Fatal("record without log path should fail")
+	}
+	if got := ZeekPathFromSource("zeek-smb_files"); got != "smb_files" {
+		t.Fatalf("got %s for kafka topic", got)
+	}
+}
@@ -80,6 +80,10 @@ type Message struct {
 	// Optional sender IP address
 	// For example, syslog UDP sender info is usually taken from UDP source
 	Sender net.IP
+
+	// Optional alternative renderings of Data, keyed by view name
+	// e.g. anonymized copy of processed event for player facing outputs
+	Views map[string][]byte
 }
 
 type Offsets struct {
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"github.com/ccdcoe/go-peek/pkg/anonymize"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// anonymizeEvent rewrites original values in payload and meta with their pseudonyms
+// payload must point to data owned by the event, meta is copied where it may be shared
+func anonymizeEvent(replacements map[string]string, m *meta.GameAsset, payload ...interface{}) error {
+	r := anonymize.NewReplacer(replacements)
+	for _, p := range payload {
+		r.Walk(p)
+	}
+	r.GameAsset(m)
+	return nil
+}
+
+// Anonymize implements Anonymizer
+func (d *DynamicWinlogbeat) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &d.GameMeta, d.DynamicWinlogbeat)
+}
+
+// Anonymize implements Anonymizer
+// base64 encoded payload, packet and http bodies are dropped, as names and addresses inside them cannot be rewritten
+// printable variants stay, those are plain strings and get replaced like any other value
+func (s *Suricata) Anonymize(replacements map[string]string) error {
+	s.Payload = ""
+	delete(s.Extra, "packet")
+	delete(s.HTTP, "http_request_body")
+	delete(s.HTTP, "http_response_body")
+	return anonymizeEvent(replacements, &s.GameMeta, &s.StaticSuricataEve, s.Syslog)
+}
+
+// Anonymize implements Anonymizer
+func (s *Syslog) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &s.GameMeta, &s.Syslog)
+}
+
+// Anonymize implements Anonymizer
+func (s *Snoopy) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &s.GameMeta, &s.Snoopy, &s.Syslog)
+}
+
+// Anonymize implements Anonymizer
+func (e *Eventlog) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &e.GameMeta, e.EventLog.DynamicEventLog)
+}
+
+// Anonymize implements Anonymizer
+func (z *ZeekCobalt) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &z.GameMeta, &z.ZeekCobalt)
+}
+
+// Anonymize implements Anonymizer
+func (m *MazeRunner) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &m.GameMeta, &m.MazeRunner)
+}
+
+// Anonymize implements Anonymizer
+func (z *Zeek) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &z.GameMeta, z.Log)
+}
+
+// Anonymize implements Anonymizer
+func (a *Auditd) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &a.GameMeta, &a.Auditd)
+}
+
+// Anonymize implements Anonymizer
+func (o *Osquery) Anonymize(replacements map[string]string) error {
+	return anonymizeEvent(replacements, &o.GameMeta, &o.Osquery)
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSuricataAnonymize(t *testing.T) {
+	payload := "GET / HTTP/1.1\r\nHost: ws01.corp.lan\r\n\r\n"
+	raw := `{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"alert","src_ip":"10.0.0.5","dest_ip":"192.0.2.10",` +
+		`"alert":{"signature_id":2000001,"signature":"test"},` +
+		`"payload":"` + base64.StdEncoding.EncodeToString([]byte(payload)) + `",` +
+		`"payload_printable":` + quote(payload) + `,` +
+		`"packet":"` + base64.StdEncoding.EncodeToString([]byte("\x00\x01ws01.corp.lan")) + `",` +
+		`"http":{"hostname":"ws01.corp.lan","http_response_body":"` + base64.StdEncoding.EncodeToString([]byte("ws01.corp.lan")) + `"}}`
+	var s Suricata
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Anonymize(map[string]string{"ws01.corp.lan": "host-a", "10.0.0.5": "240.1.2.3"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := s.JSONFormat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if strings.Contains(strings.ToLower(out), "ws01") || strings.Contains(out, "10.0.0.5") {
+		t.Fatalf("original value survived anonymization: %s", out)
+	}
+	for _, key := range []string{`"payload"`, `"packet"`, `"http_response_body"`} {
+		if strings.Contains(out, key) {
+			t.Fatalf("encoded %s not dropped: %s", key, out)
+		}
+	}
+	if !strings.Contains(s.PayloadPrintable, "Host: host-a") {
+		t.Fatalf("printable payload not rewritten: %q", s.PayloadPrintable)
+	}
+}
+
+func quote(s string) string {
+	out, _ := json.Marshal(s)
+	return string(out)
+}
@@ -0,0 +1,171 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/markuskont/go-sigma-rule-engine/pkg/sigma"
+)
+
+// auditdSyscalls are x86_64 syscall numbers that decide which side of socket address is remote
+// enriched logs carry syscall name instead, in SYSCALL field
+var auditdSyscalls = map[string]string{
+	"42":  "connect",
+	"43":  "accept",
+	"288": "accept4",
+}
+
+// Auditd is linux audit event, records that share serial are grouped by atomic.AuditdAssembler
+type Auditd struct {
+	atomic.Auditd
+	GameMeta meta.GameAsset `json:"GameMeta,omitempty"`
+}
+
+// DumpEventData implements EventDataDumper
+func (a Auditd) DumpEventData() *meta.EventData {
+	return &meta.EventData{
+		Key:    a.Type(),
+		Fields: a.GetMessage(),
+	}
+}
+
+// GetMessage implements MessageGetter
+func (a Auditd) GetMessage() []string {
+	out := make([]string, 0, 4)
+	if args := a.Args(); len(args) > 0 {
+		out = append(out, strings.Join(args, " "))
+	}
+	for _, key := range []string{"exe", "name", "op", "key"} {
+		if val, ok := a.Field(key); ok && val != "" && val != "?" && val != "(null)" {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+// GetField returns a success status and arbitrary field content if requested map key is present
+// keys are looked up from primary record first, then from other records in order
+// record type prefix selects a specific record, e.g. EXECVE.a0 or PATH.name
+func (a Auditd) GetField(key string) (interface{}, bool) {
+	switch key {
+	case "type":
+		return a.Type(), true
+	case "node":
+		return a.Node, a.Node != ""
+	case "serial":
+		return strconv.FormatUint(a.Serial, 10), true
+	}
+	if i := strings.IndexByte(key, '.'); i > 0 && strings.ToUpper(key[:i]) == key[:i] {
+		if r := a.Record(key[:i]); r != nil {
+			if val, ok := r.Get(key[i+1:]); ok {
+				return val, true
+			}
+		}
+	}
+	if val, ok := a.Field(key); ok {
+		return val, true
+	}
+	return eventField(key, &a.GameMeta)
+}
+
+// SigmaRecords implements SigmaRecordGetter
+// linux/auditd rules are written against single records, e.g. type EXECVE with a0 and a1
+// fields that are missing from record, like key and exe for EXECVE, are looked up from whole event
+func (a Auditd) SigmaRecords() []sigma.EventChecker {
+	out := make([]sigma.EventChecker, len(a.Records))
+	for i := range a.Records {
+		out[i] = AuditdRecord{event: &a, record: &a.Records[i]}
+	}
+	return out
+}
+
+// AuditdRecord is a single record of auditd event as seen by sigma rules
+type AuditdRecord struct {
+	event  *Auditd
+	record *atomic.AuditdRecord
+}
+
+// GetMessage implements MessageGetter
+func (r AuditdRecord) GetMessage() []string { return r.event.GetMessage() }
+
+// GetField returns a success status and arbitrary field content if requested map key is present
+func (r AuditdRecord) GetField(key string) (interface{}, bool) {
+	if key == "type" {
+		return r.record.Type, true
+	}
+	if val, ok := r.record.Get(key); ok {
+		return val, true
+	}
+	return r.event.GetField(key)
+}
+
+// JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
+func (a Auditd) JSONFormat() ([]byte, error) { return json.Marshal(a) }
+
+// remote returns address of remote end of socket and whether it initiated connection
+func (a Auditd) remote() (net.IP, bool) {
+	if r := a.Record("SOCKADDR"); r != nil {
+		if ip, _, ok := r.SockAddr(); ok {
+			syscall, _ := a.Field("SYSCALL")
+			if syscall == "" {
+				num, _ := a.Field("syscall")
+				syscall = auditdSyscalls[num]
+			}
+			return ip, strings.HasPrefix(syscall, "accept")
+		}
+	}
+	// login and authentication messages from user space name the client
+	if addr, ok := a.Field("addr"); ok {
+		if ip := net.ParseIP(addr); ip != nil {
+			return ip, true
+		}
+	}
+	return nil, false
+}
+
+// GetAsset is a getter for receiving event source and target information
+// For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
+// Should provide needed information for doing external asset table lookups
+func (a Auditd) GetAsset() *meta.GameAsset {
+	m := &meta.GameAsset{
+		Asset: meta.Asset{Host: a.Node},
+		MitreAttack: &meta.MitreAttack{
+			Techniques: make([]meta.Technique, 0),
+		},
+	}
+	ip, inbound := a.remote()
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		m.Directionality = meta.DirLocal
+		return m
+	}
+	local := &meta.Asset{Host: a.Node}
+	if inbound {
+		m.Source, m.Destination = &meta.Asset{IP: ip}, local
+	} else {
+		m.Source, m.Destination = local, &meta.Asset{IP: ip}
+	}
+	return m
+}
+
+// SetAsset is a setter for setting meta to object without knowing the object type
+// all asset lookups and field discoveries should be done before using this method to maintain readability
+func (a *Auditd) SetAsset(data meta.GameAsset) {
+	a.GameMeta = data
+}
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (a Auditd) Time() time.Time { return a.Auditd.Time() }
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (a Auditd) Source() string { return a.Auditd.Source() }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (a Auditd) Sender() string { return a.Auditd.Sender() }
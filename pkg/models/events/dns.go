@@ -0,0 +1,78 @@
+package events
+
+import (
+	"net"
+	"strings"
+)
+
+// DNSAnswer is a name to address binding from a DNS response
+type DNSAnswer struct {
+	Name string
+	IP   net.IP
+}
+
+// DNSAnswers extracts A and AAAA records from Suricata DNS answer events
+// both eve version 1 (one record per event) and version 2 (answers list and grouped map) formats are handled
+// queried name is bound to addresses as well, so CNAME chains resolve to name that client asked for
+func (s Suricata) DNSAnswers() []DNSAnswer {
+	if s.DNS == nil || stringField(s.DNS, "type") != "answer" {
+		return nil
+	}
+	query := normalizeName(stringField(s.DNS, "rrname"))
+	out := make([]DNSAnswer, 0)
+	add := func(name, rdata string) {
+		ip := net.ParseIP(rdata)
+		if ip == nil {
+			return
+		}
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		for _, n := range []string{normalizeName(name), query} {
+			if n == "" {
+				continue
+			}
+			var seen bool
+			for _, a := range out {
+				if a.Name == n && a.IP.Equal(ip) {
+					seen = true
+					break
+				}
+			}
+			if !seen {
+				out = append(out, DNSAnswer{Name: n, IP: ip})
+			}
+		}
+	}
+	isAddr := func(rrtype string) bool { return rrtype == "A" || rrtype == "AAAA" }
+
+	// version 1
+	if isAddr(stringField(s.DNS, "rrtype")) {
+		add(query, stringField(s.DNS, "rdata"))
+	}
+	// version 2 detailed format
+	if answers, ok := s.DNS["answers"].([]interface{}); ok {
+		for _, item := range answers {
+			if obj, ok := item.(map[string]interface{}); ok && isAddr(stringField(obj, "rrtype")) {
+				add(stringField(obj, "rrname"), stringField(obj, "rdata"))
+			}
+		}
+	}
+	// version 2 grouped format has no per-record names
+	if grouped, ok := s.DNS["grouped"].(map[string]interface{}); ok {
+		for _, rrtype := range []string{"A", "AAAA"} {
+			if items, ok := grouped[rrtype].([]interface{}); ok {
+				for _, item := range items {
+					if rdata, ok := item.(string); ok {
+						add(query, rdata)
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
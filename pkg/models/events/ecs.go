@@ -0,0 +1,737 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// ECSVersion is Elastic Common Schema version that mappings follow
+const ECSVersion = "1.5.0"
+
+// ECSMapper is implemented by events that can be normalized into Elastic Common Schema
+type ECSMapper interface {
+	ECS() map[string]interface{}
+}
+
+// FormatECS renders event as ECS document, original rendering is kept under key unless key is empty
+func FormatECS(e GameEvent, key string, original []byte) ([]byte, error) {
+	obj, ok := e.(ECSMapper)
+	if !ok {
+		return nil, fmt.Errorf("event type %T has no ECS mapping", e)
+	}
+	doc := obj.ECS()
+	if key != "" && original != nil {
+		doc[key] = json.RawMessage(original)
+	}
+	return json.Marshal(doc)
+}
+
+// ecsDoc is a nested ECS document built from dotted field names
+type ecsDoc map[string]interface{}
+
+// set stores value at dotted path, empty strings, zero numbers and nil values are skipped
+func (d ecsDoc) set(path string, val interface{}) ecsDoc {
+	switch v := val.(type) {
+	case nil:
+		return d
+	case string:
+		if v == "" || v == "-" {
+			return d
+		}
+	case int:
+		if v == 0 {
+			return d
+		}
+	case int64:
+		if v == 0 {
+			return d
+		}
+	case float64:
+		if v == 0 {
+			return d
+		}
+	case uint:
+		if v == 0 {
+			return d
+		}
+	case net.IP:
+		if v == nil {
+			return d
+		}
+		val = v.String()
+	case []string:
+		if len(v) == 0 {
+			return d
+		}
+	case time.Time:
+		if v.IsZero() {
+			return d
+		}
+	}
+	bits := strings.Split(path, ".")
+	obj := map[string]interface{}(d)
+	for _, bit := range bits[:len(bits)-1] {
+		next, ok := obj[bit].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			obj[bit] = next
+		}
+		obj = next
+	}
+	obj[bits[len(bits)-1]] = val
+	return d
+}
+
+func (d ecsDoc) has(path string) bool {
+	_, ok := getField(path, d)
+	return ok
+}
+
+// newECS fills fields that are shared by every event type from timestamp and event meta
+func newECS(ts time.Time, module, dataset string, m meta.GameAsset) ecsDoc {
+	d := ecsDoc{}
+	d.set("@timestamp", ts)
+	d.set("ecs.version", ECSVersion)
+	d.set("event.module", module)
+	if dataset != "" {
+		d.set("event.dataset", module+"."+dataset)
+	}
+	d.set("event.kind", "event")
+	if len(m.SigmaResults) > 0 || len(m.IOC) > 0 {
+		d.set("event.kind", "alert")
+	}
+	ecsAsset(d, "host", &m.Asset)
+	if m.Asset.Network != nil {
+		d.set("network.name", m.Asset.Network.Name)
+	}
+	ecsAsset(d, "source", m.Source)
+	ecsAsset(d, "destination", m.Destination)
+	d.set("network.community_id", m.CommunityID)
+	switch m.Directionality {
+	case meta.DirInbound:
+		d.set("network.direction", "inbound")
+	case meta.DirOutbound:
+		d.set("network.direction", "outbound")
+	case meta.DirLateral, meta.DirLocal:
+		d.set("network.direction", "internal")
+	}
+	if m.MitreAttack != nil && len(m.MitreAttack.Techniques) > 0 {
+		var ids, names, tactics []string
+		for _, t := range m.MitreAttack.Techniques {
+			ids = append(ids, t.ID)
+			names = append(names, t.Name)
+			for _, p := range t.Phases {
+				if !containsString(tactics, p) {
+					tactics = append(tactics, p)
+				}
+			}
+		}
+		d.set("threat.framework", "MITRE ATT&CK")
+		d.set("threat.technique.id", ids)
+		d.set("threat.technique.name", names)
+		d.set("threat.tactic.name", tactics)
+	}
+	if len(m.SigmaResults) > 0 {
+		var ids, names []string
+		for _, r := range m.SigmaResults {
+			ids = append(ids, r.ID)
+			names = append(names, r.Title)
+		}
+		d.set("rule.ruleset", "sigma")
+		d.set("rule.id", ids)
+		d.set("rule.name", names)
+	}
+	if len(m.IOC) > 0 {
+		var indicators []string
+		for _, match := range m.IOC {
+			indicators = append(indicators, match.Indicator)
+		}
+		d.set("threat.indicator.matched", indicators)
+	}
+	return d
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ecsAsset maps asset into host, source or destination field set
+func ecsAsset(d ecsDoc, prefix string, a *meta.Asset) {
+	if a == nil {
+		return
+	}
+	if prefix == "host" {
+		d.set("host.name", a.Host)
+		d.set("host.hostname", a.Host)
+		d.set("host.os.name", a.OS)
+		if a.IP != nil {
+			d.set("host.ip", []string{a.IP.String()})
+		}
+		d.set("host.mac", a.MAC)
+		return
+	}
+	d.set(prefix+".ip", a.IP)
+	d.set(prefix+".mac", a.MAC)
+	if a.Host != "" {
+		d.set(prefix+".domain", a.Host)
+	} else if len(a.DNSNames) > 0 {
+		d.set(prefix+".domain", a.DNSNames[0])
+	}
+	if g := a.Geo; g != nil {
+		d.set(prefix+".geo.country_name", g.Country)
+		d.set(prefix+".geo.country_iso_code", g.CountryISO)
+		d.set(prefix+".geo.city_name", g.City)
+		if g.Lat != 0 || g.Lon != 0 {
+			d.set(prefix+".geo.location", map[string]float64{"lat": g.Lat, "lon": g.Lon})
+		}
+		d.set(prefix+".as.number", g.ASN)
+		d.set(prefix+".as.organization.name", g.Org)
+	}
+}
+
+// ECS implements ECSMapper
+func (s Suricata) ECS() map[string]interface{} {
+	d := newECS(s.Time(), SuricataE.String(), s.EventType, s.GameMeta)
+	d.set("observer.hostname", s.Host)
+	d.set("observer.type", "ids")
+	d.set("observer.product", "Suricata")
+	d.set("event.category", "network")
+	d.set("source.port", s.SrcPort)
+	d.set("destination.port", s.DestPort)
+	d.set("network.transport", strings.ToLower(s.Proto))
+	d.set("network.protocol", s.AppProto)
+	d.set("network.community_id", s.CommunityID)
+	if s.SrcIP != nil && !d.has("source.ip") {
+		d.set("source.ip", s.SrcIP.IP)
+	}
+	if s.DestIP != nil && !d.has("destination.ip") {
+		d.set("destination.ip", s.DestIP.IP)
+	}
+	if s.Alert != nil {
+		d.set("event.kind", "alert")
+		d.set("event.action", s.Alert.Action)
+		d.set("event.severity", s.Alert.Severity)
+		d.set("rule.ruleset", "suricata")
+		d.set("rule.id", strconv.Itoa(s.Alert.SignatureID))
+		d.set("rule.name", s.Alert.Signature)
+		d.set("rule.category", s.Alert.Category)
+		d.set("message", s.Alert.Signature)
+	}
+	if s.DNS != nil {
+		d.set("dns.type", stringField(s.DNS, "type"))
+		d.set("dns.question.name", stringField(s.DNS, "rrname"))
+		d.set("dns.question.type", stringField(s.DNS, "rrtype"))
+		d.set("dns.response_code", stringField(s.DNS, "rcode"))
+	}
+	if s.HTTP != nil {
+		d.set("url.domain", stringField(s.HTTP, "hostname"))
+		d.set("url.original", stringField(s.HTTP, "url"))
+		d.set("http.request.method", stringField(s.HTTP, "http_method"))
+		d.set("http.request.referrer", stringField(s.HTTP, "http_refer"))
+		if status, ok := s.HTTP["status"].(float64); ok {
+			d.set("http.response.status_code", int(status))
+		}
+		d.set("user_agent.original", stringField(s.HTTP, "http_user_agent"))
+	}
+	if s.TLS != nil {
+		d.set("tls.client.server_name", stringField(s.TLS, "sni"))
+		d.set("tls.version", stringField(s.TLS, "version"))
+		d.set("tls.server.subject", stringField(s.TLS, "subject"))
+		d.set("tls.server.issuer", stringField(s.TLS, "issuerdn"))
+		d.set("tls.server.ja3s", stringField(s.TLS, "ja3s.hash"))
+	}
+	if s.Fileinfo != nil {
+		d.set("file.name", stringField(s.Fileinfo, "filename"))
+		d.set("file.hash.md5", stringField(s.Fileinfo, "md5"))
+		d.set("file.hash.sha1", stringField(s.Fileinfo, "sha1"))
+		d.set("file.hash.sha256", stringField(s.Fileinfo, "sha256"))
+	}
+	return d
+}
+
+// ECS implements ECSMapper
+func (s Syslog) ECS() map[string]interface{} {
+	d := newECS(s.Time(), SyslogE.String(), s.Syslog.Program, s.GameMeta)
+	ecsSyslog(d, s.Syslog.Host, s.Syslog.Program, s.Syslog.Facility, s.Syslog.Severity)
+	d.set("message", s.Syslog.Message)
+	return d
+}
+
+func ecsSyslog(d ecsDoc, host, program, facility, severity string) {
+	if !d.has("host.name") {
+		d.set("host.name", host)
+	}
+	d.set("process.name", program)
+	d.set("log.syslog.facility.name", facility)
+	d.set("log.syslog.severity.name", severity)
+}
+
+// ECS implements ECSMapper
+func (s Snoopy) ECS() map[string]interface{} {
+	d := newECS(s.Time(), SnoopyE.String(), "", s.GameMeta)
+	ecsSyslog(d, s.Syslog.Host, s.Syslog.Program, s.Syslog.Facility, s.Syslog.Severity)
+	d.set("event.category", "process")
+	d.set("event.type", "start")
+	d.set("process.command_line", s.Cmd)
+	d.set("process.executable", s.Filename)
+	d.set("process.working_directory", s.Cwd)
+	if args := strings.Fields(s.Cmd); len(args) > 0 {
+		d.set("process.args", args)
+	}
+	if len(s.GameMeta.Ancestors) > 0 {
+		// snoopy commands are linked to session leader, see proctree
+		leader := s.GameMeta.Ancestors[0]
+		d.set("process.session_leader.pid", leader.PID)
+		d.set("process.session_leader.executable", leader.Image)
+		d.set("process.session_leader.command_line", leader.CommandLine)
+		d.set("process.session_leader.user.name", leader.User)
+	}
+	d.set("user.name", s.Username)
+	d.set("user.id", s.UID)
+	d.set("group.id", s.Gid)
+	d.set("group.name", s.Group)
+	d.set("message", s.Cmd)
+	if s.SSH != nil {
+		d.set("source.port", s.SSH.SrcPort)
+		d.set("destination.port", s.SSH.DstPort)
+	}
+	return d
+}
+
+// ecsWindows maps windows event data fields, shared by winlogbeat event_data and nxlog flat layout
+// sysmon and security log use same field names for process, user and network data
+func ecsWindows(d ecsDoc, data map[string]interface{}) {
+	str := func(key string) string { return stringField(data, key) }
+	d.set("process.executable", str("Image"))
+	d.set("process.command_line", str("CommandLine"))
+	d.set("process.working_directory", str("CurrentDirectory"))
+	d.set("process.entity_id", str("ProcessGuid"))
+	d.set("process.parent.executable", str("ParentImage"))
+	d.set("process.parent.command_line", str("ParentCommandLine"))
+	d.set("process.parent.entity_id", str("ParentProcessGuid"))
+	for _, key := range []string{"ProcessId", "ParentProcessId"} {
+		if pid, err := strconv.Atoi(fmt.Sprint(data[key])); err == nil {
+			field := "process.pid"
+			if key == "ParentProcessId" {
+				field = "process.parent.pid"
+			}
+			d.set(field, pid)
+		}
+	}
+	if image := str("Image"); image != "" {
+		d.set("process.name", image[strings.LastIndexAny(image, `\/`)+1:])
+	}
+	if user := str("User"); user != "" {
+		if bits := strings.SplitN(user, `\`, 2); len(bits) == 2 {
+			d.set("user.domain", bits[0])
+			d.set("user.name", bits[1])
+		} else {
+			d.set("user.name", user)
+		}
+	}
+	if !d.has("user.name") {
+		d.set("user.name", str("TargetUserName"))
+		d.set("user.domain", str("TargetDomainName"))
+	}
+	for _, item := range strings.Split(str("Hashes"), ",") {
+		if bits := strings.SplitN(item, "=", 2); len(bits) == 2 {
+			switch strings.ToUpper(bits[0]) {
+			case "MD5", "SHA1", "SHA256":
+				d.set("process.hash."+strings.ToLower(bits[0]), strings.ToLower(bits[1]))
+			}
+		}
+	}
+	d.set("file.path", str("TargetFilename"))
+	d.set("file.path", str("ImageLoaded"))
+	d.set("registry.path", str("TargetObject"))
+	d.set("dns.question.name", str("QueryName"))
+	if !d.has("source.ip") {
+		d.set("source.ip", str("SourceIp"))
+	}
+	if !d.has("destination.ip") {
+		d.set("destination.ip", str("DestinationIp"))
+	}
+	if port, err := strconv.Atoi(str("SourcePort")); err == nil {
+		d.set("source.port", port)
+	}
+	if port, err := strconv.Atoi(str("DestinationPort")); err == nil {
+		d.set("destination.port", port)
+	}
+	d.set("network.transport", strings.ToLower(str("Protocol")))
+}
+
+// windowsModule tells sysmon apart from other windows logs, as both are parsed by same event types
+func windowsModule(provider string) string {
+	if strings.Contains(strings.ToLower(provider), "sysmon") {
+		return SysmonE.String()
+	}
+	return EventLogE.String()
+}
+
+// ecsWinlogbeatSets are top level winlogbeat keys that already follow ECS and are passed through
+// source and destination come from event meta instead, as addresses may have been resolved
+var ecsWinlogbeatSets = []string{"process", "user", "file", "registry", "dns", "network", "related"}
+
+// copyValue deep copies decoded JSON, so ECS document does not modify original event
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = copyValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = copyValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+// ECS implements ECSMapper
+func (d DynamicWinlogbeat) ECS() map[string]interface{} {
+	var code int
+	if val, ok := d.GetField("winlog.event_id"); ok {
+		if num, ok := val.(float64); ok {
+			code = int(num)
+		}
+	}
+	doc := newECS(d.Time(), windowsModule(d.Source()), strconv.Itoa(code), d.GameMeta)
+	for _, key := range ecsWinlogbeatSets {
+		if val, ok := d.DynamicWinlogbeat[key]; ok {
+			doc[key] = copyValue(val)
+		}
+	}
+	if data, ok := d.DynamicWinlogbeat["winlog"].(map[string]interface{}); ok {
+		if eventData, ok := data["event_data"].(map[string]interface{}); ok {
+			ecsWindows(doc, eventData)
+		}
+		doc.set("event.provider", stringField(data, "provider_name"))
+		doc.set("event.action", stringField(data, "task"))
+	}
+	if code > 0 {
+		doc.set("event.code", strconv.Itoa(code))
+	}
+	doc.set("message", strings.Join(d.GetMessage(), "\n"))
+	return doc
+}
+
+// ECS implements ECSMapper
+func (e Eventlog) ECS() map[string]interface{} {
+	data := map[string]interface{}(e.EventLog.DynamicEventLog)
+	var code string
+	if val, ok := data["EventID"]; ok && val != nil {
+		code = fmt.Sprint(val)
+	}
+	d := newECS(e.Time(), windowsModule(e.Source()), code, e.GameMeta)
+	ecsWindows(d, data)
+	d.set("event.provider", stringField(data, "SourceName"))
+	d.set("event.code", code)
+	d.set("message", stringField(data, "Message"))
+	return d
+}
+
+// ECS implements ECSMapper
+func (z ZeekCobalt) ECS() map[string]interface{} {
+	d := newECS(z.Time(), ZeekE.String(), "notice", z.GameMeta)
+	d.set("observer.product", "Zeek")
+	d.set("observer.type", "ids")
+	d.set("event.category", "network")
+	d.set("event.kind", "alert")
+	d.set("event.id", z.UID)
+	d.set("source.port", z.IDOrigP)
+	d.set("destination.port", z.IDRespP)
+	d.set("network.transport", strings.ToLower(z.Proto))
+	d.set("rule.name", z.Note)
+	d.set("message", z.Msg)
+	return d
+}
+
+// ECS implements ECSMapper
+// dataset is zeek log path, e.g. zeek.conn
+func (z Zeek) ECS() map[string]interface{} {
+	b := z.Log.Base()
+	d := newECS(z.Time(), ZeekE.String(), b.Path, z.GameMeta)
+	d.set("observer.hostname", b.SystemName)
+	d.set("observer.product", "Zeek")
+	d.set("observer.type", "ids")
+	d.set("event.category", "network")
+	d.set("event.id", b.UID)
+	d.set("source.port", b.IDOrigP)
+	d.set("destination.port", b.IDRespP)
+	if src, dst := z.endpoints(); src != nil || dst != nil {
+		if !d.has("source.ip") {
+			d.set("source.ip", src)
+		}
+		if !d.has("destination.ip") {
+			d.set("destination.ip", dst)
+		}
+	}
+	outcome := func(success *bool) {
+		if success == nil {
+			return
+		}
+		if *success {
+			d.set("event.outcome", "success")
+		} else {
+			d.set("event.outcome", "failure")
+		}
+	}
+	switch l := z.Log.(type) {
+	case *atomic.ZeekConn:
+		d.set("network.transport", l.Proto)
+		d.set("network.protocol", l.Service)
+		d.set("event.duration", int64(l.Duration*1e9))
+		d.set("source.bytes", l.OrigBytes)
+		d.set("source.packets", l.OrigPkts)
+		d.set("destination.bytes", l.RespBytes)
+		d.set("destination.packets", l.RespPkts)
+		d.set("source.mac", l.OrigL2Addr)
+		d.set("destination.mac", l.RespL2Addr)
+	case *atomic.ZeekDNS:
+		d.set("network.transport", l.Proto)
+		d.set("network.protocol", "dns")
+		d.set("dns.id", strconv.Itoa(l.TransID))
+		d.set("dns.question.name", l.Query)
+		d.set("dns.question.class", l.QClassName)
+		d.set("dns.question.type", l.QTypeName)
+		d.set("dns.response_code", l.RCodeName)
+		var resolved []string
+		for _, answer := range l.Answers {
+			if ip := net.ParseIP(answer); ip != nil {
+				resolved = append(resolved, ip.String())
+			}
+		}
+		d.set("dns.resolved_ip", resolved)
+	case *atomic.ZeekHTTP:
+		d.set("network.protocol", "http")
+		d.set("http.version", l.Version)
+		d.set("http.request.method", l.Method)
+		d.set("http.request.referrer", l.Referrer)
+		d.set("http.request.body.bytes", l.RequestBodyLen)
+		d.set("http.response.status_code", l.StatusCode)
+		d.set("http.response.body.bytes", l.ResponseBodyLen)
+		d.set("url.domain", l.Host)
+		d.set("url.original", l.URI)
+		d.set("user_agent.original", l.UserAgent)
+		d.set("user.name", l.Username)
+	case *atomic.ZeekSSL:
+		d.set("network.protocol", "tls")
+		d.set("tls.version", l.Version)
+		d.set("tls.cipher", l.Cipher)
+		d.set("tls.curve", l.Curve)
+		d.set("tls.established", l.Established)
+		d.set("tls.resumed", l.Resumed)
+		d.set("tls.next_protocol", l.NextProtocol)
+		d.set("tls.client.server_name", l.ServerName)
+		d.set("tls.client.ja3", l.JA3)
+		d.set("tls.client.subject", l.ClientSubject)
+		d.set("tls.client.issuer", l.ClientIssuer)
+		d.set("tls.server.ja3s", l.JA3S)
+		d.set("tls.server.subject", l.Subject)
+		d.set("tls.server.issuer", l.Issuer)
+	case *atomic.ZeekX509:
+		d.set("event.category", "file")
+		d.set("tls.server.subject", l.CertificateSubject)
+		d.set("tls.server.issuer", l.CertificateIssuer)
+		d.set("tls.server.hash.sha256", l.Fingerprint)
+		if l.CertificateNotBefore != nil {
+			d.set("tls.server.not_before", l.CertificateNotBefore.Time)
+		}
+		if l.CertificateNotAfter != nil {
+			d.set("tls.server.not_after", l.CertificateNotAfter.Time)
+		}
+	case *atomic.ZeekFiles:
+		d.set("event.category", "file")
+		d.set("file.name", l.Filename)
+		d.set("file.mime_type", l.MimeType)
+		d.set("file.size", l.TotalBytes)
+		d.set("file.hash.md5", l.MD5)
+		d.set("file.hash.sha1", l.SHA1)
+		d.set("file.hash.sha256", l.SHA256)
+	case *atomic.ZeekNotice:
+		d.set("event.kind", "alert")
+		d.set("network.transport", l.Proto)
+		d.set("rule.name", l.Note)
+		d.set("message", l.Msg)
+	case *atomic.ZeekWeird:
+		d.set("rule.name", l.Name)
+		d.set("message", l.Addl)
+	case *atomic.ZeekSSH:
+		d.set("network.protocol", "ssh")
+		outcome(l.AuthSuccess)
+	case *atomic.ZeekSMBFiles:
+		d.set("network.protocol", "smb")
+		d.set("event.category", "file")
+		d.set("event.action", l.Action)
+		d.set("file.path", l.FilePath)
+		d.set("file.name", l.Name)
+		d.set("file.size", l.Size)
+	case *atomic.ZeekSMBMapping:
+		d.set("network.protocol", "smb")
+		d.set("file.path", l.FilePath)
+	case *atomic.ZeekKerberos:
+		d.set("network.protocol", "kerberos")
+		d.set("event.category", "authentication")
+		d.set("event.action", l.RequestType)
+		if names := z.Users(); len(names) > 0 {
+			d.set("user.name", names[0])
+		}
+		d.set("message", l.ErrorMsg)
+		outcome(l.Success)
+	}
+	return d
+}
+
+// ECS implements ECSMapper
+func (m MazeRunner) ECS() map[string]interface{} {
+	d := newECS(m.Time(), MazeRunnerE.String(), "", m.GameMeta)
+	ext := m.Cef.Extensions
+	d.set("observer.vendor", m.Cef.DeviceVendor)
+	d.set("observer.product", m.Cef.DeviceProduct)
+	d.set("observer.version", m.Cef.DeviceVersion)
+	d.set("observer.type", "honeypot")
+	d.set("event.kind", "alert")
+	d.set("rule.id", m.Cef.SignatureID)
+	d.set("rule.name", m.Cef.Name)
+	d.set("message", m.Cef.Name)
+	if severity, err := strconv.Atoi(m.Cef.Severity); err == nil {
+		d.set("event.severity", severity)
+	}
+	for key, field := range map[string]string{"spt": "source.port", "dpt": "destination.port"} {
+		if port, err := strconv.Atoi(ext[key]); err == nil {
+			d.set(field, port)
+		}
+	}
+	d.set("user.name", ext["suser"])
+	d.set("user.target.name", ext["duser"])
+	return d
+}
+
+// ECS implements ECSMapper
+func (a Auditd) ECS() map[string]interface{} {
+	d := newECS(a.Time(), AuditdE.String(), "", a.GameMeta)
+	if !d.has("host.name") {
+		d.set("host.name", a.Node)
+	}
+	field := func(key string) string {
+		val, _ := a.Field(key)
+		if val == "?" || val == "(null)" {
+			return ""
+		}
+		return val
+	}
+	d.set("event.action", strings.ToLower(a.Type()))
+	d.set("event.sequence", int64(a.Serial))
+	d.set("auditd.log.record_type", a.Type())
+	if key := field("key"); key != "" {
+		d.set("tags", strings.Split(key, ","))
+	}
+	d.set("process.executable", field("exe"))
+	d.set("process.name", field("comm"))
+	d.set("process.working_directory", field("cwd"))
+	if args := a.Args(); len(args) > 0 {
+		d.set("process.args", args)
+		d.set("process.command_line", strings.Join(args, " "))
+	}
+	for key, path := range map[string]string{"pid": "process.pid", "ppid": "process.parent.pid"} {
+		if pid, err := strconv.Atoi(field(key)); err == nil {
+			d.set(path, pid)
+		}
+	}
+	if r := a.Record("PATH"); r != nil {
+		name, _ := r.Get("name")
+		d.set("file.path", name)
+	}
+	d.set("user.id", field("uid"))
+	d.set("user.audit.id", field("auid"))
+	if names := a.Users(); len(names) > 0 {
+		d.set("user.name", names[0])
+	}
+	switch a.Type() {
+	case "SYSCALL", "EXECVE":
+		d.set("event.category", "process")
+	case "USER_AUTH", "USER_LOGIN", "USER_LOGOUT", "USER_ACCT", "CRED_ACQ", "USER_START", "USER_END":
+		d.set("event.category", "authentication")
+	case "ADD_USER", "DEL_USER", "ADD_GROUP", "DEL_GROUP", "USER_MGMT", "USER_CHAUTHTOK":
+		d.set("event.category", "iam")
+	}
+	switch res := field("res"); {
+	case res == "success" || res == "1":
+		d.set("event.outcome", "success")
+	case res == "failed" || res == "0":
+		d.set("event.outcome", "failure")
+	default:
+		switch field("success") {
+		case "yes":
+			d.set("event.outcome", "success")
+		case "no":
+			d.set("event.outcome", "failure")
+		}
+	}
+	if r := a.Record("SOCKADDR"); r != nil {
+		if _, port, ok := r.SockAddr(); ok {
+			d.set("event.category", "network")
+			// addresses are mapped from meta, see GetAsset
+			if _, inbound := a.remote(); inbound {
+				d.set("source.port", port)
+			} else {
+				d.set("destination.port", port)
+			}
+		}
+	}
+	d.set("message", strings.Join(a.GetMessage(), " "))
+	return d
+}
+
+// ECS implements ECSMapper
+// only first row is mapped, snapshots stay in original rendering
+func (o Osquery) ECS() map[string]interface{} {
+	d := newECS(o.Time(), OsqueryE.String(), o.Key(), o.GameMeta)
+	if !d.has("host.name") {
+		d.set("host.name", o.HostIdentifier)
+	}
+	col := func(key string) string {
+		val, _ := o.Column(key)
+		return val
+	}
+	d.set("event.action", o.Action)
+	d.set("rule.name", o.Name)
+	if pid, err := strconv.Atoi(col("pid")); err == nil {
+		d.set("process.pid", pid)
+	}
+	d.set("process.name", col("name"))
+	d.set("process.executable", col("path"))
+	d.set("process.command_line", col("cmdline"))
+	d.set("process.working_directory", col("cwd"))
+	d.set("file.hash.md5", col("md5"))
+	d.set("file.hash.sha1", col("sha1"))
+	d.set("file.hash.sha256", col("sha256"))
+	if names := o.Users(); len(names) > 0 {
+		d.set("user.name", names[0])
+	}
+	d.set("user.id", col("uid"))
+	if port, err := strconv.Atoi(col("remote_port")); err == nil {
+		d.set("destination.port", port)
+	}
+	if port, err := strconv.Atoi(col("local_port")); err == nil {
+		d.set("source.port", port)
+	}
+	d.set("message", strings.Join(o.GetMessage(), " "))
+	return d
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+func TestFormatECS(t *testing.T) {
+	raw := []byte(`{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"alert","proto":"TCP","src_ip":"10.0.0.5","src_port":51000,"dest_ip":"192.0.2.10","dest_port":443,"host":"sensor01","alert":{"signature_id":2000001,"signature":"ET TEST","category":"Misc"}}`)
+	var s Suricata
+	if err := json.Unmarshal(raw, &s); err != nil {
+		t.Fatal(err)
+	}
+	s.SetAsset(meta.GameAsset{
+		Asset:       meta.Asset{Host: "sensor01"},
+		Source:      &meta.Asset{Host: "ws01", IP: net.ParseIP("10.0.0.5")},
+		Destination: &meta.Asset{IP: net.ParseIP("192.0.2.10"), Geo: &meta.Geo{CountryISO: "EE", ASN: 64500}},
+		MitreAttack: &meta.MitreAttack{Techniques: []meta.Technique{{ID: "T1071", Phases: []string{"command-and-control"}}}},
+	})
+	data, err := FormatECS(&s, "original", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]interface{}{
+		"@timestamp":                       "2020-04-14T10:00:00Z",
+		"event.kind":                       "alert",
+		"event.dataset":                    "suricata.alert",
+		"source.domain":                    "ws01",
+		"source.port":                      float64(51000),
+		"destination.geo.country_iso_code": "EE",
+		"destination.as.number":            float64(64500),
+		"network.transport":                "tcp",
+		"rule.id":                          "2000001",
+		"original.alert.signature":         "ET TEST",
+	} {
+		if got, ok := getField(path, doc); !ok || got != want {
+			t.Fatalf("%s: got %v, want %v", path, got, want)
+		}
+	}
+	if ids, ok := getField("threat.technique.id", doc); !ok || ids.([]interface{})[0] != "T1071" {
+		t.Fatalf("technique not mapped: %v", ids)
+	}
+}
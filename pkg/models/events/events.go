@@ -4,6 +4,11 @@ import (
 	"bytes"
 	"strings"
 	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/markuskont/go-sigma-rule-engine/pkg/sigma"
 )
 
 type Timer interface {
@@ -18,6 +23,12 @@ type SaganFormatter interface {
 	SaganFormat() string
 }
 
+// SigmaRecordGetter is implemented by events that bundle several records, such as grouped audit records or osquery snapshots
+// sigma rules are written against single records, so each record is matched separately
+type SigmaRecordGetter interface {
+	SigmaRecords() []sigma.EventChecker
+}
+
 type Atomic int
 
 func (a Atomic) String() string {
@@ -38,6 +49,10 @@ func (a Atomic) String() string {
 		return "zeek"
 	case MazeRunnerE:
 		return "mazerunner"
+	case AuditdE:
+		return "auditd"
+	case OsqueryE:
+		return "osquery"
 	default:
 		return "atomic"
 	}
@@ -59,9 +74,13 @@ func (a Atomic) Explain() string {
 	case SysmonE:
 		return "Sysmon audit log for Windows event log."
 	case ZeekE:
-		return "Zeek, formerly known as Bro. Custom output format."
+		return "Zeek, formerly known as Bro. JSON logs, such as conn, dns, http, ssl and notice."
 	case MazeRunnerE:
 		return "MazeRunner. Honeypot system from Cymmertria."
+	case AuditdE:
+		return "Linux audit log. Raw audit.log lines or audispd syslog messages, records are grouped into events by serial."
+	case OsqueryE:
+		return "Osquery scheduled query results. Differential, batched differential and snapshot JSON logs."
 	default:
 		return "Simple fallback format for unknown JSON formats. " +
 			"May attempt to access and parse popular timestamp keys but no guarantee on success."
@@ -78,6 +97,8 @@ const (
 	SysmonE
 	ZeekE
 	MazeRunnerE
+	AuditdE
+	OsqueryE
 )
 
 var Atomics = []Atomic{
@@ -89,6 +110,18 @@ var Atomics = []Atomic{
 	SysmonE,
 	ZeekE,
 	MazeRunnerE,
+	AuditdE,
+	OsqueryE,
+}
+
+// SigmaProduct returns logsource product of sigma rules that apply to event type
+func (a Atomic) SigmaProduct() string {
+	switch a {
+	case AuditdE:
+		return "linux"
+	default:
+		return a.String()
+	}
 }
 
 // Functions
@@ -108,16 +141,51 @@ func getField(key string, data map[string]interface{}) (interface{}, bool) {
 	if data == nil {
 		return nil, false
 	}
-	bits := strings.SplitN(key, ".", 2)
-	if val, ok := data[bits[0]]; ok {
-		switch res := val.(type) {
-		case map[string]interface{}:
-			return getField(bits[1], res)
-		default:
-			return val, ok
+	return atomic.JSONField(data, key)
+}
+
+// eventField is dotted path lookup shared by all event types, paths follow emitted JSON
+// GameMeta.* paths are resolved against enrichment, anything else against payloads in given order
+func eventField(key string, m *meta.GameAsset, payload ...interface{}) (interface{}, bool) {
+	if strings.HasPrefix(key, "GameMeta.") {
+		return atomic.JSONField(m, strings.TrimPrefix(key, "GameMeta."))
+	}
+	for _, p := range payload {
+		if val, ok := atomic.JSONField(p, key); ok {
+			return val, true
 		}
 	}
 	return nil, false
 }
 
+// normalizeIPFields rewrites textual addresses in nested map into canonical notation
+// keys are dotted paths as in getField, missing or non-string values are ignored
+func normalizeIPFields(data map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		bits := strings.Split(key, ".")
+		obj := data
+		for _, bit := range bits[:len(bits)-1] {
+			next, ok := obj[bit].(map[string]interface{})
+			if !ok {
+				obj = nil
+				break
+			}
+			obj = next
+		}
+		if obj == nil {
+			continue
+		}
+		if val, ok := obj[bits[len(bits)-1]].(string); ok {
+			obj[bits[len(bits)-1]] = fields.NormalizeIP(val)
+		}
+	}
+}
+
+// normalizeSyslog rewrites sender into canonical notation if relay logged it as an address
+// typed address fields are written in canonical notation by fields.StringIP
+func normalizeSyslog(s atomic.Syslog) atomic.Syslog {
+	s.Host = fields.NormalizeIP(s.Host)
+	return s
+}
+
 type EventMapFn func(string) Atomic
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+func TestGetField(t *testing.T) {
+	gm := meta.GameAsset{Asset: meta.Asset{Host: "ws01"}, Source: &meta.Asset{Host: "attacker"}}
+	mustMap := func(raw string) map[string]interface{} {
+		var out map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	var eve Suricata
+	if err := json.Unmarshal([]byte(`{"event_type":"alert","src_ip":"10.0.0.5","src_port":51000,"dest_port":80,"vlan":[10,20],"alert":{"signature_id":2000001,"signature":"ET TEST"},"http":{"hostname":"example.com","url":"/"},"dns":{"answers":[{"rrname":"example.com"}]}}`), &eve); err != nil {
+		t.Fatal(err)
+	}
+	eve.GameMeta = gm
+
+	for _, tc := range []struct {
+		name string
+		ev   interface {
+			GetField(string) (interface{}, bool)
+		}
+		fields map[string]interface{}
+	}{
+		{"suricata", eve, map[string]interface{}{
+			"alert.signature_id":   2000001,
+			"alert.signature":      "ET TEST",
+			"http.hostname":        "example.com",
+			"dns.answers.0.rrname": "example.com",
+			"vlan.1":               20,
+			"src_port":             51000,
+			"GameMeta.Host":        "ws01",
+			"GameMeta.Src.Host":    "attacker",
+		}},
+		{"winlogbeat", DynamicWinlogbeat{
+			DynamicWinlogbeat: mustMap(`{"winlog":{"event_id":4624,"event_data":{"TargetUserName":"bob"}}}`),
+			GameMeta:          gm,
+		}, map[string]interface{}{
+			"winlog.event_data.TargetUserName": "bob",
+			"GameMeta.Host":                    "ws01",
+		}},
+		{"eventlog", &Eventlog{
+			EventLog: atomic.EventLog{DynamicEventLog: mustMap(`{"EventID":4688,"NewProcessName":"C:\\cmd.exe"}`)},
+			GameMeta: gm,
+		}, map[string]interface{}{
+			"EventID":        float64(4688),
+			"NewProcessName": `C:\cmd.exe`,
+			"GameMeta.Host":  "ws01",
+		}},
+		{"syslog", Syslog{
+			Syslog:   atomic.Syslog{Host: "srv01", Program: "sshd"},
+			GameMeta: gm,
+		}, map[string]interface{}{
+			"program":               "sshd",
+			"syslog.syslog_program": "sshd",
+			"GameMeta.Host":         "ws01",
+		}},
+		{"snoopy", Snoopy{
+			Snoopy:   atomic.Snoopy{Cmd: "id", SSH: &atomic.SnoopySSH{DstPort: "22"}},
+			Syslog:   atomic.Syslog{Host: "srv01"},
+			GameMeta: gm,
+		}, map[string]interface{}{
+			"cmd":                "id",
+			"ssh.dst_port":       "22",
+			"host":               "srv01",
+			"syslog.syslog_host": "srv01",
+			"GameMeta.Host":      "ws01",
+		}},
+		{"cobalt", &ZeekCobalt{
+			ZeekCobalt: atomic.ZeekCobalt{Note: "Scan::Port_Scan", IDRespH: &fields.StringIP{IP: net.ParseIP("192.0.2.10")}},
+			GameMeta:   gm,
+		}, map[string]interface{}{
+			"note":          "Scan::Port_Scan",
+			"id.resp_h":     "192.0.2.10",
+			"GameMeta.Host": "ws01",
+		}},
+		{"mazerunner", &MazeRunner{
+			MazeRunner: atomic.MazeRunner{
+				Syslog: atomic.Syslog{Host: "decoy"},
+				Cef:    atomic.Cef{Name: "SMB login", Extensions: map[string]string{"src": "10.0.0.5"}},
+			},
+			GameMeta: gm,
+		}, map[string]interface{}{
+			"host":          "decoy",
+			"src":           "10.0.0.5",
+			"cef.Name":      "SMB login",
+			"GameMeta.Host": "ws01",
+		}},
+	} {
+		for key, want := range tc.fields {
+			if val, ok := tc.ev.GetField(key); !ok || val != want {
+				t.Fatalf("%s %s: got %v (%T), want %v", tc.name, key, val, val, want)
+			}
+		}
+		if _, ok := tc.ev.GetField("missing.key"); ok {
+			t.Fatalf("%s: missing key should not match", tc.name)
+		}
+	}
+}
+
+func TestJSONFormatNormalizesAddresses(t *testing.T) {
+	eve := Suricata{}
+	if err := json.Unmarshal([]byte(`{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"flow","src_ip":"::ffff:10.0.0.5","dest_ip":"2001:0db8:0000:0000:0000:0000:0000:0001"}`), &eve); err != nil {
+		t.Fatal(err)
+	}
+	eve.Syslog = &atomic.Syslog{Host: "::FFFF:192.0.2.1"}
+	snoopy, err := atomic.ParseSnoopy(`[login:bob ssh:(fe80::0001%eth0 51234 2001:0db8::0001 22) username:bob uid:1000 group:bob gid:1000 sid:1234 tty:/dev/pts/0 cwd:/home/bob filename:/usr/bin/id]: id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := atomic.ParseZeekLog([]byte(`{"_path":"conn","ts":1586858400.0,"uid":"CAbc1","id.orig_h":"::ffff:10.0.0.5","id.orig_p":51000,"id.resp_h":"2001:0DB8::0001","id.resp_p":443,"proto":"tcp"}`), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name   string
+		ev     atomic.JSONFormatter
+		fields map[string]string
+	}{
+		{"suricata", eve, map[string]string{
+			"src_ip":             "10.0.0.5",
+			"dest_ip":            "2001:db8::1",
+			"syslog.syslog_host": "192.0.2.1",
+		}},
+		{"syslog", Syslog{Syslog: atomic.Syslog{
+			Host: "2001:0db8::0001",
+			IP:   &fields.StringIP{IP: net.ParseIP("::ffff:10.0.0.5")},
+		}}, map[string]string{
+			"syslog.syslog_host": "2001:db8::1",
+			"syslog.syslog_ip":   "10.0.0.5",
+		}},
+		{"snoopy", Snoopy{Snoopy: *snoopy, Syslog: atomic.Syslog{Host: "::ffff:10.0.0.7"}}, map[string]string{
+			"ssh.src_ip":         "fe80::1",
+			"ssh.dst_ip":         "2001:db8::1",
+			"syslog.syslog_host": "10.0.0.7",
+		}},
+		{"zeek", Zeek{Log: conn}, map[string]string{
+			"id.orig_h": "10.0.0.5",
+			"id.resp_h": "2001:db8::1",
+		}},
+	} {
+		data, err := tc.ev.JSONFormat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			t.Fatal(err)
+		}
+		for key, want := range tc.fields {
+			if val, ok := atomic.JSONField(obj, key); !ok || val != want {
+				t.Fatalf("%s %s: got %v, want %s", tc.name, key, val, want)
+			}
+		}
+	}
+}
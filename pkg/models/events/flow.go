@@ -0,0 +1,199 @@
+package events
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/ccdcoe/go-peek/pkg/communityid"
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+)
+
+// Flow is network 5-tuple of event, for ICMP ports hold message type and code
+type Flow struct {
+	SrcIP, DstIP     net.IP
+	SrcPort, DstPort uint16
+	Proto            uint8
+}
+
+// CommunityID returns Community ID v1 hash of flow with default seed
+func (f Flow) CommunityID() (string, bool) {
+	return communityid.Hash(0, f.SrcIP, f.DstIP, f.SrcPort, f.DstPort, f.Proto)
+}
+
+// FlowGetter is implemented by events that expose source and destination address and port
+type FlowGetter interface {
+	NetworkFlow() (*Flow, bool)
+}
+
+// newFlow builds flow from loosely typed values as they appear in logs
+func newFlow(src, dst net.IP, sport, dport interface{}, proto string) (*Flow, bool) {
+	if src == nil || dst == nil {
+		return nil, false
+	}
+	p, ok := communityid.Protocol(proto)
+	if !ok {
+		return nil, false
+	}
+	f := &Flow{SrcIP: src, DstIP: dst, Proto: p}
+	if f.SrcPort, ok = port(sport); !ok {
+		return nil, false
+	}
+	if f.DstPort, ok = port(dport); !ok {
+		return nil, false
+	}
+	return f, true
+}
+
+func port(val interface{}) (uint16, bool) {
+	switch v := val.(type) {
+	case int:
+		return uint16(v), v >= 0 && v <= 65535
+	case float64:
+		return uint16(v), v >= 0 && v <= 65535
+	case string:
+		num, err := strconv.ParseUint(v, 10, 16)
+		return uint16(num), err == nil
+	}
+	return 0, false
+}
+
+func stringIP(ip *fields.StringIP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	return ip.IP
+}
+
+// NetworkFlow implements FlowGetter
+func (s Suricata) NetworkFlow() (*Flow, bool) {
+	return newFlow(stringIP(s.SrcIP), stringIP(s.DestIP), s.SrcPort, s.DestPort, s.Proto)
+}
+
+// NetworkFlow implements FlowGetter
+func (z ZeekCobalt) NetworkFlow() (*Flow, bool) {
+	return newFlow(stringIP(z.IDOrigH), stringIP(z.IDRespH), z.IDOrigP, z.IDRespP, z.Proto)
+}
+
+// NetworkFlow implements FlowGetter
+// only conn, dns and notice logs carry protocol, application logs other than kerberos are bound to tcp
+func (z Zeek) NetworkFlow() (*Flow, bool) {
+	b := z.Log.Base()
+	var proto string
+	switch l := z.Log.(type) {
+	case *atomic.ZeekConn:
+		proto = l.Proto
+	case *atomic.ZeekDNS:
+		proto = l.Proto
+	case *atomic.ZeekNotice:
+		proto = l.Proto
+	case *atomic.ZeekHTTP, *atomic.ZeekSSL, *atomic.ZeekSSH, *atomic.ZeekSMBFiles, *atomic.ZeekSMBMapping:
+		proto = "tcp"
+	}
+	return newFlow(stringIP(b.IDOrigH), stringIP(b.IDRespH), b.IDOrigP, b.IDRespP, proto)
+}
+
+// NetworkFlow implements FlowGetter
+// ssh session is always tcp
+func (s Snoopy) NetworkFlow() (*Flow, bool) {
+	if s.SSH == nil {
+		return nil, false
+	}
+	return newFlow(stringIP(s.SSH.SrcIP), stringIP(s.SSH.DstIP), s.SSH.SrcPort, s.SSH.DstPort, "tcp")
+}
+
+// NetworkFlow implements FlowGetter
+func (m MazeRunner) NetworkFlow() (*Flow, bool) {
+	ext := m.Cef.Extensions
+	proto := ext["proto"]
+	if proto == "" {
+		proto = "tcp"
+	}
+	return newFlow(m.GetSrcIP(), m.GetDstIP(), ext["spt"], ext["dpt"], proto)
+}
+
+// sysmonFlow handles network connection event 3
+func sysmonFlow(data map[string]interface{}) (*Flow, bool) {
+	return newFlow(
+		fields.ParseIP(stringField(data, "SourceIp")),
+		fields.ParseIP(stringField(data, "DestinationIp")),
+		data["SourcePort"],
+		data["DestinationPort"],
+		stringField(data, "Protocol"),
+	)
+}
+
+// NetworkFlow implements FlowGetter
+func (d DynamicWinlogbeat) NetworkFlow() (*Flow, bool) {
+	if ev, ok := d.Sysmon.(*atomic.SysmonNetworkConnect); ok {
+		return newFlow(ev.SourceIP, ev.DestinationIP, ev.SourcePort, ev.DestinationPort, ev.Protocol)
+	}
+	if val, ok := d.GetField("winlog.event_id"); !ok || fmt.Sprint(val) != "3" {
+		return nil, false
+	}
+	data, ok := getField("winlog.event_data", d.DynamicWinlogbeat)
+	if !ok {
+		return nil, false
+	}
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return sysmonFlow(obj)
+}
+
+// NetworkFlow implements FlowGetter
+func (e Eventlog) NetworkFlow() (*Flow, bool) {
+	data := map[string]interface{}(e.EventLog.DynamicEventLog)
+	if fmt.Sprint(data["EventID"]) != "3" {
+		return nil, false
+	}
+	return sysmonFlow(data)
+}
+
+// firewallFields matches netfilter log prefix keys, e.g. SRC=10.0.0.1 DST=10.0.0.2 PROTO=TCP SPT=51000 DPT=22
+var firewallFields = regexp.MustCompile(`\b(SRC|DST|PROTO|SPT|DPT|TYPE|CODE)=(\S+)`)
+
+// NetworkFlow implements FlowGetter for firewall log lines, such as iptables and nftables kernel log
+func (s Syslog) NetworkFlow() (*Flow, bool) {
+	matches := firewallFields.FindAllStringSubmatch(s.Syslog.Message, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	kv := make(map[string]string, len(matches))
+	for _, m := range matches {
+		if _, ok := kv[m[1]]; !ok {
+			kv[m[1]] = m[2]
+		}
+	}
+	sport, dport := kv["SPT"], kv["DPT"]
+	if p, ok := communityid.Protocol(kv["PROTO"]); ok && (p == communityid.ProtoICMP || p == communityid.ProtoICMPv6) {
+		sport, dport = kv["TYPE"], kv["CODE"]
+	}
+	return newFlow(fields.ParseIP(kv["SRC"]), fields.ParseIP(kv["DST"]), sport, dport, kv["PROTO"])
+}
+
+// CommunityID returns Community ID of event flow, value supplied by sensor takes precedence over computed one
+func CommunityID(ev interface{}) (string, bool) {
+	switch obj := ev.(type) {
+	case *Suricata:
+		if obj.CommunityID != "" {
+			return obj.CommunityID, true
+		}
+	case *Zeek:
+		if conn, ok := obj.Log.(*atomic.ZeekConn); ok && conn.CommunityID != "" {
+			return conn.CommunityID, true
+		}
+	}
+	obj, ok := ev.(FlowGetter)
+	if !ok {
+		return "", false
+	}
+	f, ok := obj.NetworkFlow()
+	if !ok {
+		return "", false
+	}
+	return f.CommunityID()
+}
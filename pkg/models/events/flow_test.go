@@ -0,0 +1,88 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+)
+
+func TestNetworkFlow(t *testing.T) {
+	winlogbeat := func(raw string) FlowGetter {
+		var obj atomic.DynamicWinlogbeat
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			t.Fatal(err)
+		}
+		return DynamicWinlogbeat{DynamicWinlogbeat: obj, Sysmon: obj.ParseSysmon()}
+	}
+	zeek := func(raw string) FlowGetter {
+		log, err := atomic.ParseZeekLog([]byte(raw), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Zeek{Log: log}
+	}
+	suricata := func(raw string) FlowGetter {
+		var s Suricata
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	mazerunner := func(raw string) FlowGetter {
+		cef, err := atomic.ParseCEF(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return MazeRunner{MazeRunner: atomic.MazeRunner{Cef: *cef}}
+	}
+	snoopy := func(raw string) FlowGetter {
+		obj, err := atomic.ParseSnoopy(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Snoopy{Snoopy: *obj}
+	}
+	for _, tc := range []struct {
+		name  string
+		ev    FlowGetter
+		src   string
+		dst   string
+		sport uint16
+		dport uint16
+		proto uint8
+	}{
+		{"zeek conn", zeek(`{"_path":"conn","ts":1586858400.0,"uid":"CAbc1","id.orig_h":"10.0.0.5","id.orig_p":51000,"id.resp_h":"192.0.2.10","id.resp_p":53,"proto":"udp"}`),
+			"10.0.0.5", "192.0.2.10", 51000, 53, 17},
+		{"zeek http", zeek(`{"_path":"http","ts":1586858400.0,"uid":"CAbc1","id.orig_h":"10.0.0.5","id.orig_p":51001,"id.resp_h":"2001:db8::1","id.resp_p":80,"method":"GET","host":"example.com","uri":"/"}`),
+			"10.0.0.5", "2001:db8::1", 51001, 80, 6},
+		{"sysmon", winlogbeat(`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"provider_name":"Microsoft-Windows-Sysmon","channel":"Microsoft-Windows-Sysmon/Operational","event_id":3,"event_data":{"Protocol":"tcp","Initiated":"true","SourceIp":"10.0.0.5","SourcePort":"49710","DestinationIp":"192.0.2.10","DestinationPort":"445"}}}`),
+			"10.0.0.5", "192.0.2.10", 49710, 445, 6},
+		{"winlogbeat without sysmon provider", winlogbeat(`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"event_id":3,"event_data":{"Protocol":"udp","SourceIp":"10.0.0.5","SourcePort":"5353","DestinationIp":"224.0.0.251","DestinationPort":"5353"}}}`),
+			"10.0.0.5", "224.0.0.251", 5353, 5353, 17},
+		{"netfilter", Syslog{Syslog: atomic.Syslog{Program: "kernel", Message: `[UFW BLOCK] IN=eth0 OUT= MAC=00:00:00:00:00:00 SRC=198.51.100.7 DST=10.0.0.5 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=1 DF PROTO=TCP SPT=41000 DPT=22 WINDOW=29200 RES=0x00 SYN URGP=0`}},
+			"198.51.100.7", "10.0.0.5", 41000, 22, 6},
+		{"netfilter icmp", Syslog{Syslog: atomic.Syslog{Program: "kernel", Message: `IN=eth0 OUT= SRC=198.51.100.7 DST=10.0.0.5 LEN=84 PROTO=ICMP TYPE=8 CODE=0 ID=1 SEQ=1`}},
+			"198.51.100.7", "10.0.0.5", 8, 0, 1},
+		{"mazerunner", mazerunner(`CEF:0|Cymmetria|MazeRunner|1.0|alert|SMB login|7|src=10.0.0.5 spt=51002 dst=10.0.0.99 dpt=445 msg=login`),
+			"10.0.0.5", "10.0.0.99", 51002, 445, 6},
+		{"suricata", suricata(`{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"flow","src_ip":"10.0.0.5","src_port":51003,"dest_ip":"192.0.2.10","dest_port":443,"proto":"TCP"}`),
+			"10.0.0.5", "192.0.2.10", 51003, 443, 6},
+		{"snoopy", snoopy(`[login:bob ssh:(198.51.100.7 51234 10.0.0.5 22) username:bob uid:1000 group:bob gid:1000 sid:1234 tty:/dev/pts/0 cwd:/home/bob filename:/usr/bin/id]: id`),
+			"198.51.100.7", "10.0.0.5", 51234, 22, 6},
+	} {
+		f, ok := tc.ev.NetworkFlow()
+		if !ok {
+			t.Fatalf("%s: no flow", tc.name)
+		}
+		if f.SrcIP.String() != tc.src || f.DstIP.String() != tc.dst || f.SrcPort != tc.sport || f.DstPort != tc.dport || f.Proto != tc.proto {
+			t.Fatalf("%s: got %s:%d -> %s:%d proto %d", tc.name, f.SrcIP, f.SrcPort, f.DstIP, f.DstPort, f.Proto)
+		}
+	}
+	if _, ok := (Syslog{Syslog: atomic.Syslog{Message: "session opened for user bob"}}).NetworkFlow(); ok {
+		t.Fatal("syslog line without firewall fields should not have flow")
+	}
+	if _, ok := (Snoopy{}).NetworkFlow(); ok {
+		t.Fatal("snoopy without ssh session should not have flow")
+	}
+}
@@ -39,10 +39,19 @@ type DynamicWinlogbeat struct {
 	Timestamp time.Time `json:"@timestamp"`
 	atomic.DynamicWinlogbeat
 	GameMeta meta.GameAsset `json:"GameMeta,omitempty"`
+	// Sysmon is typed model of sysmon event, nil for other providers and event IDs without model
+	Sysmon atomic.SysmonEvent `json:"-"`
 }
 
 // DumpEventData implements EventDataDumper
 func (d DynamicWinlogbeat) DumpEventData() *meta.EventData {
+	if d.Sysmon != nil {
+		return &meta.EventData{
+			Key:    d.Source(),
+			ID:     d.Sysmon.Base().EventID,
+			Fields: d.Sysmon.Fields(),
+		}
+	}
 	return &meta.EventData{
 		Key: d.Source(),
 		ID: func() int {
@@ -119,7 +128,7 @@ func (d DynamicWinlogbeat) GetMessage() []string {
 
 // GetField returns a success status and arbitrary field content if requested map key is present
 func (d DynamicWinlogbeat) GetField(key string) (interface{}, bool) {
-	return getField(key, d.DynamicWinlogbeat)
+	return eventField(key, &d.GameMeta, d.DynamicWinlogbeat)
 }
 
 // Time implements atomic.Event
@@ -140,7 +149,7 @@ func (d DynamicWinlogbeat) Sender() string {
 // For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
 // Should provide needed information for doing external asset table lookups
 func (d DynamicWinlogbeat) GetAsset() *meta.GameAsset {
-	return &meta.GameAsset{
+	m := &meta.GameAsset{
 		Asset: meta.Asset{
 			Host: d.Sender(),
 			IP:   nil,
@@ -151,6 +160,22 @@ func (d DynamicWinlogbeat) GetAsset() *meta.GameAsset {
 		Source:      nil,
 		Destination: nil,
 	}
+	switch ev := d.Sysmon.(type) {
+	case *atomic.SysmonNetworkConnect:
+		if ev.SourceIP != nil {
+			m.Source = &meta.Asset{IP: ev.SourceIP}
+		}
+		if ev.DestinationIP != nil {
+			m.Destination = &meta.Asset{IP: ev.DestinationIP}
+		}
+	case *atomic.SysmonDNSQuery:
+		// logging host asks for name, first resolved address is where it is likely to connect to
+		m.Source = &meta.Asset{Host: d.Sender()}
+		if addrs := ev.Addresses(); len(addrs) > 0 {
+			m.Destination = &meta.Asset{IP: addrs[0]}
+		}
+	}
+	return m
 }
 
 // SetAsset is a setter for setting meta to object without knowing the object type
@@ -159,9 +184,19 @@ func (d *DynamicWinlogbeat) SetAsset(obj meta.GameAsset) {
 	d.GameMeta = obj
 }
 
+// winlogbeatIPFields are address fields that are passed through from windows logs as-is
+var winlogbeatIPFields = []string{
+	"winlog.event_data.SourceIp",
+	"winlog.event_data.DestinationIp",
+	"winlog.event_data.IpAddress",
+	"source.ip",
+	"destination.ip",
+}
+
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
 func (d DynamicWinlogbeat) JSONFormat() ([]byte, error) {
 	obj := d.DynamicWinlogbeat
+	normalizeIPFields(obj, winlogbeatIPFields...)
 	obj["GameMeta"] = d.GameMeta
 	return json.Marshal(obj)
 }
@@ -194,6 +229,50 @@ func (s Suricata) DumpEventData() *meta.EventData {
 	}
 }
 
+// MitreAttack extracts ATT&CK techniques from alert rule metadata
+// ET and many custom rules ship mitre_technique_id and mitre_tactic_id metadata keys
+func (s Suricata) MitreAttack() *meta.MitreAttack {
+	if s.Alert == nil || s.Alert.Metadata == nil {
+		return nil
+	}
+	ids := s.Alert.GetMetadataStrings("mitre_technique_id")
+	if len(ids) == 0 {
+		return nil
+	}
+	names := s.Alert.GetMetadataStrings("mitre_technique_name")
+	phases := func() []string {
+		var out []string
+		// Initial_Access in rule metadata vs initial-access in kill chain phase names
+		for _, t := range s.Alert.GetMetadataStrings("mitre_tactic_name") {
+			if phase, ok := meta.TagToPhase(t); ok {
+				out = append(out, phase)
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+		// rules that only carry TA0001 style IDs are resolved to phase names too
+		for _, id := range s.Alert.GetMetadataStrings("mitre_tactic_id") {
+			if phase, ok := meta.TacticIDToPhase(id); ok {
+				out = append(out, phase)
+			}
+		}
+		return out
+	}()
+	attack := &meta.MitreAttack{Techniques: make([]meta.Technique, 0, len(ids))}
+	for i, id := range ids {
+		technique := meta.Technique{
+			ID:     strings.ToUpper(id),
+			Phases: phases,
+		}
+		if i < len(names) {
+			technique.Name = strings.Replace(names[i], "_", " ", -1)
+		}
+		attack.Add(technique)
+	}
+	return attack
+}
+
 // GetMessage implements MessageGetter
 func (s Suricata) GetMessage() []string {
 	out := []string{s.PayloadPrintable}
@@ -204,67 +283,61 @@ func (s Suricata) GetMessage() []string {
 }
 
 // GetField returns a success status and arbitrary field content if requested map key is present
+// common eve fields and alert are served from typed structs, other sections by generic lookup over eve JSON layout
 func (s Suricata) GetField(key string) (interface{}, bool) {
-	if !strings.Contains(key, ".") {
-		return s.StaticSuricataEve.EveBase.GetField(key)
+	if val, ok := s.StaticSuricataEve.EveBase.GetField(key); ok {
+		return val, ok
 	}
-	bits := strings.SplitN(key, ".", 1)
-	if len(bits) == 2 && strings.HasPrefix(bits[0], "alert") && s.Alert != nil {
-		return s.Alert.GetField(bits[1])
-	}
-	switch bits[0] {
-	case "alert":
-		if s.Alert != nil {
-			return s.Alert.GetField(bits[1])
+	if s.Alert != nil && strings.HasPrefix(key, "alert.") {
+		if val, ok := s.Alert.GetField(strings.TrimPrefix(key, "alert.")); ok {
+			return val, ok
 		}
-	case "ssh":
-		return getField(bits[1], s.SSH)
-	case "tls":
-		return getField(bits[1], s.TLS)
-	case "tcp":
-		return getField(bits[1], s.TCP)
-	case "dns":
-		return getField(bits[1], s.DNS)
-	case "http":
-		return getField(bits[1], s.HTTP)
-	case "rdp":
-		return getField(bits[1], s.RDP)
-	case "smb":
-		return getField(bits[1], s.SMB)
-	case "dhcp":
-		return getField(bits[1], s.DHCP)
-	case "snmp":
-		return getField(bits[1], s.SNMP)
-	case "tftp":
-		return getField(bits[1], s.TFTP)
-	case "sip":
-		return getField(bits[1], s.SIP)
-	case "ftp_data":
-		return getField(bits[1], s.FTPdata)
-	case "packet_info":
-		return getField(bits[1], s.PacketInfo)
-	case "traffic":
-		return getField(bits[1], s.Traffic)
-	case "fileinfo":
-		return getField(bits[1], s.Fileinfo)
-	case "flow":
-		return getField(bits[1], s.Flow)
-	case "krb5":
-		return getField(bits[1], s.Krb5)
-	case "ikev2":
-		return getField(bits[1], s.Ikev2)
-	case "tunnel":
-		return getField(bits[1], s.Tunnel)
-	case "metadata":
-		return getField(bits[1], s.Metadata)
-	case "anomaly":
-		return getField(bits[1], s.Anomaly)
-	}
-	return nil, false
+	}
+	return eventField(key, &s.GameMeta, s, s.Extra)
+}
+
+// suricataWrapper holds fields that peek adds to eve object
+type suricataWrapper struct {
+	Syslog   *atomic.Syslog `json:"syslog,omitempty"`
+	GameMeta meta.GameAsset `json:"GameMeta,omitempty"`
+}
+
+// MarshalJSON keeps all eve sections at top level, next to syslog and GameMeta
+// needed as otherwise marshaler of embedded eve would be promoted and peek fields would be lost
+func (s Suricata) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(s.StaticSuricataEve)
+	if err != nil {
+		return nil, err
+	}
+	m, err := json.Marshal(suricataWrapper{Syslog: s.Syslog, GameMeta: s.GameMeta})
+	if err != nil {
+		return nil, err
+	}
+	return atomic.JoinJSONObjects(data, m), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, so emitted events can be decoded again
+// syslog and GameMeta are picked up in the same pass as eve sections
+func (s *Suricata) UnmarshalJSON(data []byte) error {
+	var w suricataWrapper
+	if err := s.StaticSuricataEve.DecodeJSON(data, map[string]interface{}{
+		"syslog":   &w.Syslog,
+		"GameMeta": &w.GameMeta,
+	}); err != nil {
+		return err
+	}
+	s.Syslog, s.GameMeta = w.Syslog, w.GameMeta
+	return nil
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
-func (s Suricata) JSONFormat() ([]byte, error) { return json.Marshal(s) }
+func (s Suricata) JSONFormat() ([]byte, error) {
+	if s.Syslog != nil {
+		relay := normalizeSyslog(*s.Syslog)
+		s.Syslog = &relay
+	}
+	return json.Marshal(s)
+}
 
 // GetAsset is a getter for receiving event source and target information
 // For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
@@ -346,12 +419,19 @@ func (s Syslog) GetMessage() []string {
 }
 
 // GetField returns a success status and arbitrary field content if requested map key is present
+// short keys, such as host or program, are accepted for syslog fields
 func (s Syslog) GetField(key string) (interface{}, bool) {
-	return s.Syslog.GetField(key)
+	if val, ok := s.Syslog.GetField(key); ok {
+		return val, ok
+	}
+	return eventField(key, &s.GameMeta, s, s.Syslog)
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
-func (s Syslog) JSONFormat() ([]byte, error) { return json.Marshal(s) }
+func (s Syslog) JSONFormat() ([]byte, error) {
+	s.Syslog = normalizeSyslog(s.Syslog)
+	return json.Marshal(s)
+}
 
 // GetAsset is a getter for receiving event source and target information
 // For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
@@ -441,9 +521,8 @@ func (s Snoopy) GetField(key string) (interface{}, bool) {
 	case "login":
 		return s.Login, true
 	}
-	if s.SSH != nil && strings.HasPrefix("ssh", key) {
-		bits := strings.SplitN(key, ".", 1)
-		switch bits[1] {
+	if s.SSH != nil && strings.HasPrefix(key, "ssh.") {
+		switch strings.TrimPrefix(key, "ssh.") {
 		case "dst_port", "dest_port", "dport":
 			return s.SSH.DstPort, true
 		case "dst_ip", "dest_ip":
@@ -458,11 +537,17 @@ func (s Snoopy) GetField(key string) (interface{}, bool) {
 			}
 		}
 	}
-	return s.Syslog.GetField(key)
+	if val, ok := s.Syslog.GetField(key); ok {
+		return val, ok
+	}
+	return eventField(key, &s.GameMeta, s)
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
-func (s Snoopy) JSONFormat() ([]byte, error) { return json.Marshal(s) }
+func (s Snoopy) JSONFormat() ([]byte, error) {
+	s.Syslog = normalizeSyslog(s.Syslog)
+	return json.Marshal(s)
+}
 
 // GetAsset is a getter for receiving event source and target information
 // For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
@@ -533,17 +618,26 @@ type Eventlog struct {
 
 // DumpEventData implements EventDataDumper
 func (e *Eventlog) DumpEventData() *meta.EventData {
-	panic("not implemented") // TODO: Implement
+	d := &meta.EventData{Key: e.Source(), Fields: e.GetMessage()}
+	if id, ok := e.GetField("EventID"); ok {
+		if f, ok := id.(float64); ok {
+			d.ID = int(f)
+		}
+	}
+	return d
 }
 
 // GetMessage implements MessageGetter
 func (e *Eventlog) GetMessage() []string {
-	panic("not implemented") // TODO: Implement
+	if m, ok := e.DynamicEventLog["Message"].(string); ok {
+		return []string{m}
+	}
+	return nil
 }
 
 // GetField returns a success status and arbitrary field content if requested map key is present
-func (e *Eventlog) GetField(_ string) (interface{}, bool) {
-	panic("not implemented") // TODO: Implement
+func (e *Eventlog) GetField(key string) (interface{}, bool) {
+	return eventField(key, &e.GameMeta, e.DynamicEventLog)
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
@@ -596,17 +690,18 @@ type ZeekCobalt struct {
 
 // DumpEventData implements EventDataDumper
 func (z *ZeekCobalt) DumpEventData() *meta.EventData {
-	panic("not implemented") // TODO: Implement
+	return &meta.EventData{Key: z.Note, Fields: z.GetMessage()}
 }
 
 // GetMessage implements MessageGetter
 func (z *ZeekCobalt) GetMessage() []string {
-	panic("not implemented") // TODO: Implement
+	return []string{z.Msg, z.Sub}
 }
 
 // GetField returns a success status and arbitrary field content if requested map key is present
-func (z *ZeekCobalt) GetField(_ string) (interface{}, bool) {
-	panic("not implemented") // TODO: Implement
+// keys are as in zeek notice log, e.g. note or id.orig_h
+func (z *ZeekCobalt) GetField(key string) (interface{}, bool) {
+	return eventField(key, &z.GameMeta, z.ZeekCobalt)
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
@@ -664,17 +759,21 @@ type MazeRunner struct {
 
 // DumpEventData implements EventDataDumper
 func (m *MazeRunner) DumpEventData() *meta.EventData {
-	panic("not implemented") // TODO: Implement
+	return &meta.EventData{Key: m.Cef.SignatureID, Fields: m.GetMessage()}
 }
 
 // GetMessage implements MessageGetter
 func (m *MazeRunner) GetMessage() []string {
-	panic("not implemented") // TODO: Implement
+	return []string{m.Cef.Name}
 }
 
 // GetField returns a success status and arbitrary field content if requested map key is present
-func (m *MazeRunner) GetField(_ string) (interface{}, bool) {
-	panic("not implemented") // TODO: Implement
+// CEF extensions are also accessible by bare name, e.g. src or dvchost
+func (m *MazeRunner) GetField(key string) (interface{}, bool) {
+	if val, ok := m.Syslog.GetField(key); ok {
+		return val, ok
+	}
+	return eventField(key, &m.GameMeta, m.MazeRunner, m.Cef.Extensions)
 }
 
 // JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
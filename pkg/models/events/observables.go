@@ -0,0 +1,251 @@
+package events
+
+import (
+	"net"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// ObservableGetter is implemented by events that carry values for threat intel lookups
+type ObservableGetter interface {
+	Observables() []meta.Observable
+}
+
+// observables is a deduplicating collector
+type observables []meta.Observable
+
+func (o observables) add(kind, value string) observables {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return o
+	}
+	if kind == meta.ObservableDomain {
+		value = strings.ToLower(strings.TrimSuffix(value, "."))
+		// TLS SNI and HTTP host may be a plain address
+		if net.ParseIP(value) != nil {
+			return o
+		}
+	}
+	switch kind {
+	case meta.ObservableMD5, meta.ObservableSHA1, meta.ObservableSHA256:
+		value = strings.ToLower(value)
+	case meta.ObservableIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return o
+		}
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		value = ip.String()
+	}
+	for _, item := range o {
+		if item.Kind == kind && item.Value == value {
+			return o
+		}
+	}
+	return append(o, meta.Observable{Kind: kind, Value: value})
+}
+
+func (o observables) addIP(ip net.IP) observables {
+	if ip == nil {
+		return o
+	}
+	return o.add(meta.ObservableIP, ip.String())
+}
+
+// addPath adds both full path and file name, as indicator lists rarely know where dropped files end up
+func (o observables) addPath(path string) observables {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return o
+	}
+	o = o.add(meta.ObservableFilename, path)
+	if i := strings.LastIndexAny(path, `/\`); i != -1 && i < len(path)-1 {
+		o = o.add(meta.ObservableFilename, path[i+1:])
+	}
+	return o
+}
+
+// addText extracts addresses and URLs from free text such as command lines
+func (o observables) addText(text string) observables {
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '"' || r == '\'' || r == '=' || r == ';' || r == '|'
+	}) {
+		if ip := net.ParseIP(token); ip != nil {
+			o = o.addIP(ip)
+			continue
+		}
+		if i := strings.Index(token, "://"); i > 0 {
+			o = o.add(meta.ObservableURL, token[i+3:])
+			host := token[i+3:]
+			if j := strings.IndexAny(host, "/?#"); j != -1 {
+				host = host[:j]
+			}
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+			}
+			if ip := net.ParseIP(host); ip != nil {
+				o = o.addIP(ip)
+			} else {
+				o = o.add(meta.ObservableDomain, host)
+			}
+		}
+	}
+	return o
+}
+
+func stringField(data map[string]interface{}, key string) string {
+	if data == nil {
+		return ""
+	}
+	if val, ok := data[key].(string); ok {
+		return val
+	}
+	return ""
+}
+
+// Observables implements ObservableGetter
+// domains are collected from DNS queries and answers, HTTP host and TLS SNI, hashes from fileinfo
+// URL is reconstructed from HTTP host and path without scheme, as Suricata does not log it
+func (s Suricata) Observables() []meta.Observable {
+	out := make(observables, 0)
+	if s.SrcIP != nil {
+		out = out.addIP(s.SrcIP.IP)
+	}
+	if s.DestIP != nil {
+		out = out.addIP(s.DestIP.IP)
+	}
+	if s.DNS != nil {
+		out = out.add(meta.ObservableDomain, stringField(s.DNS, "rrname"))
+		for _, section := range []string{"queries", "answers"} {
+			if items, ok := s.DNS[section].([]interface{}); ok {
+				for _, item := range items {
+					if obj, ok := item.(map[string]interface{}); ok {
+						out = out.add(meta.ObservableDomain, stringField(obj, "rrname"))
+					}
+				}
+			}
+		}
+	}
+	if s.HTTP != nil {
+		host := stringField(s.HTTP, "hostname")
+		out = out.add(meta.ObservableDomain, host)
+		if path := stringField(s.HTTP, "url"); host != "" && path != "" {
+			out = out.add(meta.ObservableURL, host+path)
+		}
+	}
+	if s.TLS != nil {
+		out = out.add(meta.ObservableDomain, stringField(s.TLS, "sni"))
+	}
+	if s.Fileinfo != nil {
+		out = out.add(meta.ObservableMD5, stringField(s.Fileinfo, "md5"))
+		out = out.add(meta.ObservableSHA1, stringField(s.Fileinfo, "sha1"))
+		out = out.add(meta.ObservableSHA256, stringField(s.Fileinfo, "sha256"))
+		out = out.addPath(stringField(s.Fileinfo, "filename"))
+	}
+	return out
+}
+
+// Observables implements ObservableGetter
+// dns answers are either addresses or names of CNAME and other records
+func (z Zeek) Observables() []meta.Observable {
+	out := make(observables, 0)
+	src, dst := z.endpoints()
+	out = out.addIP(src).addIP(dst)
+	switch l := z.Log.(type) {
+	case *atomic.ZeekDNS:
+		out = out.add(meta.ObservableDomain, l.Query)
+		for _, answer := range l.Answers {
+			if ip := net.ParseIP(answer); ip != nil {
+				out = out.addIP(ip)
+			} else {
+				out = out.add(meta.ObservableDomain, answer)
+			}
+		}
+	case *atomic.ZeekHTTP:
+		out = out.add(meta.ObservableDomain, l.Host)
+		if l.Host != "" && l.URI != "" {
+			out = out.add(meta.ObservableURL, l.Host+l.URI)
+		}
+	case *atomic.ZeekSSL:
+		out = out.add(meta.ObservableDomain, l.ServerName)
+	case *atomic.ZeekX509:
+		for _, name := range l.SANDNS {
+			// wildcard entries cannot be matched against indicator lists
+			if !strings.HasPrefix(name, "*") {
+				out = out.add(meta.ObservableDomain, name)
+			}
+		}
+	case *atomic.ZeekFiles:
+		out = out.add(meta.ObservableMD5, l.MD5)
+		out = out.add(meta.ObservableSHA1, l.SHA1)
+		out = out.add(meta.ObservableSHA256, l.SHA256)
+		out = out.addPath(l.Filename)
+	case *atomic.ZeekSMBFiles:
+		out = out.addPath(l.Name)
+	}
+	return out
+}
+
+// Observables implements ObservableGetter
+// Sysmon logs hashes as single comma separated string, e.g. SHA1=...,MD5=...,SHA256=...,IMPHASH=...
+func (d DynamicWinlogbeat) Observables() []meta.Observable {
+	out := make(observables, 0)
+	if val, ok := d.GetField("winlog.event_data.Hashes"); ok {
+		if s, ok := val.(string); ok {
+			for _, item := range strings.Split(s, ",") {
+				bits := strings.SplitN(item, "=", 2)
+				if len(bits) != 2 {
+					continue
+				}
+				switch strings.ToUpper(strings.TrimSpace(bits[0])) {
+				case "MD5":
+					out = out.add(meta.ObservableMD5, bits[1])
+				case "SHA1":
+					out = out.add(meta.ObservableSHA1, bits[1])
+				case "SHA256":
+					out = out.add(meta.ObservableSHA256, bits[1])
+				}
+			}
+		}
+	}
+	// sysmon event 22
+	if val, ok := d.GetField("winlog.event_data.QueryName"); ok {
+		if s, ok := val.(string); ok {
+			out = out.add(meta.ObservableDomain, s)
+		}
+	}
+	// sysmon event 7
+	if val, ok := d.GetField("winlog.event_data.ImageLoaded"); ok {
+		if s, ok := val.(string); ok {
+			out = out.addPath(s)
+		}
+	}
+	// sysmon event 3
+	if val, ok := d.GetField("winlog.event_data.DestinationIp"); ok {
+		if s, ok := val.(string); ok {
+			out = out.addIP(net.ParseIP(s))
+		}
+	}
+	return out
+}
+
+// Observables implements ObservableGetter
+// command line is scanned for addresses and URLs, e.g. wget or curl invocations
+func (s Snoopy) Observables() []meta.Observable {
+	out := make(observables, 0)
+	out = out.addPath(s.Filename)
+	out = out.addText(s.Cmd)
+	if s.SSH != nil {
+		if s.SSH.SrcIP != nil {
+			out = out.addIP(s.SSH.SrcIP.IP)
+		}
+		if s.SSH.DstIP != nil {
+			out = out.addIP(s.SSH.DstIP.IP)
+		}
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+func TestSuricataObservables(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want []meta.Observable
+	}{
+		{
+			`{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"dns","src_ip":"10.0.0.5","dest_ip":"::ffff:192.0.2.53","dns":{"type":"answer","rrname":"Evil.Example.","answers":[{"rrname":"evil.example","rdata":"192.0.2.10"},{"rrname":"cdn.example"}]}}`,
+			[]meta.Observable{
+				{Kind: meta.ObservableIP, Value: "10.0.0.5"},
+				{Kind: meta.ObservableIP, Value: "192.0.2.53"},
+				{Kind: meta.ObservableDomain, Value: "evil.example"},
+				{Kind: meta.ObservableDomain, Value: "cdn.example"},
+			},
+		},
+		{
+			`{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"fileinfo","src_ip":"192.0.2.10","dest_ip":"10.0.0.5","http":{"hostname":"evil.example","url":"/drop.exe"},"tls":{"sni":"192.0.2.10"},"fileinfo":{"filename":"/drop.exe","md5":"D41D8CD98F00B204E9800998ECF8427E","sha256":"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}}`,
+			[]meta.Observable{
+				{Kind: meta.ObservableIP, Value: "192.0.2.10"},
+				{Kind: meta.ObservableIP, Value: "10.0.0.5"},
+				{Kind: meta.ObservableDomain, Value: "evil.example"},
+				{Kind: meta.ObservableURL, Value: "evil.example/drop.exe"},
+				{Kind: meta.ObservableMD5, Value: "d41d8cd98f00b204e9800998ecf8427e"},
+				{Kind: meta.ObservableSHA256, Value: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+				{Kind: meta.ObservableFilename, Value: "/drop.exe"},
+				{Kind: meta.ObservableFilename, Value: "drop.exe"},
+			},
+		},
+	} {
+		var s Suricata
+		if err := json.Unmarshal([]byte(tc.raw), &s); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Observables(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s\ngot  %+v\nwant %+v", s.EventType, got, tc.want)
+		}
+	}
+}
+
+func TestWinlogbeatObservables(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want []meta.Observable
+	}{
+		{
+			`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"event_id":1,"event_data":{"Image":"C:\\Temp\\a.exe","Hashes":"SHA1=DA39A3EE5E6B4B0D3255BFEF95601890AFD80709,MD5=D41D8CD98F00B204E9800998ECF8427E,SHA256=E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855,IMPHASH=00000000000000000000000000000000"}}}`,
+			[]meta.Observable{
+				{Kind: meta.ObservableSHA1, Value: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+				{Kind: meta.ObservableMD5, Value: "d41d8cd98f00b204e9800998ecf8427e"},
+				{Kind: meta.ObservableSHA256, Value: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			},
+		},
+		{
+			`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"event_id":22,"event_data":{"QueryName":"Evil.Example","QueryResults":"::ffff:192.0.2.10;"}}}`,
+			[]meta.Observable{{Kind: meta.ObservableDomain, Value: "evil.example"}},
+		},
+		{
+			`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"event_id":7,"event_data":{"ImageLoaded":"C:\\Windows\\System32\\evil.dll"}}}`,
+			[]meta.Observable{
+				{Kind: meta.ObservableFilename, Value: `C:\Windows\System32\evil.dll`},
+				{Kind: meta.ObservableFilename, Value: "evil.dll"},
+			},
+		},
+		{
+			`{"@timestamp":"2020-04-14T10:00:00.000Z","winlog":{"event_id":3,"event_data":{"SourceIp":"10.0.0.5","DestinationIp":"::ffff:192.0.2.10"}}}`,
+			[]meta.Observable{{Kind: meta.ObservableIP, Value: "192.0.2.10"}},
+		},
+	} {
+		var obj atomic.DynamicWinlogbeat
+		if err := json.Unmarshal([]byte(tc.raw), &obj); err != nil {
+			t.Fatal(err)
+		}
+		d := DynamicWinlogbeat{DynamicWinlogbeat: obj}
+		if got := d.Observables(); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("%s\ngot  %+v\nwant %+v", tc.raw, got, tc.want)
+		}
+	}
+}
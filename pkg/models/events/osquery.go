@@ -0,0 +1,184 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+	"github.com/markuskont/go-sigma-rule-engine/pkg/sigma"
+)
+
+// Osquery is scheduled query result from osqueryd
+type Osquery struct {
+	atomic.Osquery
+	GameMeta meta.GameAsset `json:"GameMeta,omitempty"`
+}
+
+// Key returns query name in form that is safe for kafka topics and elastic indices, so outputs can split per query
+// pack queries are named pack_<pack>_<query> by osquery
+func (o Osquery) Key() string {
+	name := strings.ToLower(o.Name)
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// DumpEventData implements EventDataDumper
+func (o Osquery) DumpEventData() *meta.EventData {
+	return &meta.EventData{
+		Key:    o.Name,
+		Fields: o.GetMessage(),
+	}
+}
+
+// osqueryMessageColumns are columns that describe a row best in common osquery tables
+var osqueryMessageColumns = []string{"path", "cmdline", "name", "remote_address", "username", "sha256"}
+
+// GetMessage implements MessageGetter
+func (o Osquery) GetMessage() []string {
+	out := []string{o.Name}
+	for _, key := range osqueryMessageColumns {
+		if val, ok := o.Column(key); ok && val != "" {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+// GetField returns a success status and arbitrary field content if requested map key is present
+// columns are accessible by bare name or under columns prefix, value is taken from first row that has it
+func (o Osquery) GetField(key string) (interface{}, bool) {
+	switch key {
+	case "name":
+		return o.Name, true
+	case "hostIdentifier":
+		return o.HostIdentifier, true
+	case "action":
+		if o.Action == "" && len(o.Snapshot) > 0 {
+			return atomic.OsquerySnapshot, true
+		}
+		return o.Action, o.Action != ""
+	case "calendarTime":
+		return o.CalendarTime, o.CalendarTime != ""
+	case "unixTime", "epoch", "counter":
+		return o.number(key)
+	}
+	if strings.HasPrefix(key, "decorations.") {
+		val, ok := o.Decorations[strings.TrimPrefix(key, "decorations.")]
+		return val, ok
+	}
+	if val, ok := o.Column(strings.TrimPrefix(key, "columns.")); ok {
+		return val, true
+	}
+	return eventField(key, &o.GameMeta)
+}
+
+func (o Osquery) number(key string) (interface{}, bool) {
+	var num json.Number
+	switch key {
+	case "unixTime":
+		num = o.UnixTime
+	case "epoch":
+		num = o.Epoch
+	case "counter":
+		num = o.Counter
+	}
+	if val, err := num.Int64(); err == nil {
+		return val, true
+	}
+	return nil, false
+}
+
+// SigmaRecords implements SigmaRecordGetter
+// every snapshot or batched differential row is matched separately, with action of its own
+func (o Osquery) SigmaRecords() []sigma.EventChecker {
+	rows := o.Rows()
+	out := make([]sigma.EventChecker, len(rows))
+	for i := range rows {
+		out[i] = OsqueryRow{event: &o, result: rows[i]}
+	}
+	return out
+}
+
+// OsqueryRow is a single result row of osquery event as seen by sigma rules
+type OsqueryRow struct {
+	event  *Osquery
+	result atomic.OsqueryResult
+}
+
+// GetMessage implements MessageGetter
+func (r OsqueryRow) GetMessage() []string {
+	out := []string{r.event.Name}
+	for _, key := range osqueryMessageColumns {
+		if val, ok := r.result.Row.String(key); ok && val != "" {
+			out = append(out, val)
+		}
+	}
+	return out
+}
+
+// GetField returns a success status and arbitrary field content if requested map key is present
+func (r OsqueryRow) GetField(key string) (interface{}, bool) {
+	if key == "action" {
+		return r.result.Action, r.result.Action != ""
+	}
+	if val, ok := r.result.Row.String(strings.TrimPrefix(key, "columns.")); ok {
+		return val, true
+	}
+	return r.event.GetField(key)
+}
+
+// JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
+func (o Osquery) JSONFormat() ([]byte, error) { return json.Marshal(o) }
+
+// GetAsset is a getter for receiving event source and target information
+// For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
+// Should provide needed information for doing external asset table lookups
+// socket tables, such as process_open_sockets, name local and remote address of connection
+func (o Osquery) GetAsset() *meta.GameAsset {
+	m := &meta.GameAsset{
+		Asset: meta.Asset{Host: o.HostIdentifier},
+		MitreAttack: &meta.MitreAttack{
+			Techniques: make([]meta.Technique, 0),
+		},
+	}
+	ip := func(key string) net.IP {
+		if val, ok := o.Column(key); ok {
+			if ip := net.ParseIP(val); ip != nil && !ip.IsUnspecified() && !ip.IsLoopback() {
+				return ip
+			}
+		}
+		return nil
+	}
+	if remote := ip("remote_address"); remote != nil {
+		m.Source = &meta.Asset{Host: o.HostIdentifier, IP: ip("local_address")}
+		m.Destination = &meta.Asset{IP: remote}
+	}
+	return m
+}
+
+// SetAsset is a setter for setting meta to object without knowing the object type
+// all asset lookups and field discoveries should be done before using this method to maintain readability
+func (o *Osquery) SetAsset(data meta.GameAsset) {
+	o.GameMeta = data
+}
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (o Osquery) Time() time.Time { return o.Osquery.Time() }
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (o Osquery) Source() string { return o.Osquery.Source() }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (o Osquery) Sender() string { return o.Osquery.Sender() }
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+)
+
+func TestOsquery(t *testing.T) {
+	diff, err := atomic.NewOsquery([]byte(`{"name":"pack/incident/open_sockets","hostIdentifier":"ws01","calendarTime":"Tue Apr 14 10:00:00 2020 UTC","unixTime":1586858400,"epoch":0,"counter":3,"numerics":false,"decorations":{"os":"ubuntu"},"columns":{"pid":"4343","path":"/usr/bin/nc","local_address":"10.0.0.5","remote_address":"192.0.2.10","remote_port":"4444"},"action":"added"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := Osquery{Osquery: *diff}
+	if o.Key() != "pack_incident_open_sockets" {
+		t.Fatalf("bad key %s", o.Key())
+	}
+	if want := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC); !o.Time().Equal(want) {
+		t.Fatalf("got ts %s, want %s", o.Time(), want)
+	}
+	for key, want := range map[string]interface{}{
+		"action":         "added",
+		"path":           "/usr/bin/nc",
+		"columns.pid":    "4343",
+		"decorations.os": "ubuntu",
+		"counter":        int64(3),
+		"hostIdentifier": "ws01",
+	} {
+		if val, ok := o.GetField(key); !ok || val != want {
+			t.Fatalf("%s: got %v, want %v", key, val, want)
+		}
+	}
+	m := o.GetAsset()
+	if m.Host != "ws01" || m.Destination == nil || m.Destination.IP.String() != "192.0.2.10" || m.Source.IP.String() != "10.0.0.5" {
+		t.Fatalf("bad asset %+v", m)
+	}
+
+	snap, err := atomic.NewOsquery([]byte(`{"name":"users","hostIdentifier":"srv01","unixTime":"1586858400","numerics":true,"snapshot":[{"username":"root","uid":0},{"username":"bob","uid":1000}],"action":"snapshot"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o = Osquery{Osquery: *snap}
+	records := o.SigmaRecords()
+	if len(records) != 2 {
+		t.Fatalf("expected a record per snapshot row, got %d", len(records))
+	}
+	if val, _ := records[1].GetField("uid"); val != "1000" {
+		t.Fatalf("numeric column should be matched as string, got %v", val)
+	}
+	if val, _ := records[1].GetField("action"); val != "snapshot" {
+		t.Fatalf("bad row action %v", val)
+	}
+	if users := o.Users(); len(users) != 2 || users[1] != "bob" {
+		t.Fatalf("bad users %v", users)
+	}
+
+	if _, err := atomic.NewOsquery([]byte(`{"hostIdentifier":"ws01","severity":0,"message":"osqueryd started"}`)); err == nil {
+		t.Fatal("status log should not be parsed as result")
+	}
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+func TestSuricataUnknownSections(t *testing.T) {
+	raw := `{"timestamp":"2020-04-14T10:00:00.000000+0000","event_type":"alert","src_ip":"10.0.0.5","dest_ip":"192.0.2.10","alert":{"signature_id":2000001,"signature":"test"},"http2":{"stream_id":1,"request":{"settings":[{"settings_id":"SETTINGS_MAX_FRAME_SIZE","settings_value":16384}]}},"quic":{"version":"1","sni":"example.com"},"stats":{"capture":{"kernel_packets":9007199254740993}}}`
+	var s Suricata
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Alert == nil || s.Alert.SignatureID != 2000001 || s.SrcIP == nil {
+		t.Fatalf("typed fields not decoded: %+v", s.StaticSuricataEve)
+	}
+	for key, want := range map[string]interface{}{
+		"alert.signature_id":           2000001,
+		"event_type":                   "alert",
+		"quic.sni":                     "example.com",
+		"http2.stream_id":              int64(1),
+		"stats.capture.kernel_packets": int64(9007199254740993),
+	} {
+		if val, ok := s.GetField(key); !ok || val != want {
+			t.Fatalf("%s: got %v (%T), want %v", key, val, val, want)
+		}
+	}
+	if val, ok := s.GetField("quic"); !ok || val == nil {
+		t.Fatal("bare unknown section should be reachable")
+	}
+
+	s.SetAsset(meta.GameAsset{Asset: meta.Asset{Host: "sensor"}})
+	data, err := s.JSONFormat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("invalid output %s: %s", string(data), err)
+	}
+	for _, key := range []string{"alert", "http2", "quic", "stats", "GameMeta"} {
+		if _, ok := out[key]; !ok {
+			t.Fatalf("%s missing from %s", key, string(data))
+		}
+	}
+	if string(out["stats"]) != `{"capture":{"kernel_packets":9007199254740993}}` {
+		t.Fatalf("unknown section changed on round-trip: %s", string(out["stats"]))
+	}
+
+	var again Suricata
+	if err := json.Unmarshal(data, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.GameMeta.Host != "sensor" || len(again.Extra) != 3 {
+		t.Fatalf("bad decode of emitted event: %+v", again)
+	}
+}
+
+func TestSuricataMitreAttack(t *testing.T) {
+	for _, tc := range []struct {
+		metadata string
+		ids      []string
+		phases   []string
+	}{
+		{`{"mitre_technique_id":["T1190"],"mitre_technique_name":["Exploit_Public_Facing_Application"],"mitre_tactic_id":["TA0001"],"mitre_tactic_name":["Initial_Access"]}`, []string{"T1190"}, []string{"initial-access"}},
+		{`{"mitre_technique_id":["t1059.001"],"mitre_tactic_id":["TA0002","TA9999"]}`, []string{"T1059.001"}, []string{"execution"}},
+		{`{"mitre_technique_id":["T1071"],"mitre_tactic_name":["Command_And_Control"]}`, []string{"T1071"}, []string{"command-and-control"}},
+		{`{"mitre_tactic_id":["TA0001"]}`, nil, nil},
+	} {
+		var s Suricata
+		raw := `{"event_type":"alert","alert":{"signature_id":1,"metadata":` + tc.metadata + `}}`
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			t.Fatal(err)
+		}
+		attack := s.MitreAttack()
+		if tc.ids == nil {
+			if attack != nil {
+				t.Fatalf("%s: expected no techniques, got %+v", tc.metadata, attack)
+			}
+			continue
+		}
+		if attack == nil || len(attack.Techniques) != len(tc.ids) {
+			t.Fatalf("%s: got %+v", tc.metadata, attack)
+		}
+		for i, tech := range attack.Techniques {
+			if tech.ID != tc.ids[i] {
+				t.Fatalf("%s: got technique %s, want %s", tc.metadata, tech.ID, tc.ids[i])
+			}
+			if len(tech.Phases) != len(tc.phases) || (len(tc.phases) > 0 && tech.Phases[0] != tc.phases[0]) {
+				t.Fatalf("%s: got phases %v, want %v", tc.metadata, tech.Phases, tc.phases)
+			}
+		}
+	}
+	if (Suricata{}).MitreAttack() != nil {
+		t.Fatal("event without alert should have no techniques")
+	}
+}
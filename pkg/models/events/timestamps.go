@@ -3,6 +3,8 @@ package events
 import (
 	"strconv"
 	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
 )
 
 const (
@@ -35,6 +37,17 @@ func (t *eventReceivedTimeStamp) UnmarshalJSON(b []byte) (err error) {
 	return err
 }
 
+// zeekTimeStamp is lenient, as ts key may hold unrelated values in other formats
+type zeekTimeStamp struct{ time.Time }
+
+func (t *zeekTimeStamp) UnmarshalJSON(b []byte) error {
+	var ts fields.ZeekTime
+	if err := ts.UnmarshalJSON(b); err == nil {
+		t.Time = ts.Time
+	}
+	return nil
+}
+
 func parseTimeFromByte(b []byte, fmt string) (time.Time, error) {
 	raw, err := strconv.Unquote(string(b))
 	if err != nil {
@@ -49,6 +62,7 @@ type KnownTimeStamps struct {
 	SuriTimestamp     *suricataTimeStamp      `json:"timestamp,omitempty"`
 	EventTime         *eventTimeStamp         `json:"EventTime,omitempty"`
 	EventReceivedTime *eventReceivedTimeStamp `json:"EventReceivedTime,omitempty"`
+	ZeekTimestamp     *zeekTimeStamp          `json:"ts,omitempty"`
 }
 
 func (s KnownTimeStamps) Time() time.Time {
@@ -64,5 +78,8 @@ func (s KnownTimeStamps) Time() time.Time {
 	if s.SuriTimestamp != nil {
 		return s.SuriTimestamp.Time
 	}
+	if s.ZeekTimestamp != nil && !s.ZeekTimestamp.IsZero() {
+		return s.ZeekTimestamp.Time
+	}
 	return s.Timestamp
 }
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+)
+
+// UserGetter is implemented by events that carry account names
+// windows accounts are returned as DOMAIN\user when domain is logged
+type UserGetter interface {
+	Users() []string
+}
+
+type users []string
+
+func (u users) add(name string) users {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "-" {
+		return u
+	}
+	for _, existing := range u {
+		if existing == name {
+			return u
+		}
+	}
+	return append(u, name)
+}
+
+func (u users) addDomain(domain, name string) users {
+	if domain = strings.TrimSpace(domain); domain != "" && domain != "-" && strings.TrimSpace(name) != "" {
+		return u.add(domain + `\` + name)
+	}
+	return u.add(name)
+}
+
+// Users implements UserGetter
+func (s Snoopy) Users() []string {
+	return users{}.add(s.Username).add(s.Login)
+}
+
+// Users implements UserGetter
+func (d DynamicWinlogbeat) Users() []string {
+	field := func(key string) string {
+		if val, ok := d.GetField(key); ok {
+			if s, ok := val.(string); ok {
+				return s
+			}
+		}
+		return ""
+	}
+	return users{}.
+		addDomain(field("winlog.user.domain"), field("winlog.user.name")).
+		add(field("user.name")).
+		add(field("winlog.event_data.User")).
+		addDomain(field("winlog.event_data.SubjectDomainName"), field("winlog.event_data.SubjectUserName")).
+		addDomain(field("winlog.event_data.TargetDomainName"), field("winlog.event_data.TargetUserName")).
+		add(field("winlog.event_data.AccountName"))
+}
+
+// Users implements UserGetter
+func (e Eventlog) Users() []string {
+	data := map[string]interface{}(e.EventLog.DynamicEventLog)
+	return users{}.
+		addDomain(stringField(data, "SubjectDomainName"), stringField(data, "SubjectUserName")).
+		addDomain(stringField(data, "TargetDomainName"), stringField(data, "TargetUserName")).
+		add(stringField(data, "AccountName")).
+		add(stringField(data, "User"))
+}
+
+// Users implements UserGetter
+// kerberos client and NTLM authentication names are logged for windows traffic
+func (s Suricata) Users() []string {
+	out := users{}
+	if s.Krb5 != nil {
+		out = out.add(stringField(s.Krb5, "cname"))
+	}
+	if s.SMB != nil {
+		if ntlm, ok := s.SMB["ntlmssp"].(map[string]interface{}); ok {
+			out = out.addDomain(stringField(ntlm, "domain"), stringField(ntlm, "user"))
+		}
+	}
+	return out
+}
+
+// Users implements UserGetter
+func (m MazeRunner) Users() []string {
+	return users{}.add(m.Cef.Extensions["suser"]).add(m.Cef.Extensions["duser"])
+}
+
+// Users implements UserGetter
+// kerberos client is logged as user/REALM
+func (z Zeek) Users() []string {
+	switch l := z.Log.(type) {
+	case *atomic.ZeekHTTP:
+		return users{}.add(l.Username)
+	case *atomic.ZeekKerberos:
+		name := l.Client
+		if i := strings.IndexByte(name, '/'); i != -1 {
+			name = name[:i]
+		}
+		return users{}.add(name)
+	}
+	return nil
+}
+
+// syslogUsers matches account names in common sshd, sudo, su and PAM messages
+var syslogUsers = []*regexp.Regexp{
+	regexp.MustCompile(`(?:Accepted|Failed) \S+ for (?:invalid user )?(\S+) from`),
+	regexp.MustCompile(`[Ii]nvalid user (\S+) from`),
+	regexp.MustCompile(`session (?:opened|closed) for user (\S+)`),
+	regexp.MustCompile(`^\s*(\S+) : (?:TTY|PWD)=`),
+	regexp.MustCompile(`\bUSER=(\S+)`),
+	regexp.MustCompile(`\b(?:ruser|user)=(\S+)`),
+}
+
+// Users implements UserGetter
+func (s Syslog) Users() []string {
+	out := users{}
+	for _, re := range syslogUsers {
+		for _, m := range re.FindAllStringSubmatch(s.Syslog.Message, -1) {
+			out = out.add(strings.TrimRight(m[1], ";,"))
+		}
+	}
+	return out
+}
+
+// Users implements UserGetter
+// names are only known from enriched logs and user space messages, other records carry numeric IDs
+func (a Auditd) Users() []string {
+	out := users{}
+	for _, key := range []string{"acct", "AUID", "UID", "EUID"} {
+		if val, ok := a.Field(key); ok && val != "unset" && val != "(unknown)" && val != "?" {
+			out = out.add(val)
+		}
+	}
+	return out
+}
+
+// Users implements UserGetter
+func (o Osquery) Users() []string {
+	out := users{}
+	for _, r := range o.Rows() {
+		for _, key := range []string{"username", "user"} {
+			if val, ok := r.Row.String(key); ok {
+				out = out.add(val)
+			}
+		}
+	}
+	return out
+}
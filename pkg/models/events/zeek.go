@@ -0,0 +1,160 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/ccdcoe/go-peek/pkg/models/atomic"
+	"github.com/ccdcoe/go-peek/pkg/models/meta"
+)
+
+// Zeek is a record from any supported zeek log, concrete log type is decided on parse by atomic.ParseZeekLog
+type Zeek struct {
+	Log      atomic.ZeekLog
+	GameMeta meta.GameAsset
+}
+
+// DumpEventData implements EventDataDumper
+func (z Zeek) DumpEventData() *meta.EventData {
+	return &meta.EventData{
+		Key:    z.Source(),
+		Fields: z.GetMessage(),
+	}
+}
+
+// GetMessage implements MessageGetter
+// returns most descriptive fields of each log, as zeek has no message field outside of notices
+func (z Zeek) GetMessage() []string {
+	var out []string
+	switch l := z.Log.(type) {
+	case *atomic.ZeekConn:
+		out = []string{l.Service, l.ConnState, l.History}
+	case *atomic.ZeekDNS:
+		out = append([]string{l.Query, l.QTypeName, l.RCodeName}, l.Answers...)
+	case *atomic.ZeekHTTP:
+		out = []string{l.Method, l.Host + l.URI, l.UserAgent}
+	case *atomic.ZeekSSL:
+		out = []string{l.ServerName, l.Subject, l.Issuer}
+	case *atomic.ZeekX509:
+		out = append([]string{l.CertificateSubject, l.CertificateIssuer}, l.SANDNS...)
+	case *atomic.ZeekFiles:
+		out = []string{l.Filename, l.MimeType, l.SHA256}
+	case *atomic.ZeekNotice:
+		out = []string{l.Note, l.Msg, l.Sub}
+	case *atomic.ZeekWeird:
+		out = []string{l.Name, l.Addl}
+	case *atomic.ZeekSSH:
+		out = []string{l.Client, l.Server}
+	case *atomic.ZeekSMBFiles:
+		out = []string{l.Action, l.FilePath, l.Name}
+	case *atomic.ZeekSMBMapping:
+		out = []string{l.FilePath, l.Service, l.ShareType}
+	case *atomic.ZeekKerberos:
+		out = []string{l.RequestType, l.Client, l.Service, l.ErrorMsg}
+	}
+	msg := make([]string, 0, len(out))
+	for _, item := range out {
+		if item != "" && item != "-" {
+			msg = append(msg, item)
+		}
+	}
+	return msg
+}
+
+// GetField returns a success status and arbitrary field content if requested map key is present
+// keys are as in zeek logs, e.g. id.orig_h or certificate.subject
+func (z Zeek) GetField(key string) (interface{}, bool) {
+	return eventField(key, &z.GameMeta, z.Log)
+}
+
+// MarshalJSON keeps zeek fields at top level, next to GameMeta
+func (z Zeek) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(z.Log)
+	if err != nil {
+		return nil, err
+	}
+	m, err := json.Marshal(z.GameMeta)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) < 2 || data[len(data)-1] != '}' {
+		return data, nil
+	}
+	out := make([]byte, 0, len(data)+len(m)+16)
+	out = append(out, data[:len(data)-1]...)
+	if len(data) > 2 {
+		out = append(out, ',')
+	}
+	out = append(out, `"GameMeta":`...)
+	out = append(out, m...)
+	return append(out, '}'), nil
+}
+
+// JSONFormat implements atomic.JSONFormatter by wrapping json.Marshal
+func (z Zeek) JSONFormat() ([]byte, error) { return json.Marshal(z) }
+
+// endpoints returns connection originator and responder
+// files and notices that are not bound to a connection carry their own address fields
+func (z Zeek) endpoints() (net.IP, net.IP) {
+	b := z.Log.Base()
+	src, dst := stringIP(b.IDOrigH), stringIP(b.IDRespH)
+	switch l := z.Log.(type) {
+	case *atomic.ZeekFiles:
+		if src == nil && len(l.TxHosts) > 0 {
+			src = l.TxHosts[0].IP
+		}
+		if dst == nil && len(l.RxHosts) > 0 {
+			dst = l.RxHosts[0].IP
+		}
+	case *atomic.ZeekNotice:
+		if src == nil {
+			src = stringIP(l.Src)
+		}
+		if dst == nil {
+			dst = stringIP(l.Dst)
+		}
+	}
+	return src, dst
+}
+
+// GetAsset is a getter for receiving event source and target information
+// For exampe, event source for syslog is usually the shipper, while suricata alert has affected source and destination IP addresses whereas directionality matters
+// Should provide needed information for doing external asset table lookups
+func (z Zeek) GetAsset() *meta.GameAsset {
+	srcFn := func(ip net.IP) *meta.Asset {
+		if ip != nil {
+			return &meta.Asset{IP: ip}
+		}
+		return nil
+	}
+	src, dst := z.endpoints()
+	return &meta.GameAsset{
+		Asset: meta.Asset{Host: z.Sender()},
+		MitreAttack: &meta.MitreAttack{
+			Techniques: make([]meta.Technique, 0),
+		},
+		Source:      srcFn(src),
+		Destination: srcFn(dst),
+	}
+}
+
+// SetAsset is a setter for setting meta to object without knowing the object type
+// all asset lookups and field discoveries should be done before using this method to maintain readability
+func (z *Zeek) SetAsset(data meta.GameAsset) {
+	z.GameMeta = data
+}
+
+// Time implements atomic.Event
+// Timestamp in event, should default to time.Time{} so time.IsZero() could be used to verify success
+func (z Zeek) Time() time.Time { return z.Log.Time() }
+
+// Source implements atomic.Event
+// Source of message, usually emitting program
+func (z Zeek) Source() string { return z.Log.Source() }
+
+// Sender implements atomic.Event
+// Sender of message, usually a host
+func (z Zeek) Sender() string { return z.Log.Sender() }
@@ -1,6 +1,8 @@
 package fields
 
 import (
+	"encoding/json"
+	"math"
 	"net"
 	"strconv"
 	"strings"
@@ -10,10 +12,17 @@ import (
 type StringNet struct{ net.IPNet }
 
 func (t *StringNet) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
 	raw, err := strconv.Unquote(string(b))
 	if err != nil {
 		return err
 	}
+	if raw == "" {
+		// pandas exports missing IPv4 or IPv6 range as empty string
+		return nil
+	}
 	_, net, err := net.ParseCIDR(strings.Replace(raw, `\`, "", 2))
 
 	if err != nil {
@@ -23,6 +32,10 @@ func (t *StringNet) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (t StringNet) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.IPNet.String())), nil
+}
+
 type StringIP struct{ net.IP }
 
 func (t *StringIP) UnmarshalJSON(b []byte) error {
@@ -34,6 +47,16 @@ func (t *StringIP) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON writes canonical notation, so addresses set without ParseIP are emitted the same way as decoded ones
+func (t StringIP) MarshalJSON() ([]byte, error) {
+	if t.IP == nil {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(CanonicalIP(t.IP).String())
+}
+
+// QuotedRFC3339 is suricata eve timestamp
+// numeric zone offset without colon is written by suricata, RFC3339 is accepted as well for decoding events emitted by peek
 type QuotedRFC3339 struct{ time.Time }
 
 func (t *QuotedRFC3339) UnmarshalJSON(b []byte) error {
@@ -41,16 +64,84 @@ func (t *QuotedRFC3339) UnmarshalJSON(b []byte) error {
 	if err != nil {
 		return err
 	}
-	t.Time, err = time.Parse("2006-01-02T15:04:05.999999-0700", raw)
+	if t.Time, err = time.Parse("2006-01-02T15:04:05.999999-0700", raw); err != nil {
+		if ts, rfcErr := time.Parse(time.RFC3339Nano, raw); rfcErr == nil {
+			t.Time, err = ts, nil
+		}
+	}
 	return err
 }
 
+// ZeekTime is zeek log timestamp
+// epoch seconds with fraction are written by default, ISO8601 strings if LogAscii::json_timestamps is changed
+type ZeekTime struct{ time.Time }
+
+func (t *ZeekTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	raw := string(b)
+	if strings.HasPrefix(raw, `"`) {
+		var err error
+		if raw, err = strconv.Unquote(raw); err != nil {
+			return err
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			t.Time = ts
+			return nil
+		}
+	}
+	epoch, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return err
+	}
+	t.Time = ParseEpoch(epoch)
+	return nil
+}
+
 // Helpers
 
+// ParseEpoch converts unix seconds with fraction into UTC time, precision is kept up to microseconds
+func ParseEpoch(epoch float64) time.Time {
+	sec, frac := math.Modf(epoch)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3).UTC()
+}
+
 func ParseStringIP(textual string) (net.IP, error) {
 	raw, err := strconv.Unquote(textual)
 	if err != nil {
 		return nil, err
 	}
-	return net.ParseIP(raw), nil
+	return ParseIP(raw), nil
+}
+
+// ParseIP parses textual address into canonical form
+// IPv6 zone index is dropped as it is meaningless outside originating host
+func ParseIP(raw string) net.IP {
+	raw = strings.TrimSpace(raw)
+	if i := strings.IndexByte(raw, '%'); i != -1 {
+		raw = raw[:i]
+	}
+	return CanonicalIP(net.ParseIP(raw))
+}
+
+// CanonicalIP returns 4-byte form for IPv4 and IPv4-mapped IPv6 addresses and 16-byte form otherwise
+// so 10.0.0.1 and ::ffff:10.0.0.1 compare and print the same
+func CanonicalIP(ip net.IP) net.IP {
+	if ip == nil {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip.To16()
+}
+
+// NormalizeIP rewrites textual address into canonical notation
+// value is returned as-is if it is not an address
+func NormalizeIP(raw string) string {
+	if ip := ParseIP(raw); ip != nil {
+		return ip.String()
+	}
+	return raw
 }
@@ -1,6 +1,10 @@
 package meta
 
-import "net"
+import (
+	"net"
+
+	"github.com/ccdcoe/go-peek/pkg/models/fields"
+)
 
 type Indicators struct {
 	IsAsset bool `json:"is_asset"`
@@ -12,5 +16,36 @@ type Asset struct {
 	OS    string `json:"OS"`
 	VM    string `json:"VM"`
 	IP    net.IP `json:"IP"`
+	// MAC is client hardware address from DHCP lease that was valid at event time
+	MAC string `json:"MAC,omitempty"`
+	// Net is set when entry describes a whole range rather than a single address
+	// lookups fall back to longest matching network if address itself is not known
+	Net *fields.StringNet `json:"Net,omitempty"`
+	// Network is exercise network segment that address belongs to, set for assets and non-assets alike
+	Network *NetSegment `json:"Network,omitempty"`
+	// DNSNames are names that address was resolved from in observed DNS traffic, most recent first
+	DNSNames []string `json:"DNSNames,omitempty"`
+	// Geo is location and autonomous system of external address
+	Geo *Geo `json:"Geo,omitempty"`
 	Indicators
 }
+
+// Geo is GeoIP and ASN information for an address
+type Geo struct {
+	Country    string  `json:"country,omitempty"`
+	CountryISO string  `json:"country_iso,omitempty"`
+	City       string  `json:"city,omitempty"`
+	Lat        float64 `json:"lat,omitempty"`
+	Lon        float64 `json:"lon,omitempty"`
+	ASN        uint    `json:"asn,omitempty"`
+	Org        string  `json:"org,omitempty"`
+}
+
+// Process is an ancestor of process that generated event, as seen in process creation logs
+type Process struct {
+	PID         int    `json:"PID,omitempty"`
+	GUID        string `json:"GUID,omitempty"`
+	Image       string `json:"Image,omitempty"`
+	CommandLine string `json:"CommandLine,omitempty"`
+	User        string `json:"User,omitempty"`
+}
@@ -0,0 +1,84 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Observable kinds, named after Moloch WISE query types
+const (
+	ObservableIP     = "ip"
+	ObservableDomain = "domain"
+	ObservableMD5    = "md5"
+	ObservableSHA256 = "sha256"
+	ObservableEmail  = "email"
+	ObservableURL    = "url"
+	// not served by WISE, but common in indicator lists
+	ObservableSHA1     = "sha1"
+	ObservableFilename = "filename"
+)
+
+// Observable is a value in event that can be looked up from threat intel sources
+type Observable struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// IntelMatch is threat intel returned for a single observable
+type IntelMatch struct {
+	Observable
+	Fields map[string]string `json:"fields"`
+}
+
+// IOCMatch is an observable in event that was found in a local indicator list
+type IOCMatch struct {
+	Observable
+	// Indicator is matched list entry, differs from observable value for CIDR ranges
+	Indicator   string `json:"indicator"`
+	Source      string `json:"source"`
+	Description string `json:"description,omitempty"`
+}
+
+// Intel holds threat intel matches for event
+// matches are serialized into GameMeta under Key, so field name can follow naming used in intel source
+type Intel struct {
+	Key     string
+	Matches []IntelMatch
+}
+
+// Add appends a match, duplicate observables are ignored
+func (i *Intel) Add(match IntelMatch) *Intel {
+	for _, m := range i.Matches {
+		if m.Observable == match.Observable {
+			return i
+		}
+	}
+	i.Matches = append(i.Matches, match)
+	return i
+}
+
+// MarshalJSON implements json.Marshaler
+// intel key is configurable, so it is spliced into otherwise static GameMeta object
+func (g GameAsset) MarshalJSON() ([]byte, error) {
+	type alias GameAsset
+	data, err := json.Marshal(alias(g))
+	if err != nil || g.Intel == nil || g.Intel.Key == "" || len(g.Intel.Matches) == 0 {
+		return data, err
+	}
+	key, err := json.Marshal(g.Intel.Key)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := json.Marshal(g.Intel.Matches)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	buf.WriteByte(',')
+	buf.Write(key)
+	buf.WriteByte(':')
+	buf.Write(matches)
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,33 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIntelMarshal(t *testing.T) {
+	g := GameAsset{Intel: &Intel{Key: "peek"}}
+	g.Intel.Add(IntelMatch{
+		Observable: Observable{Kind: ObservableDomain, Value: "evil.example"},
+		Fields:     map[string]string{"threat": "c2"},
+	})
+	g.Intel.Add(IntelMatch{Observable: Observable{Kind: ObservableDomain, Value: "evil.example"}})
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatalf("invalid JSON %s: %s", string(data), err)
+	}
+	matches, ok := obj["peek"].([]interface{})
+	if !ok || len(matches) != 1 {
+		t.Fatalf("expected single intel match under peek key, got %s", string(data))
+	}
+	if _, ok := obj["EventType"]; !ok {
+		t.Fatalf("static GameMeta fields missing, got %s", string(data))
+	}
+	if data, _ := json.Marshal(GameAsset{}); json.Unmarshal(data, &obj) != nil {
+		t.Fatalf("GameMeta without intel should remain valid JSON, got %s", string(data))
+	}
+}
@@ -34,6 +34,17 @@ type GameAsset struct {
 
 	Source      *Asset `json:"Src"`
 	Destination *Asset `json:"Dest"`
+
+	// CommunityID is flow hash for pivoting between sensors, either from sensor or computed from 5-tuple
+	CommunityID string `json:"CommunityID,omitempty"`
+
+	// Ancestors is process chain that led to event, parent first
+	Ancestors []Process `json:"Ancestors,omitempty"`
+
+	// Intel is serialized under its own configurable key, see MarshalJSON
+	Intel *Intel `json:"-"`
+	// IOC lists indicator list matches
+	IOC []IOCMatch `json:"IOC,omitempty"`
 }
 
 func (g *GameAsset) SetDirection() *GameAsset {
@@ -20,25 +20,43 @@ type MitreAttack struct {
 
 func (m *MitreAttack) Set(mapping Techniques) *MitreAttack {
 	if m.Techniques != nil && len(m.Techniques) > 0 {
-		if mapping != nil {
-			for i, t := range m.Techniques {
-				if val, ok := mapping[t.ID]; ok {
-					m.Techniques[i] = Technique{
-						ID:     t.ID,
-						Name:   val.Name,
-						Phases: val.Phases,
-					}
-				}
+		for i, t := range m.Techniques {
+			// sub-techniques missing from mapping fall back to their parent
+			if val, ok := mapping.Get(t.ID); ok {
+				m.Techniques[i] = val
 			}
 		}
 
 		m.Name = fmt.Sprintf("%s: %s", m.Techniques[0].ID, m.Techniques[0].Name)
 		m.ID = m.Techniques[0].ID
-		m.Phases = m.Techniques[0].Phases
+		m.Parent = m.Techniques[0].Parent
 		m.Items = make([]string, len(m.Techniques))
 		for i, t := range m.Techniques {
 			m.Items[i] = t.Name
+			for _, phase := range t.Phases {
+				m.Phases = appendUnique(m.Phases, phase)
+			}
+		}
+	}
+	return m
+}
+
+// Add appends techniques that are not already present, deduplicated by technique ID
+func (m *MitreAttack) Add(techniques ...Technique) *MitreAttack {
+	if m.Techniques == nil {
+		m.Techniques = make([]Technique, 0, len(techniques))
+	}
+outer:
+	for _, t := range techniques {
+		if t.ID == "" {
+			continue outer
 		}
+		for _, existing := range m.Techniques {
+			if existing.ID == t.ID {
+				continue outer
+			}
+		}
+		m.Techniques = append(m.Techniques, t)
 	}
 	return m
 }
@@ -50,15 +68,19 @@ func (m *MitreAttack) ParseSigmaTags(results sigma.Results, mapping Techniques)
 
 	for _, res := range results {
 		for _, tag := range res.Tags {
-			if strings.HasPrefix(tag, "attack.t") {
-				if id := strings.Split(tag, "."); len(id) == 2 && len(id[1]) == 5 {
-					key := strings.ToUpper(id[1])
-					if mapping == nil {
-						m.Techniques = append(m.Techniques, Technique{ID: key})
-					} else if val, ok := mapping[key]; ok {
-						m.Techniques = append(m.Techniques, val)
-					}
+			if !strings.HasPrefix(tag, "attack.") {
+				continue
+			}
+			value := strings.ToLower(strings.TrimPrefix(tag, "attack."))
+			if IsTechniqueID(value) {
+				key := strings.ToUpper(value)
+				if mapping == nil {
+					m.Add(Technique{ID: key})
+				} else if val, ok := mapping.Get(key); ok {
+					m.Add(val)
 				}
+			} else if phase, ok := TagToPhase(value); ok {
+				m.Phases = appendUnique(m.Phases, phase)
 			}
 		}
 	}
@@ -69,18 +91,51 @@ type Technique struct {
 	ID     string
 	Name   string
 	Phases []string
+
+	// Parent is the technique ID of a sub-technique parent, e.g. T1059 for T1059.001
+	Parent       string `json:"Parent,omitempty"`
+	SubTechnique bool   `json:"SubTechnique,omitempty"`
+	Deprecated   bool   `json:"Deprecated,omitempty"`
 }
 
 type Techniques map[string]Technique
 
-func NewTechniquesFromJSONfile(path string) (Techniques, error) {
+// Get looks up a technique by ID
+// unknown sub-techniques fall back to parent technique, as mapping files may lag behind rule sets
+func (t Techniques) Get(id string) (Technique, bool) {
+	if t == nil {
+		return Technique{}, false
+	}
+	if val, ok := t[id]; ok {
+		return val, true
+	}
+	if parent := ParentTechniqueID(id); parent != "" {
+		if val, ok := t[parent]; ok {
+			return Technique{
+				ID:           id,
+				Name:         val.Name,
+				Phases:       val.Phases,
+				Parent:       parent,
+				SubTechnique: true,
+				Deprecated:   val.Deprecated,
+			}, true
+		}
+	}
+	return Technique{}, false
+}
+
+// NewTechniquesFromFile loads techniques from either a STIX 2 bundle or a pre-digested JSON map
+func NewTechniquesFromFile(path string) (Techniques, error) {
 	path, err := utils.ExpandHome(path)
 	if err != nil {
 		return nil, err
 	}
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		fmt.Print(err)
+		return nil, err
+	}
+	if isSTIXBundle(data) {
+		return NewTechniquesFromSTIX(data)
 	}
 	var t Techniques
 	if err := json.Unmarshal(data, &t); err != nil {
@@ -88,3 +143,83 @@ func NewTechniquesFromJSONfile(path string) (Techniques, error) {
 	}
 	return t, nil
 }
+
+// NewTechniquesFromJSONfile is kept for existing callers, format is detected by NewTechniquesFromFile
+func NewTechniquesFromJSONfile(path string) (Techniques, error) { return NewTechniquesFromFile(path) }
+
+// IsTechniqueID checks if string is a technique or sub-technique ID, such as t1059 or T1059.001
+func IsTechniqueID(id string) bool {
+	if len(id) != 5 && len(id) != 9 {
+		return false
+	}
+	if id[0] != 't' && id[0] != 'T' {
+		return false
+	}
+	for i, r := range id[1:] {
+		if i == 4 {
+			if r != '.' {
+				return false
+			}
+			continue
+		}
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParentTechniqueID returns parent ID for sub-technique, or empty string if ID is not a sub-technique
+func ParentTechniqueID(id string) string {
+	if i := strings.Index(id, "."); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// TagToPhase converts a sigma tactic tag, such as initial_access, into ATT&CK kill chain phase name
+func TagToPhase(tag string) (string, bool) {
+	phase := strings.Replace(strings.ToLower(tag), "_", "-", -1)
+	if _, ok := Tactics[phase]; ok {
+		return phase, true
+	}
+	return "", false
+}
+
+// TacticIDToPhase converts a tactic ID, such as TA0001, into ATT&CK kill chain phase name
+func TacticIDToPhase(id string) (string, bool) {
+	id = strings.ToUpper(id)
+	for phase, tactic := range Tactics {
+		if tactic == id {
+			return phase, true
+		}
+	}
+	return "", false
+}
+
+// Tactics maps enterprise ATT&CK kill chain phase names to tactic IDs
+var Tactics = map[string]string{
+	"reconnaissance":       "TA0043",
+	"resource-development": "TA0042",
+	"initial-access":       "TA0001",
+	"execution":            "TA0002",
+	"persistence":          "TA0003",
+	"privilege-escalation": "TA0004",
+	"defense-evasion":      "TA0005",
+	"credential-access":    "TA0006",
+	"discovery":            "TA0007",
+	"lateral-movement":     "TA0008",
+	"collection":           "TA0009",
+	"command-and-control":  "TA0011",
+	"exfiltration":         "TA0010",
+	"impact":               "TA0040",
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,101 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/ccdcoe/go-peek/pkg/utils"
+)
+
+// stixBundle is a minimal subset of STIX 2 bundle, as published in mitre/cti repository
+type stixBundle struct {
+	Type    string       `json:"type"`
+	Objects []stixObject `json:"objects"`
+}
+
+type stixObject struct {
+	Type               string                  `json:"type"`
+	ID                 string                  `json:"id"`
+	Name               string                  `json:"name"`
+	Revoked            bool                    `json:"revoked"`
+	Deprecated         bool                    `json:"x_mitre_deprecated"`
+	IsSubtechnique     bool                    `json:"x_mitre_is_subtechnique"`
+	KillChainPhases    []stixKillChainPhase    `json:"kill_chain_phases"`
+	ExternalReferences []stixExternalReference `json:"external_references"`
+}
+
+func (o stixObject) attackID() string {
+	for _, ref := range o.ExternalReferences {
+		if ref.SourceName == "mitre-attack" && ref.ExternalID != "" {
+			return ref.ExternalID
+		}
+	}
+	return ""
+}
+
+type stixKillChainPhase struct {
+	KillChainName string `json:"kill_chain_name"`
+	PhaseName     string `json:"phase_name"`
+}
+
+type stixExternalReference struct {
+	SourceName string `json:"source_name"`
+	ExternalID string `json:"external_id"`
+}
+
+// NewTechniquesFromSTIX builds technique mapping from raw STIX 2 bundle
+// sub-techniques are linked to their parents and revoked or deprecated items are kept but flagged
+func NewTechniquesFromSTIX(data []byte) (Techniques, error) {
+	var bundle stixBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, utils.ErrDecodeJson{Err: err, Raw: truncate(data, 256)}
+	}
+	t := make(Techniques)
+	for _, obj := range bundle.Objects {
+		switch obj.Type {
+		case "attack-pattern":
+			id := obj.attackID()
+			if id == "" {
+				continue
+			}
+			technique := Technique{
+				ID:           id,
+				Name:         obj.Name,
+				Phases:       make([]string, 0, len(obj.KillChainPhases)),
+				SubTechnique: obj.IsSubtechnique,
+				Deprecated:   obj.Deprecated || obj.Revoked,
+			}
+			for _, phase := range obj.KillChainPhases {
+				if phase.KillChainName == "mitre-attack" {
+					technique.Phases = appendUnique(technique.Phases, phase.PhaseName)
+				}
+			}
+			if technique.SubTechnique || strings.Contains(id, ".") {
+				technique.SubTechnique = true
+				technique.Parent = ParentTechniqueID(id)
+			}
+			// revoked duplicates should not shadow live techniques with the same ID
+			if existing, ok := t[id]; ok && !existing.Deprecated && technique.Deprecated {
+				continue
+			}
+			t[id] = technique
+		}
+	}
+	return t, nil
+}
+
+func isSTIXBundle(data []byte) bool {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return bytes.Contains(head, []byte(`"bundle"`))
+}
+
+func truncate(data []byte, size int) []byte {
+	if len(data) > size {
+		return data[:size]
+	}
+	return data
+}
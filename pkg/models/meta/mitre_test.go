@@ -48,3 +48,97 @@ func TestSigmaToMitreWithMapping(t *testing.T) {
 		fmt.Println(tech)
 	}
 }
+
+const stixTestBundle = `{
+	"type": "bundle",
+	"id": "bundle--test",
+	"objects": [
+		{
+			"type": "x-mitre-tactic",
+			"name": "Execution",
+			"x_mitre_shortname": "execution",
+			"external_references": [{"source_name": "mitre-attack", "external_id": "TA0002"}]
+		},
+		{
+			"type": "attack-pattern",
+			"name": "Command and Scripting Interpreter",
+			"kill_chain_phases": [{"kill_chain_name": "mitre-attack", "phase_name": "execution"}],
+			"external_references": [{"source_name": "mitre-attack", "external_id": "T1059"}]
+		},
+		{
+			"type": "attack-pattern",
+			"name": "PowerShell",
+			"x_mitre_is_subtechnique": true,
+			"kill_chain_phases": [{"kill_chain_name": "mitre-attack", "phase_name": "execution"}],
+			"external_references": [{"source_name": "mitre-attack", "external_id": "T1059.001"}]
+		},
+		{
+			"type": "attack-pattern",
+			"name": "Scripting",
+			"x_mitre_deprecated": true,
+			"kill_chain_phases": [{"kill_chain_name": "mitre-attack", "phase_name": "defense-evasion"}],
+			"external_references": [{"source_name": "mitre-attack", "external_id": "T1064"}]
+		}
+	]
+}`
+
+func TestTechniquesFromSTIX(t *testing.T) {
+	mapping, err := NewTechniquesFromSTIX([]byte(stixTestBundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mapping) != 3 {
+		t.Fatalf("STIX bundle should produce 3 techniques, got %d", len(mapping))
+	}
+	sub, ok := mapping["T1059.001"]
+	if !ok {
+		t.Fatal("Sub-technique T1059.001 missing from mapping")
+	}
+	if !sub.SubTechnique || sub.Parent != "T1059" {
+		t.Fatalf("Sub-technique should link to parent T1059, got %+v", sub)
+	}
+	if len(sub.Phases) != 1 || sub.Phases[0] != "execution" {
+		t.Fatalf("Sub-technique should be in execution phase, got %+v", sub.Phases)
+	}
+	if !mapping["T1064"].Deprecated {
+		t.Fatal("T1064 should be flagged as deprecated")
+	}
+	if !isSTIXBundle([]byte(stixTestBundle)) {
+		t.Fatal("STIX bundle not detected")
+	}
+}
+
+func TestSigmaSubTechniqueTags(t *testing.T) {
+	mapping, err := NewTechniquesFromSTIX([]byte(stixTestBundle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := sigma.Result{
+		Tags: sigma.Tags([]string{
+			"attack.t1059.001",
+			"attack.t1059.005",
+			"attack.execution",
+			"attack.initial_access",
+		}),
+	}
+	a := &MitreAttack{}
+	a.ParseSigmaTags([]sigma.Result{r}, mapping)
+	if len(a.Techniques) != 2 {
+		t.Fatalf("Sigma to Mitre should output two sub-techniques, got %+v", a.Techniques)
+	}
+	if a.Techniques[1].ID != "T1059.005" || a.Techniques[1].Name != "Command and Scripting Interpreter" {
+		t.Fatalf("Unknown sub-technique should fall back to parent, got %+v", a.Techniques[1])
+	}
+	if len(a.Phases) != 2 || a.Phases[0] != "execution" || a.Phases[1] != "initial-access" {
+		t.Fatalf("Tactic tags should map to phases, got %+v", a.Phases)
+	}
+	a.Set(mapping)
+	if a.ID != "T1059.001" || a.Parent != "T1059" {
+		t.Fatalf("Set should use first sub-technique, got %+v", a.Technique)
+	}
+	b := &MitreAttack{Techniques: []Technique{{ID: "T1059.005"}}}
+	b.Set(mapping)
+	if b.Name != "T1059.005: Command and Scripting Interpreter" || b.Parent != "T1059" {
+		t.Fatalf("Set should fall back to parent of unknown sub-technique, got %+v", b.Technique)
+	}
+}
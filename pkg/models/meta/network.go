@@ -55,10 +55,14 @@ func (n Network) Shorthand() (*NetSegment, *NetSegment) {
 			ID:   n.ID,
 			net:  n.IPv4,
 			Name: n.Name,
+			VLAN: n.VLAN,
+			Team: n.Team,
 		}, &NetSegment{
 			ID:   n.ID,
 			net:  n.IPv6,
 			Name: n.Name,
+			VLAN: n.VLAN,
+			Team: n.Team,
 		}
 }
 
@@ -67,8 +71,12 @@ type NetSegment struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 	VLAN string `json:"vlan"`
+	Team string `json:"team,omitempty"`
 	net  net.IPNet
 }
 
+// Net returns the range that segment covers
+func (n NetSegment) Net() net.IPNet { return n.net }
+
 func (n NetSegment) String() string          { return n.net.String() }
 func (n NetSegment) Contains(ip net.IP) bool { return n.net.Contains(ip) }
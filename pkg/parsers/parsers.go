@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -11,6 +12,14 @@ import (
 )
 
 func Parse(data []byte, enum events.Atomic, p consumer.Parser) (interface{}, error) {
+	if enum == events.AuditdE {
+		// without assembler, every record is an event on its own
+		r, err := auditdRecord(data, p)
+		if err != nil {
+			return nil, err
+		}
+		return &events.Auditd{Auditd: *atomic.NewAuditd(r)}, nil
+	}
 	if p == consumer.RFC5424 {
 		return ParseSyslogGameEvent(data, enum)
 	}
@@ -33,7 +42,7 @@ func ParseSyslogGameEvent(data []byte, enum events.Atomic) (interface{}, error)
 		Program:   *msg.Appname(),
 	}
 	switch enum {
-	case events.EventLogE, events.SysmonE, events.SuricataE:
+	case events.EventLogE, events.SysmonE, events.SuricataE, events.ZeekE, events.OsqueryE:
 		return UnmarshalStructuredEvent([]byte(*msg.Message()), enum)
 	}
 
@@ -84,7 +93,90 @@ func UnmarshalStructuredEvent(data []byte, enum events.Atomic) (interface{}, err
 		return &events.DynamicWinlogbeat{
 			Timestamp:         obj.Time(),
 			DynamicWinlogbeat: obj,
+			Sysmon:            obj.ParseSysmon(),
 		}, nil
+	case events.ZeekE:
+		return ParseZeek(data, "", "")
+	case events.OsqueryE:
+		obj, err := atomic.NewOsquery(data)
+		if err != nil {
+			return nil, err
+		}
+		return &events.Osquery{Osquery: *obj}, nil
 	}
 	return nil, fmt.Errorf("Unsupported structured event type")
 }
+
+// ParseZeekMessage decodes zeek JSON record that is delivered as is or wrapped in syslog
+// path and source are used as in ParseZeek, as syslog relays do not carry log path either
+func ParseZeekMessage(data []byte, p consumer.Parser, path, source string) (interface{}, error) {
+	if p == consumer.RFC5424 {
+		bestEffort := true
+		msg, err := rfc5424.NewParser().Parse(data, &bestEffort)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Message() == nil {
+			return nil, fmt.Errorf("zeek syslog message without payload: %s", string(data))
+		}
+		data = []byte(*msg.Message())
+	}
+	return ParseZeek(data, path, source)
+}
+
+// ParseZeek decodes zeek JSON record
+// path is used as log type if record does not carry _path field, otherwise log type is guessed from source file or topic name
+func ParseZeek(data []byte, path, source string) (interface{}, error) {
+	if path == "" {
+		path = atomic.ZeekPathFromSource(source)
+	}
+	log, err := atomic.ParseZeekLog(data, path)
+	if err != nil {
+		return nil, err
+	}
+	return &events.Zeek{Log: log}, nil
+}
+
+// ParseAuditd parses audit record and groups it with other records of same event
+// nothing is returned until assembler completes an event, and returned events may belong to earlier records
+func ParseAuditd(data []byte, p consumer.Parser, a *atomic.AuditdAssembler) ([]*events.Auditd, error) {
+	r, err := auditdRecord(data, p)
+	if err != nil {
+		return nil, err
+	}
+	return AuditdEvents(a.Add(r)), nil
+}
+
+// AuditdEvents wraps events released by assembler
+func AuditdEvents(evs []*atomic.Auditd) []*events.Auditd {
+	out := make([]*events.Auditd, len(evs))
+	for i, ev := range evs {
+		out[i] = &events.Auditd{Auditd: *ev}
+	}
+	return out
+}
+
+// auditdRecord parses raw audit.log line, or syslog message from audispd if parser is rfc5424
+// syslog host is used as node name, so records from different hosts are not mixed up
+func auditdRecord(data []byte, p consumer.Parser) (*atomic.AuditdRecord, error) {
+	data = bytes.TrimSpace(data)
+	if p != consumer.RFC5424 || bytes.HasPrefix(data, []byte("type=")) || bytes.HasPrefix(data, []byte("node=")) {
+		return atomic.ParseAuditdRecord(string(data))
+	}
+	bestEffort := true
+	msg, err := rfc5424.NewParser().Parse(data, &bestEffort)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Message() == nil {
+		return nil, fmt.Errorf("auditd syslog message without payload: %s", string(data))
+	}
+	r, err := atomic.ParseAuditdRecord(*msg.Message())
+	if err != nil {
+		return nil, err
+	}
+	if r.Node == "" && msg.Hostname() != nil {
+		r.Node = *msg.Hostname()
+	}
+	return r, nil
+}
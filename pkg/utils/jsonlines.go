@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// WriteJSONLines dumps items to path through a temporary file, so readers never see a partial dump
+// write is called once with encoder for temporary file, previous dump is kept if anything fails
+func WriteJSONLines(path string, write func(*json.Encoder) error) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(json.NewEncoder(w)); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadJSONLines calls fn for every line in file written by WriteJSONLines
+// line buffer is reused, so fn must copy data it keeps
+func ReadJSONLines(path string, fn func([]byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// RunPersisted calls maintain and dump on every interval until context is cancelled
+// final dump is written on exit, so state survives restarts
+func RunPersisted(ctx context.Context, interval time.Duration, maintain func(), dump func() error, errs *ErrChan) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := dump(); err != nil {
+				errs.Send(err)
+			}
+			return
+		case <-tick.C:
+			if maintain != nil {
+				maintain()
+			}
+			if err := dump(); err != nil {
+				errs.Send(err)
+			}
+		}
+	}
+}
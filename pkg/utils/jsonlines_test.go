@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonlines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dump.json")
+
+	items := []string{"a", "b", "c"}
+	if err := WriteJSONLines(path, func(enc *json.Encoder) error {
+		for _, item := range items {
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSONLines(path, func(enc *json.Encoder) error {
+		enc.Encode("partial")
+		return errors.New("fail")
+	}); err == nil {
+		t.Fatal("write error should be returned")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("temporary file should be removed after failed write")
+	}
+
+	var got []string
+	if err := ReadJSONLines(path, func(line []byte) error {
+		var item string
+		if err := json.Unmarshal(line, &item); err != nil {
+			return err
+		}
+		got = append(got, item)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(items) || got[2] != "c" {
+		t.Fatalf("previous dump should survive failed write, got %v", got)
+	}
+}
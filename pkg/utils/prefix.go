@@ -0,0 +1,63 @@
+package utils
+
+import "net"
+
+type prefixNode struct {
+	child [2]*prefixNode
+	value interface{}
+}
+
+// PrefixTree is a binary trie of network ranges for longest prefix match lookups
+// IPv4 ranges are stored in IPv4-mapped IPv6 space, so both address families share one tree
+// tree is not safe for concurrent modification, build it fully before doing lookups
+type PrefixTree struct {
+	root  *prefixNode
+	count int
+}
+
+func NewPrefixTree() *PrefixTree { return &PrefixTree{root: &prefixNode{}} }
+
+// Insert stores value for network, existing value for same range is replaced
+// ranges without address are ignored
+func (t *PrefixTree) Insert(n net.IPNet, value interface{}) {
+	ip := n.IP.To16()
+	ones, bits := n.Mask.Size()
+	if ip == nil || bits == 0 {
+		return
+	}
+	if bits == 32 {
+		ones += 96
+	}
+	node := t.root
+	for i := 0; i < ones; i++ {
+		b := ip[i/8] >> (7 - uint(i%8)) & 1
+		if node.child[b] == nil {
+			node.child[b] = &prefixNode{}
+		}
+		node = node.child[b]
+	}
+	if node.value == nil {
+		t.count++
+	}
+	node.value = value
+}
+
+// Lookup returns value of most specific range containing the address
+func (t PrefixTree) Lookup(ip net.IP) (interface{}, bool) {
+	ip16 := ip.To16()
+	if t.root == nil || ip16 == nil {
+		return nil, false
+	}
+	node := t.root
+	found := node.value
+	for i := 0; i < 128 && node != nil; i++ {
+		node = node.child[ip16[i/8]>>(7-uint(i%8))&1]
+		if node != nil && node.value != nil {
+			found = node.value
+		}
+	}
+	return found, found != nil
+}
+
+// Len returns number of distinct ranges in tree
+func (t PrefixTree) Len() int { return t.count }